@@ -0,0 +1,50 @@
+package berkas
+
+import (
+    "bytes"
+    "encoding/binary"
+)
+
+// OrientasiEXIF membaca tag Orientation (0x0112) dari segmen APP1 Exif sebuah JPEG.
+// Mengembalikan 1 (normal) jika tidak ada EXIF atau datanya tidak dapat dibaca.
+func OrientasiEXIF(data []byte) int {
+    if len(data) < 4 || data[0] != 0xff || data[1] != 0xd8 { return 1 }
+    i := 2
+    for i+4 <= len(data) {
+        if data[i] != 0xff { return 1 }
+        marker := data[i+1]
+        // SOS: data gambar dimulai, metadata tidak ada lagi
+        if marker == 0xda || marker == 0xd9 { return 1 }
+        n := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+        if n < 2 || i+2+n > len(data) { return 1 }
+        seg := data[i+4 : i+2+n]
+        if marker == 0xe1 && bytes.HasPrefix(seg, []byte("Exif\x00\x00")) { return orientasiTIFF(seg[6:]) }
+        i += 2 + n
+    }
+    return 1
+}
+
+func orientasiTIFF(t []byte) int {
+    if len(t) < 8 { return 1 }
+    var bo binary.ByteOrder
+    switch string(t[:2]) {
+    case "II": bo = binary.LittleEndian
+    case "MM": bo = binary.BigEndian
+    default: return 1
+    }
+    if bo.Uint16(t[2:4]) != 42 { return 1 }
+    ifd := int(bo.Uint32(t[4:8]))
+    if ifd < 8 || ifd+2 > len(t) { return 1 }
+    n := int(bo.Uint16(t[ifd : ifd+2]))
+    for k := 0; k < n; k++ {
+        e := ifd + 2 + k*12
+        if e+12 > len(t) { return 1 }
+        // tipe 3 = SHORT, nilainya ada di 2 byte pertama field value
+        if bo.Uint16(t[e:e+2]) == 0x0112 && bo.Uint16(t[e+2:e+4]) == 3 {
+            o := int(bo.Uint16(t[e+8 : e+10]))
+            if o < 1 || o > 8 { return 1 }
+            return o
+        }
+    }
+    return 1
+}
@@ -0,0 +1,180 @@
+// Package berkas mengolah berkas dokumen anggota sebelum disimpan: koreksi orientasi dan pembersihan
+// metadata foto, pengecilan dan kompresi, thumbnail, serta penggabungan dokumen menjadi satu PDF arsip.
+// Hanya memakai pustaka standar.
+package berkas
+
+import (
+    "bytes"
+    "errors"
+    "fmt"
+    "image"
+    "image/color"
+    "image/draw"
+    "image/jpeg"
+    "image/png"
+)
+
+// maksPiksel membatasi resolusi gambar yang mau di-decode, agar berkas kecil berisi dimensi
+// raksasa tidak menghabiskan memori
+const maksPiksel = 50_000_000
+
+var (
+    ErrGambarRusak = errors.New("gambar tidak dapat dibaca")
+    ErrGambarBesar = errors.New("dimensi gambar melebihi batas")
+)
+
+// Opsi mengatur hasil olah gambar. Nilai 0 pada MaksDimensi atau Thumbnail berarti tidak dibuat/diubah.
+type Opsi struct {
+    MaksDimensi  int // sisi terpanjang gambar tersimpan (px)
+    KualitasJPEG int // 1-100
+    Thumbnail    int // sisi terpanjang thumbnail (px)
+}
+
+// Gambar adalah hasil OlahGambar. ContentType bisa berbeda dari aslinya: PNG tanpa transparansi
+// disimpan sebagai JPEG karena jauh lebih kecil untuk foto dan hasil scan.
+type Gambar struct {
+    Data        []byte
+    ContentType string
+    Lebar       int
+    Tinggi      int
+    Thumbnail   []byte // JPEG; nil jika Opsi.Thumbnail 0
+}
+
+// OlahGambar men-decode JPEG/PNG, memutar sesuai orientasi EXIF, mengecilkan ke MaksDimensi, lalu
+// meng-encode ulang. Encode ulang sekaligus membuang seluruh metadata (EXIF, GPS, komentar, chunk teks).
+func OlahGambar(data []byte, o Opsi) (Gambar, error) {
+    cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+    if err != nil { return Gambar{}, fmt.Errorf("%w: %v", ErrGambarRusak, err) }
+    if format != "jpeg" && format != "png" { return Gambar{}, fmt.Errorf("%w: format %s", ErrGambarRusak, format) }
+    if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maksPiksel {
+        return Gambar{}, fmt.Errorf("%w: %dx%d", ErrGambarBesar, cfg.Width, cfg.Height)
+    }
+    src, _, err := image.Decode(bytes.NewReader(data))
+    if err != nil { return Gambar{}, fmt.Errorf("%w: %v", ErrGambarRusak, err) }
+
+    img := keRGBA(src)
+    if format == "jpeg" { img = orientasikan(img, OrientasiEXIF(data)) }
+    img = kecilkan(img, o.MaksDimensi)
+
+    g := Gambar{Lebar: img.Rect.Dx(), Tinggi: img.Rect.Dy()}
+    var buf bytes.Buffer
+    if format == "png" && !opak(img) {
+        enc := png.Encoder{CompressionLevel: png.BestCompression}
+        if err := enc.Encode(&buf, img); err != nil { return Gambar{}, err }
+        g.ContentType = "image/png"
+    } else {
+        if err := jpeg.Encode(&buf, diAtasPutih(img), &jpeg.Options{Quality: kualitas(o.KualitasJPEG)}); err != nil { return Gambar{}, err }
+        g.ContentType = "image/jpeg"
+    }
+    g.Data = buf.Bytes()
+
+    if o.Thumbnail > 0 {
+        var tb bytes.Buffer
+        if err := jpeg.Encode(&tb, diAtasPutih(kecilkan(img, o.Thumbnail)), &jpeg.Options{Quality: 75}); err != nil { return Gambar{}, err }
+        g.Thumbnail = tb.Bytes()
+    }
+    return g, nil
+}
+
+// JPEGUntukPDF men-decode gambar tersimpan dan meng-encode ulang sebagai JPEG RGB baseline
+// agar selalu dapat disematkan ke PDF (DCTDecode), termasuk PNG dan JPEG CMYK dari upload lama.
+func JPEGUntukPDF(data []byte) ([]byte, int, int, error) {
+    cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+    if err != nil { return nil, 0, 0, fmt.Errorf("%w: %v", ErrGambarRusak, err) }
+    if cfg.Width*cfg.Height > maksPiksel { return nil, 0, 0, ErrGambarBesar }
+    src, format, err := image.Decode(bytes.NewReader(data))
+    if err != nil { return nil, 0, 0, fmt.Errorf("%w: %v", ErrGambarRusak, err) }
+    img := keRGBA(src)
+    if format == "jpeg" { img = orientasikan(img, OrientasiEXIF(data)) }
+    var buf bytes.Buffer
+    if err := jpeg.Encode(&buf, diAtasPutih(img), &jpeg.Options{Quality: 85}); err != nil { return nil, 0, 0, err }
+    return buf.Bytes(), img.Rect.Dx(), img.Rect.Dy(), nil
+}
+
+func kualitas(q int) int {
+    if q < 1 || q > 100 { return 80 }
+    return q
+}
+
+func keRGBA(src image.Image) *image.RGBA {
+    b := src.Bounds()
+    dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+    draw.Draw(dst, dst.Rect, src, b.Min, draw.Src)
+    return dst
+}
+
+func opak(img *image.RGBA) bool {
+    for i := 3; i < len(img.Pix); i += 4 {
+        if img.Pix[i] != 0xff { return false }
+    }
+    return true
+}
+
+// diAtasPutih meratakan transparansi ke latar putih sebelum encode JPEG
+func diAtasPutih(img *image.RGBA) image.Image {
+    if opak(img) { return img }
+    dst := image.NewRGBA(img.Rect)
+    draw.Draw(dst, dst.Rect, image.NewUniform(color.White), image.Point{}, draw.Src)
+    draw.Draw(dst, dst.Rect, img, img.Rect.Min, draw.Over)
+    return dst
+}
+
+// orientasikan memutar/mencerminkan gambar sesuai tag Orientation EXIF (1-8) sehingga tampil tegak
+// tanpa bergantung pada viewer yang membaca EXIF
+func orientasikan(src *image.RGBA, o int) *image.RGBA {
+    if o < 2 || o > 8 { return src }
+    w, h := src.Rect.Dx(), src.Rect.Dy()
+    dw, dh := w, h
+    if o >= 5 { dw, dh = h, w }
+    dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+    for y := 0; y < dh; y++ {
+        for x := 0; x < dw; x++ {
+            var sx, sy int
+            switch o {
+            case 2: sx, sy = w-1-x, y
+            case 3: sx, sy = w-1-x, h-1-y
+            case 4: sx, sy = x, h-1-y
+            case 5: sx, sy = y, x
+            case 6: sx, sy = y, h-1-x
+            case 7: sx, sy = w-1-y, h-1-x
+            case 8: sx, sy = w-1-y, x
+            }
+            copy(dst.Pix[y*dst.Stride+x*4:y*dst.Stride+x*4+4], src.Pix[sy*src.Stride+sx*4:sy*src.Stride+sx*4+4])
+        }
+    }
+    return dst
+}
+
+// kecilkan memperkecil gambar agar sisi terpanjangnya paling besar maks, dengan merata-ratakan
+// blok piksel sumber (area averaging) sehingga teks pada KTP tetap terbaca. Gambar tidak pernah diperbesar.
+func kecilkan(src *image.RGBA, maks int) *image.RGBA {
+    w, h := src.Rect.Dx(), src.Rect.Dy()
+    if maks <= 0 || (w <= maks && h <= maks) { return src }
+    dw, dh := maks, h*maks/w
+    if h > w { dw, dh = w*maks/h, maks }
+    if dw < 1 { dw = 1 }
+    if dh < 1 { dh = 1 }
+    dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+    for y := 0; y < dh; y++ {
+        sy0, sy1 := y*h/dh, (y+1)*h/dh
+        if sy1 <= sy0 { sy1 = sy0 + 1 }
+        for x := 0; x < dw; x++ {
+            sx0, sx1 := x*w/dw, (x+1)*w/dw
+            if sx1 <= sx0 { sx1 = sx0 + 1 }
+            var r, g, b, a, n uint32
+            for sy := sy0; sy < sy1; sy++ {
+                p := src.Pix[sy*src.Stride+sx0*4 : sy*src.Stride+sx1*4]
+                for i := 0; i < len(p); i += 4 {
+                    r += uint32(p[i])
+                    g += uint32(p[i+1])
+                    b += uint32(p[i+2])
+                    a += uint32(p[i+3])
+                    n++
+                }
+            }
+            d := dst.Pix[y*dst.Stride+x*4:]
+            d[0], d[1], d[2], d[3] = uint8(r/n), uint8(g/n), uint8(b/n), uint8(a/n)
+        }
+    }
+    return dst
+}
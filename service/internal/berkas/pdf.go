@@ -0,0 +1,187 @@
+package berkas
+
+import (
+    "bytes"
+    "fmt"
+    "io"
+    "sort"
+    "strings"
+)
+
+// Ukuran halaman A4 dalam point (1/72 inci) dan margin
+const (
+    lebarA4  = 595
+    tinggiA4 = 842
+    margin   = 40
+
+    barisPerHalaman = 56
+    hurufPerBaris   = 90
+)
+
+// PDF menyusun dokumen PDF sederhana: halaman teks (font Helvetica bawaan), halaman berisi satu
+// gambar JPEG, dan berkas lampiran (embedded file). Berkas PDF asli tidak digabung halamannya
+// melainkan dilampirkan utuh, sehingga isi dan tanda tangan digitalnya tidak berubah.
+type PDF struct {
+    judul    string
+    objek    [][]byte // objek ke-i bernomor i+1; objek 1-4 dicadangkan
+    halaman  []int
+    lampiran map[string]int
+}
+
+// NewPDF membuat dokumen kosong. Objek 1 katalog, 2 pohon halaman, 3 dan 4 font.
+func NewPDF(judul string) *PDF {
+    p := &PDF{judul: judul, objek: make([][]byte, 4), lampiran: map[string]int{}}
+    p.objek[2] = []byte("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+    p.objek[3] = []byte("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+    return p
+}
+
+func (p *PDF) tambah(body []byte) int {
+    p.objek = append(p.objek, body)
+    return len(p.objek)
+}
+
+func (p *PDF) tambahStream(dict string, data []byte) int {
+    var b bytes.Buffer
+    if dict != "" { dict += " " }
+    fmt.Fprintf(&b, "<< %s/Length %d >>\nstream\n", dict, len(data))
+    b.Write(data)
+    b.WriteString("\nendstream")
+    return p.tambah(b.Bytes())
+}
+
+func (p *PDF) tambahHalaman(isi []byte, xobject string) {
+    konten := p.tambahStream("", isi)
+    res := "/Font << /F1 3 0 R /F2 4 0 R >>"
+    if xobject != "" { res += " /XObject << " + xobject + " >>" }
+    p.halaman = append(p.halaman, p.tambah([]byte(fmt.Sprintf(
+        "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << %s >> /Contents %d 0 R >>", lebarA4, tinggiA4, res, konten))))
+}
+
+// TambahTeks menambahkan satu atau beberapa halaman berisi judul dan baris teks.
+// Baris yang terlalu panjang dipotong ke baris berikutnya.
+func (p *PDF) TambahTeks(judul string, baris []string) {
+    var semua []string
+    for _, b := range baris { semua = append(semua, potongBaris(b, hurufPerBaris)...) }
+    for awal := 0; awal == 0 || awal < len(semua); awal += barisPerHalaman {
+        akhir := min(awal+barisPerHalaman, len(semua))
+        var b bytes.Buffer
+        fmt.Fprintf(&b, "BT /F2 14 Tf %d %d Td (%s) Tj ET\n", margin, tinggiA4-margin-14, teksPDF(judul))
+        fmt.Fprintf(&b, "BT /F1 10 Tf 13 TL %d %d Td\n", margin, tinggiA4-margin-40)
+        for _, s := range semua[awal:akhir] { fmt.Fprintf(&b, "(%s) Tj T*\n", teksPDF(s)) }
+        b.WriteString("ET")
+        p.tambahHalaman(b.Bytes(), "")
+    }
+}
+
+// TambahGambar menambahkan satu halaman berisi keterangan dan gambar JPEG yang diskalakan agar muat
+func (p *PDF) TambahGambar(keterangan string, jpg []byte, lebar, tinggi int) {
+    warna := "/DeviceRGB"
+    // encoder JPEG Go menulis gambar abu-abu sebagai satu komponen
+    if komponenJPEG(jpg) == 1 { warna = "/DeviceGray" }
+    img := p.tambahStream(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s /BitsPerComponent 8 /Filter /DCTDecode", lebar, tinggi, warna), jpg)
+
+    kotakW, kotakH := float64(lebarA4-2*margin), float64(tinggiA4-2*margin-30)
+    skala := min(kotakW/float64(lebar), kotakH/float64(tinggi))
+    w, h := float64(lebar)*skala, float64(tinggi)*skala
+    x, y := float64(margin)+(kotakW-w)/2, float64(margin)+kotakH-h
+
+    var b bytes.Buffer
+    fmt.Fprintf(&b, "BT /F2 11 Tf %d %d Td (%s) Tj ET\n", margin, tinggiA4-margin-11, teksPDF(keterangan))
+    fmt.Fprintf(&b, "q %.2f 0 0 %.2f %.2f %.2f cm /Im1 Do Q", w, h, x, y)
+    p.tambahHalaman(b.Bytes(), fmt.Sprintf("/Im1 %d 0 R", img))
+}
+
+// Lampirkan menyematkan berkas utuh; nama harus unik dalam satu dokumen
+func (p *PDF) Lampirkan(nama, contentType string, data []byte) {
+    sub := strings.ReplaceAll(contentType, "/", "#2F")
+    ef := p.tambahStream(fmt.Sprintf("/Type /EmbeddedFile /Subtype /%s /Params << /Size %d >>", sub, len(data)), data)
+    p.lampiran[nama] = p.tambah([]byte(fmt.Sprintf("<< /Type /Filespec /F (%s) /UF (%s) /EF << /F %d 0 R >> >>", teksPDF(nama), teksPDF(nama), ef)))
+}
+
+// Tulis menulis dokumen lengkap beserta tabel xref
+func (p *PDF) Tulis(w io.Writer) error {
+    if len(p.halaman) == 0 { p.TambahTeks(p.judul, nil) }
+    kids := make([]string, len(p.halaman))
+    for i, n := range p.halaman { kids[i] = fmt.Sprintf("%d 0 R", n) }
+    p.objek[1] = []byte(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.halaman)))
+
+    katalog := "<< /Type /Catalog /Pages 2 0 R"
+    if len(p.lampiran) > 0 {
+        // pohon nama harus terurut
+        nama := make([]string, 0, len(p.lampiran))
+        for n := range p.lampiran { nama = append(nama, n) }
+        sort.Strings(nama)
+        var daftar []string
+        for _, n := range nama { daftar = append(daftar, fmt.Sprintf("(%s) %d 0 R", teksPDF(n), p.lampiran[n])) }
+        katalog += " /Names << /EmbeddedFiles << /Names [" + strings.Join(daftar, " ") + "] >> >> /PageMode /UseAttachments"
+    }
+    p.objek[0] = []byte(katalog + " >>")
+    info := p.tambah([]byte(fmt.Sprintf("<< /Title (%s) /Producer (Koperasi Desa) >>", teksPDF(p.judul))))
+
+    var b bytes.Buffer
+    b.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+    offset := make([]int, len(p.objek))
+    for i, o := range p.objek {
+        offset[i] = b.Len()
+        fmt.Fprintf(&b, "%d 0 obj\n", i+1)
+        b.Write(o)
+        b.WriteString("\nendobj\n")
+    }
+    xref := b.Len()
+    fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(p.objek)+1)
+    for _, off := range offset { fmt.Fprintf(&b, "%010d 00000 n \n", off) }
+    fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(p.objek)+1, info, xref)
+    _, err := w.Write(b.Bytes())
+    return err
+}
+
+// teksPDF mengubah teks ke string literal PDF berenkoding WinAnsi; karakter di luar Latin-1 menjadi '?'
+func teksPDF(s string) string {
+    var b strings.Builder
+    for _, r := range s {
+        switch {
+        case r == '\\' || r == '(' || r == ')':
+            b.WriteByte('\\')
+            b.WriteByte(byte(r))
+        case r < 0x20:
+            b.WriteByte(' ')
+        case r < 0x7f || (r >= 0xa0 && r <= 0xff):
+            b.WriteByte(byte(r))
+        default:
+            b.WriteByte('?')
+        }
+    }
+    return b.String()
+}
+
+func potongBaris(s string, n int) []string {
+    r := []rune(s)
+    if len(r) <= n { return []string{s} }
+    var out []string
+    for len(r) > n {
+        i := n
+        // potong di spasi terakhir jika ada
+        for j := n; j > n/2; j-- {
+            if r[j] == ' ' {
+                i = j
+                break
+            }
+        }
+        out = append(out, string(r[:i]))
+        r = []rune(strings.TrimLeft(string(r[i:]), " "))
+    }
+    return append(out, string(r))
+}
+
+// komponenJPEG membaca jumlah komponen warna dari marker SOF
+func komponenJPEG(data []byte) int {
+    for i := 2; i+9 < len(data); {
+        if data[i] != 0xff { return 3 }
+        m := data[i+1]
+        n := int(data[i+2])<<8 | int(data[i+3])
+        if m >= 0xc0 && m <= 0xcf && m != 0xc4 && m != 0xc8 && m != 0xcc { return int(data[i+9]) }
+        i += 2 + n
+    }
+    return 3
+}
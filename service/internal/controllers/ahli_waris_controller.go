@@ -0,0 +1,154 @@
+package controllers
+
+import (
+    "errors"
+    "fmt"
+    "math"
+    "net/http"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+
+    "koperasi-desa/service/internal/models"
+)
+
+var (
+    errBagianWarisTidak100 = errors.New("total persen bagian ahli waris harus 100")
+    errAhliWarisKosong     = errors.New("anggota belum memiliki ahli waris terdaftar")
+)
+
+// GET /api/anggota/:id/ahli-waris
+func (h *AnggotaController) ListAhliWaris(c *gin.Context) {
+    var list []models.AhliWaris
+    if err := h.DB.Where("anggota_id = ?", c.Param("id")).Order("id ASC").Find(&list).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": list})
+}
+
+// PUT /api/anggota/:id/ahli-waris
+// { data: [{ nama, hubungan, nik, telp, persen_bagian }] } menggantikan seluruh daftar ahli waris
+type AhliWarisInput struct {
+    Nama         string  `json:"nama" binding:"required"`
+    Hubungan     string  `json:"hubungan" binding:"required"`
+    NIK          string  `json:"nik" binding:"required"`
+    Telp         string  `json:"telp"`
+    PersenBagian float64 `json:"persen_bagian" binding:"required,gt=0,lte=100"`
+}
+
+type SimpanAhliWarisInput struct {
+    Data []AhliWarisInput `json:"data" binding:"required,min=1,dive"`
+}
+
+func (h *AnggotaController) SimpanAhliWaris(c *gin.Context) {
+    var in SimpanAhliWarisInput
+    if err := c.ShouldBindJSON(&in); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    total := 0.0
+    for _, w := range in.Data { total += w.PersenBagian }
+    if math.Abs(total-100) > 0.001 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": errBagianWarisTidak100.Error(), "total": total})
+        return
+    }
+
+    var a models.Anggota
+    list := []models.AhliWaris{}
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.First(&a, c.Param("id")).Error; err != nil { return err }
+        if a.Status == "meninggal" || a.Status == "keluar" { return errTransisiStatus }
+        if err := tx.Where("anggota_id = ?", a.ID).Delete(&models.AhliWaris{}).Error; err != nil { return err }
+        var nama []string
+        for _, w := range in.Data {
+            list = append(list, models.AhliWaris{
+                AnggotaID:    a.ID,
+                Nama:         strings.TrimSpace(w.Nama),
+                Hubungan:     strings.ToLower(strings.TrimSpace(w.Hubungan)),
+                NIK:          strings.TrimSpace(w.NIK),
+                Telp:         strings.TrimSpace(w.Telp),
+                PersenBagian: w.PersenBagian,
+            })
+            nama = append(nama, fmt.Sprintf("%s (%.2f%%)", strings.TrimSpace(w.Nama), w.PersenBagian))
+        }
+        if err := tx.Create(&list).Error; err != nil { return err }
+        return tx.Create(&models.AnggotaActivity{AnggotaID: a.ID, Action: "ahli_waris", Note: "Ahli waris: " + strings.Join(nama, ", "), CreatedAt: time.Now()}).Error
+    })
+    if err != nil {
+        if errors.Is(err, errTransisiStatus) {
+            c.JSON(http.StatusConflict, gin.H{"error": "ahli waris tidak dapat diubah setelah anggota keluar/meninggal"})
+        } else {
+            statusError(c, err)
+        }
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": list})
+}
+
+// POST /api/anggota/:id/klaim-meninggal { alasan, user_id }
+// Menyelesaikan keanggotaan dengan status meninggal lalu membagi selisih penyelesaian ke ahli waris.
+// Setiap bagian dibayarkan tunai dari laci sesi kasir user_id; kekurangan diterima lewat laci yang sama.
+type KlaimMeninggalInput struct {
+    Alasan string `json:"alasan" binding:"required"`
+    UserID uint   `json:"user_id" binding:"required"`
+}
+
+func (h *AnggotaController) KlaimMeninggal(c *gin.Context) {
+    var in KlaimMeninggalInput
+    if err := c.ShouldBindJSON(&in); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    var p *models.PenyelesaianKeluar
+    bagian := []models.PembagianWaris{}
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        if _, err := cariPengguna(tx, in.UserID, rolePengelolaKeanggotaan...); err != nil { return err }
+        var a models.Anggota
+        if err := tx.First(&a, c.Param("id")).Error; err != nil { return err }
+        var waris []models.AhliWaris
+        if err := tx.Where("anggota_id = ?", a.ID).Order("id ASC").Find(&waris).Error; err != nil { return err }
+        if len(waris) == 0 { return errAhliWarisKosong }
+        total := 0.0
+        for _, w := range waris { total += w.PersenBagian }
+        if math.Abs(total-100) > 0.001 { return errBagianWarisTidak100 }
+
+        var err error
+        p, err = selesaikanKeanggotaan(tx, &a, "meninggal", in.Alasan, in.UserID)
+        if err != nil { return err }
+
+        // Bagian dibulatkan ke bawah; sisa pembulatan diberikan ke ahli waris pertama
+        hak := math.Max(p.Selisih, 0)
+        sisa := hak
+        for _, w := range waris {
+            j := math.Floor(hak * w.PersenBagian / 100)
+            sisa -= j
+            bagian = append(bagian, models.PembagianWaris{PenyelesaianID: p.ID, AhliWarisID: w.ID, Nama: w.Nama, NIK: w.NIK, Hubungan: w.Hubungan, PersenBagian: w.PersenBagian, Jumlah: j})
+        }
+        bagian[0].Jumlah += sisa
+        if err := tx.Create(&bagian).Error; err != nil { return err }
+        if p.Selisih < 0 { return catatKasPenyelesaian(tx, in.UserID, p) }
+        for _, b := range bagian {
+            if b.Jumlah <= 0 { continue }
+            if err := catatTransaksiKasir(tx, in.UserID, &models.TransaksiKasir{
+                Jenis: "waris", Sumber: "pembagian_waris", RefID: b.ID, Arah: "keluar", Jumlah: b.Jumlah,
+                Keterangan: fmt.Sprintf("Bagian ahli waris %s (%s) anggota #%d", b.Nama, b.Hubungan, p.AnggotaID),
+            }); err != nil {
+                return err
+            }
+        }
+        return nil
+    })
+    if err != nil {
+        switch {
+        case errors.Is(err, errAhliWarisKosong), errors.Is(err, errBagianWarisTidak100):
+            c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+        default:
+            penyelesaianError(c, err)
+        }
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": p, "pembagian": bagian})
+}
@@ -1,10 +1,8 @@
 package controllers
 
 import (
-    "fmt"
+    "errors"
     "net/http"
-    "os"
-    "path/filepath"
     "strconv"
     "strings"
     "time"
@@ -13,23 +11,27 @@ import (
     "gorm.io/gorm"
 
     "koperasi-desa/service/internal/models"
+    "koperasi-desa/service/internal/storage"
 )
 
 type AnggotaController struct {
-    DB *gorm.DB
+    DB      *gorm.DB
+    Storage storage.Storage
 }
 
-func NewAnggotaController(db *gorm.DB) *AnggotaController {
-    return &AnggotaController{DB: db}
+func NewAnggotaController(db *gorm.DB, st storage.Storage) *AnggotaController {
+    return &AnggotaController{DB: db, Storage: st}
 }
 
 type CreateAnggotaInput struct {
-    NomorAnggota  string    `json:"nomor_anggota" binding:"required"`
+    NomorAnggota  string    `json:"nomor_anggota"` // kosong = dibuat otomatis dari settings.format
     Nama          string    `json:"nama" binding:"required"`
     NIK           string    `json:"nik" binding:"required"`
     Alamat        string    `json:"alamat"`
     Telp          string    `json:"telp"`
     TanggalGabung time.Time `json:"tanggal_gabung"`
+    // KonfirmasiDuplikat diisi petugas setelah memeriksa peringatan kemungkinan duplikat
+    KonfirmasiDuplikat bool `json:"konfirmasi_duplikat"`
 }
 
 type UpdateAnggotaInput struct {
@@ -38,7 +40,7 @@ type UpdateAnggotaInput struct {
     NIK           *string    `json:"nik"`
     Alamat        *string    `json:"alamat"`
     Telp          *string    `json:"telp"`
-    Status        *string    `json:"status"`
+    Status        *string    `json:"status"` // ditolak; status hanya lewat endpoint khusus
     TanggalGabung *time.Time `json:"tanggal_gabung"`
 }
 
@@ -49,17 +51,45 @@ func (h *AnggotaController) CreateAnggota(c *gin.Context) {
         return
     }
     if input.TanggalGabung.IsZero() { input.TanggalGabung = time.Now() }
+    nik := strings.TrimSpace(input.NIK)
+    dataNIK, err := validasiNIK(nik)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
     anggota := models.Anggota{
         NomorAnggota:  input.NomorAnggota,
         Nama:          input.Nama,
-        NIK:           input.NIK,
+        NIK:           nik,
+        NIKAktif:      &nik,
+        TanggalLahir:  &dataNIK.TanggalLahir,
+        JenisKelamin:  dataNIK.JenisKelamin,
         Alamat:        input.Alamat,
         Telp:          input.Telp,
         Status:        "pending",
         TanggalGabung: input.TanggalGabung,
     }
-    if err := h.DB.Create(&anggota).Error; err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    var duplikat []KemungkinanDuplikat
+    err = h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := cekNIKTerdaftar(tx, nik, 0); err != nil { return err }
+        var err error
+        duplikat, err = cariDuplikat(tx, input.Nama, input.Alamat, anggota.TanggalLahir)
+        if err != nil { return err }
+        if len(duplikat) > 0 && !input.KonfirmasiDuplikat { return errKemungkinanDuplikat }
+        if strings.TrimSpace(anggota.NomorAnggota) == "" {
+            if anggota.NomorAnggota, err = nomorBerikutnya(tx, "anggota", anggota.TanggalGabung); err != nil { return err }
+        }
+        return tx.Create(&anggota).Error
+    })
+    if err != nil {
+        switch {
+        case errors.Is(err, errNIKTerdaftar):
+            c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+        case errors.Is(err, errKemungkinanDuplikat):
+            c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "kemungkinan_duplikat": duplikat})
+        default:
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        }
         return
     }
     // Log activity
@@ -92,7 +122,7 @@ func (h *AnggotaController) ListAnggota(c *gin.Context) {
 func (h *AnggotaController) GetAnggota(c *gin.Context) {
     id := c.Param("id")
     var anggota models.Anggota
-    if err := h.DB.Preload("Documents").First(&anggota, id).Error; err != nil {
+    if err := h.DB.Preload("Documents", dokumenBerlaku).First(&anggota, id).Error; err != nil {
         if err == gorm.ErrRecordNotFound {
             c.JSON(http.StatusNotFound, gin.H{"error": "anggota not found"})
         } else {
@@ -102,7 +132,12 @@ func (h *AnggotaController) GetAnggota(c *gin.Context) {
     }
     var acts []models.AnggotaActivity
     _ = h.DB.Where("anggota_id = ?", anggota.ID).Order("created_at DESC").Find(&acts).Error
-    c.JSON(http.StatusOK, gin.H{"data": anggota, "activities": acts})
+    skor, err := skorKreditTerkini(h.DB, &anggota)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": anggota, "activities": acts, "skor_kredit": skor})
 }
 
 func (h *AnggotaController) UpdateAnggota(c *gin.Context) {
@@ -122,19 +157,37 @@ func (h *AnggotaController) UpdateAnggota(c *gin.Context) {
         c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
         return
     }
+    // status hanya berubah lewat endpoint khusus yang memeriksa syarat dan alasannya
+    if input.Status != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "status tidak dapat diubah lewat PUT; gunakan /verify, /activate atau /status"})
+        return
+    }
 
     if input.NomorAnggota != nil { anggota.NomorAnggota = *input.NomorAnggota }
     if input.Nama != nil { anggota.Nama = *input.Nama }
-    if input.NIK != nil { anggota.NIK = *input.NIK }
+    if input.NIK != nil && strings.TrimSpace(*input.NIK) != anggota.NIK {
+        nik := strings.TrimSpace(*input.NIK)
+        dataNIK, err := validasiNIK(nik)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+            return
+        }
+        if err := cekNIKTerdaftar(h.DB, nik, anggota.ID); err != nil {
+            if errors.Is(err, errNIKTerdaftar) {
+                c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+            } else {
+                c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+            }
+            return
+        }
+        anggota.NIK = nik
+        if anggota.NIKAktif != nil { anggota.NIKAktif = &nik }
+        anggota.TanggalLahir = &dataNIK.TanggalLahir
+        anggota.JenisKelamin = dataNIK.JenisKelamin
+    }
     if input.Alamat != nil { anggota.Alamat = *input.Alamat }
     if input.Telp != nil { anggota.Telp = *input.Telp }
     if input.TanggalGabung != nil { anggota.TanggalGabung = *input.TanggalGabung }
-    if input.Status != nil {
-        s := strings.ToLower(*input.Status)
-        if s == "pending" || s == "verified" || s == "active" {
-            anggota.Status = s
-        }
-    }
 
     if err := h.DB.Save(&anggota).Error; err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -150,12 +203,10 @@ func (h *AnggotaController) VerifyAnggota(c *gin.Context) {
         c.JSON(http.StatusNotFound, gin.H{"error": "anggota not found"})
         return
     }
-    anggota.Status = "verified"
-    if err := h.DB.Save(&anggota).Error; err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+    if err := ubahStatusAnggota(h.DB, &anggota, "verified", "Verifikasi anggota"); err != nil {
+        statusError(c, err)
         return
     }
-    h.DB.Create(&models.AnggotaActivity{AnggotaID: anggota.ID, Action: "verified", Note: "Verifikasi anggota", CreatedAt: time.Now()})
     c.JSON(http.StatusOK, gin.H{"verified": true})
 }
 
@@ -166,65 +217,18 @@ func (h *AnggotaController) ActivateAnggota(c *gin.Context) {
         c.JSON(http.StatusNotFound, gin.H{"error": "anggota not found"})
         return
     }
-    anggota.Status = "active"
-    if err := h.DB.Save(&anggota).Error; err != nil {
+    unmet, err := cekSyaratAktivasi(h.DB, &anggota)
+    if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
     }
-    h.DB.Create(&models.AnggotaActivity{AnggotaID: anggota.ID, Action: "activated", Note: "Aktivasi anggota", CreatedAt: time.Now()})
-    c.JSON(http.StatusOK, gin.H{"activated": true})
-}
-
-func (h *AnggotaController) UploadDocuments(c *gin.Context) {
-    idStr := c.Param("id")
-    id, _ := strconv.Atoi(idStr)
-    var anggota models.Anggota
-    if err := h.DB.First(&anggota, id).Error; err != nil {
-        c.JSON(http.StatusNotFound, gin.H{"error": "anggota not found"})
+    if len(unmet) > 0 {
+        c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "syarat aktivasi belum terpenuhi", "unmet": unmet})
         return
     }
-
-    form, err := c.MultipartForm()
-    if err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid multipart form"})
-        return
-    }
-    files := form.File["files"]
-    if len(files) == 0 {
-        c.JSON(http.StatusBadRequest, gin.H{"error": "no files"})
+    if err := ubahStatusAnggota(h.DB, &anggota, "active", "Aktivasi anggota"); err != nil {
+        statusError(c, err)
         return
     }
-
-    baseDir := filepath.Join("uploads", "anggota", fmt.Sprintf("%d", anggota.ID))
-    if err := os.MkdirAll(baseDir, 0755); err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot create upload dir"})
-        return
-    }
-    var saved []models.AnggotaDocument
-    for _, f := range files {
-        filename := filepath.Base(f.Filename)
-        path := filepath.Join(baseDir, filename)
-        if err := c.SaveUploadedFile(f, path); err != nil {
-            c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save file"})
-            return
-        }
-        url := "/uploads/anggota/" + fmt.Sprintf("%d", anggota.ID) + "/" + filename
-        doc := models.AnggotaDocument{AnggotaID: uint(id), Filename: filename, URL: url, UploadedAt: time.Now()}
-        if err := h.DB.Create(&doc).Error; err != nil {
-            c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to record document"})
-            return
-        }
-        saved = append(saved, doc)
-    }
-    c.JSON(http.StatusOK, gin.H{"uploaded": saved})
+    c.JSON(http.StatusOK, gin.H{"activated": true})
 }
-
-func (h *AnggotaController) ListDocuments(c *gin.Context) {
-    id := c.Param("id")
-    var docs []models.AnggotaDocument
-    if err := h.DB.Where("anggota_id = ?", id).Order("uploaded_at DESC").Find(&docs).Error; err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-        return
-    }
-    c.JSON(http.StatusOK, gin.H{"data": docs})
-}
\ No newline at end of file
@@ -0,0 +1,663 @@
+package controllers
+
+import (
+    "bytes"
+    "crypto/hmac"
+    "crypto/rand"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "io"
+    "log"
+    "mime"
+    "mime/multipart"
+    "net/http"
+    "os"
+    "path/filepath"
+    "slices"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+
+    "koperasi-desa/service/internal/berkas"
+    "koperasi-desa/service/internal/models"
+    "koperasi-desa/service/internal/settings"
+    "koperasi-desa/service/internal/storage"
+)
+
+// masaBerlakuTautan adalah umur tautan unduh dokumen yang diterbitkan
+const masaBerlakuTautan = 5 * time.Minute
+
+var (
+    errTautanTidakSah      = errors.New("tautan dokumen tidak valid atau sudah kedaluwarsa")
+    errJenisDokumen        = errors.New("jenis dokumen tidak dikenal")
+    errTipeDokumen         = errors.New("tipe berkas tidak diizinkan; hanya JPEG, PNG atau PDF")
+    errUkuranDokumen       = errors.New("ukuran berkas melebihi batas")
+    errDokumenTidakBerlaku = errors.New("dokumen sudah diganti atau dihapus")
+    errStatusVerifikasi    = errors.New("status verifikasi harus valid atau ditolak")
+)
+
+// rolePembacaDokumen boleh menerima tautan unduh dokumen anggota: pengelola keanggotaan dan petugas pelayanan
+var rolePembacaDokumen = []string{"petugas", "admin", "bendahara", "ketua"}
+
+// tipeDokumenDiizinkan memetakan tipe hasil deteksi isi berkas ke ekstensi nama berkas di storage
+var tipeDokumenDiizinkan = map[string]string{"image/jpeg": ".jpg", "image/png": ".png", "application/pdf": ".pdf"}
+
+// tipeInline adalah tipe berkas yang aman ditampilkan langsung di browser; selain itu diunduh sebagai lampiran
+var tipeInline = map[string]bool{"image/jpeg": true, "image/png": true, "application/pdf": true}
+
+var (
+    kunciTautanOnce sync.Once
+    kunciTautan     []byte
+)
+
+// kunciTautanDokumen membaca DOCUMENT_URL_SECRET. Jika kosong dipakai kunci acak per proses,
+// sehingga tautan yang sudah diterbitkan tidak berlaku lagi setelah service restart.
+func kunciTautanDokumen() []byte {
+    kunciTautanOnce.Do(func() {
+        if k := os.Getenv("DOCUMENT_URL_SECRET"); k != "" {
+            kunciTautan = []byte(k)
+            return
+        }
+        kunciTautan = make([]byte, 32)
+        if _, err := rand.Read(kunciTautan); err != nil { log.Fatalf("failed to generate document url key: %v", err) }
+        log.Printf("DOCUMENT_URL_SECRET kosong; tautan dokumen memakai kunci acak per proses")
+    })
+    return kunciTautan
+}
+
+// tandaTanganDokumen mengikat tautan ke dokumen, pengguna yang memintanya, dan waktu kedaluwarsa
+func tandaTanganDokumen(dokumenID, userID uint, exp int64) string {
+    m := hmac.New(sha256.New, kunciTautanDokumen())
+    fmt.Fprintf(m, "%d:%d:%d", dokumenID, userID, exp)
+    return base64.RawURLEncoding.EncodeToString(m.Sum(nil))
+}
+
+func tautanDokumen(d *models.AnggotaDocument, userID uint, now time.Time) string {
+    exp := now.Add(masaBerlakuTautan).Unix()
+    return fmt.Sprintf("/api/anggota/%d/documents/%d/unduh?u=%d&exp=%d&sig=%s", d.AnggotaID, d.ID, userID, exp, tandaTanganDokumen(d.ID, userID, exp))
+}
+
+// isiTautan mengisi URL dokumen dan, jika ada, URL thumbnail-nya. Tautan thumbnail memakai tanda tangan
+// yang sama karena siapa pun yang boleh membuka dokumen juga boleh melihat thumbnail-nya.
+func isiTautan(d *models.AnggotaDocument, userID uint, now time.Time) {
+    d.URL = tautanDokumen(d, userID, now)
+    if d.ThumbnailKey != "" { d.ThumbnailURL = strings.Replace(d.URL, "/unduh?", "/thumbnail?", 1) }
+}
+
+func catatAksesDokumen(tx *gorm.DB, c *gin.Context, dokumenID, userID uint, aksi string) error {
+    ua := c.Request.UserAgent()
+    if len(ua) > 255 { ua = ua[:255] }
+    return tx.Create(&models.DokumenAkses{DokumenID: dokumenID, UserID: userID, Aksi: aksi, IP: c.ClientIP(), UserAgent: ua}).Error
+}
+
+// dokumenBerlaku membatasi query ke dokumen yang belum diganti dan belum dihapus
+func dokumenBerlaku(tx *gorm.DB) *gorm.DB {
+    return tx.Where("dihapus_at IS NULL AND diganti_oleh_id IS NULL")
+}
+
+// berkasSiap adalah berkas unggahan yang sudah diperiksa dan diolah, siap ditulis ke storage
+type berkasSiap struct {
+    nama          string
+    data          []byte
+    contentType   string
+    ukuranAsli    int64
+    lebar, tinggi int
+    thumbnail     []byte
+}
+
+// siapkanBerkas memeriksa ukuran, mendeteksi tipe dari isi berkas (bukan dari nama atau header klien),
+// lalu mengolah foto: orientasi EXIF, buang metadata, kecilkan/kompres, dan buat thumbnail
+func siapkanBerkas(fh *multipart.FileHeader, jenis string, cfg settings.Dokumen) (berkasSiap, error) {
+    maks := cfg.MaksUkuran(jenis)
+    if fh.Size > maks { return berkasSiap{}, fmt.Errorf("%w: %s %d KB, maksimal %d KB", errUkuranDokumen, fh.Filename, (fh.Size+1023)/1024, maks/1024) }
+    f, err := fh.Open()
+    if err != nil { return berkasSiap{}, err }
+    defer f.Close()
+    data, err := io.ReadAll(io.LimitReader(f, maks+1))
+    if err != nil { return berkasSiap{}, err }
+    if int64(len(data)) > maks { return berkasSiap{}, fmt.Errorf("%w: %s, maksimal %d KB", errUkuranDokumen, fh.Filename, maks/1024) }
+    tipe := http.DetectContentType(data)
+    if _, ok := tipeDokumenDiizinkan[tipe]; !ok { return berkasSiap{}, fmt.Errorf("%w: %s terdeteksi %s", errTipeDokumen, fh.Filename, tipe) }
+
+    b := berkasSiap{nama: filepath.Base(fh.Filename), data: data, contentType: tipe, ukuranAsli: int64(len(data))}
+    if tipe == "application/pdf" { return b, nil }
+    g, err := berkas.OlahGambar(data, berkas.Opsi{MaksDimensi: cfg.MaksDimensiPx, KualitasJPEG: cfg.KualitasJPEG, Thumbnail: cfg.ThumbnailPx})
+    switch {
+    case errors.Is(err, berkas.ErrGambarBesar):
+        return berkasSiap{}, fmt.Errorf("%w: %s: %v", errUkuranDokumen, fh.Filename, err)
+    case err != nil:
+        return berkasSiap{}, fmt.Errorf("%w: %s: %v", errTipeDokumen, fh.Filename, err)
+    }
+    // nama berkas mengikuti format tersimpan, mis. scan.png tanpa transparansi yang disimpan sebagai JPEG
+    if g.ContentType != tipe { b.nama = strings.TrimSuffix(b.nama, filepath.Ext(b.nama)) + tipeDokumenDiizinkan[g.ContentType] }
+    b.data, b.contentType, b.lebar, b.tinggi, b.thumbnail = g.Data, g.ContentType, g.Lebar, g.Tinggi, g.Thumbnail
+    return b, nil
+}
+
+// simpanBerkas menulis berkas (dan thumbnail-nya) ke storage dengan nama acak, agar tidak bisa ditebak
+// dan upload dengan nama sama tidak menimpa berkas lama. Baris dokumen belum dibuat.
+func simpanBerkas(c *gin.Context, st storage.Storage, anggotaID uint, jenis string, b berkasSiap, userID uint) (models.AnggotaDocument, error) {
+    acak := make([]byte, 16)
+    if _, err := rand.Read(acak); err != nil { return models.AnggotaDocument{}, err }
+    dasar := fmt.Sprintf("anggota/%d/%s", anggotaID, hex.EncodeToString(acak))
+    sum := sha256.Sum256(b.data)
+    doc := models.AnggotaDocument{
+        AnggotaID:        anggotaID,
+        Jenis:            jenis,
+        Filename:         b.nama,
+        StorageKey:       dasar + tipeDokumenDiizinkan[b.contentType],
+        Checksum:         hex.EncodeToString(sum[:]),
+        Ukuran:           int64(len(b.data)),
+        UkuranAsli:       b.ukuranAsli,
+        ContentType:      b.contentType,
+        Lebar:            b.lebar,
+        Tinggi:           b.tinggi,
+        UploadedAt:       time.Now(),
+        DiunggahOleh:     &userID,
+        Versi:            1,
+        StatusVerifikasi: "menunggu",
+    }
+    ctx := c.Request.Context()
+    if err := st.Put(ctx, doc.StorageKey, bytes.NewReader(b.data), doc.Ukuran, doc.ContentType); err != nil { return models.AnggotaDocument{}, err }
+    if b.thumbnail != nil {
+        doc.ThumbnailKey = dasar + "_thumb.jpg"
+        if err := st.Put(ctx, doc.ThumbnailKey, bytes.NewReader(b.thumbnail), int64(len(b.thumbnail)), "image/jpeg"); err != nil {
+            _ = st.Delete(ctx, doc.StorageKey)
+            return models.AnggotaDocument{}, err
+        }
+    }
+    return doc, nil
+}
+
+// hapusBerkas membersihkan berkas yang sudah ditulis ke storage jika baris dokumennya gagal disimpan
+func hapusBerkas(c *gin.Context, st storage.Storage, docs []models.AnggotaDocument) {
+    for _, d := range docs {
+        _ = st.Delete(c.Request.Context(), d.StorageKey)
+        if d.ThumbnailKey != "" { _ = st.Delete(c.Request.Context(), d.ThumbnailKey) }
+    }
+}
+
+// POST /api/anggota/:id/documents
+// multipart: files[], jenis (satu untuk semua berkas, atau satu per berkas sesuai urutan), user_id
+// jenis: salah satu models.JenisDokumen (ktp, kk, pas_foto, slip_gaji, surat_pernyataan, ...)
+func (h *AnggotaController) UploadDocuments(c *gin.Context) {
+    idStr := c.Param("id")
+    id, _ := strconv.Atoi(idStr)
+    var anggota models.Anggota
+    if err := h.DB.First(&anggota, id).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "anggota not found"})
+        return
+    }
+    unggahDokumen(c, h.DB, h.Storage, anggota.ID, nil)
+}
+
+// unggahDokumen menangani multipart files[] + jenis + user_id untuk anggota, dan untuk pinjaman jika pinjamanID
+// diisi. Hanya rolePengelolaKeanggotaan yang boleh mengunggah; pengunggah dicatat di dokumen dan audit.
+// Semua berkas diperiksa dan diolah dulu agar tidak ada yang tersimpan sebagian.
+func unggahDokumen(c *gin.Context, db *gorm.DB, st storage.Storage, anggotaID uint, pinjamanID *uint) {
+    form, err := c.MultipartForm()
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid multipart form"})
+        return
+    }
+    userID, err := strconv.Atoi(c.PostForm("user_id"))
+    if err != nil || userID <= 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "user_id wajib diisi"})
+        return
+    }
+    u, err := cariPengguna(db, uint(userID), rolePengelolaKeanggotaan...)
+    if err != nil {
+        dokumenError(c, err)
+        return
+    }
+    files := form.File["files"]
+    if len(files) == 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "no files"})
+        return
+    }
+    jenisList := form.Value["jenis"]
+    if len(jenisList) != 1 && len(jenisList) != len(files) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "jenis wajib diisi satu untuk semua berkas atau satu per berkas"})
+        return
+    }
+    cfg, err := settings.LoadDokumen(db)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    jenis := make([]string, len(files))
+    siap := make([]berkasSiap, len(files))
+    for i, f := range files {
+        jenis[i] = strings.ToLower(strings.TrimSpace(jenisList[min(i, len(jenisList)-1)]))
+        if !slices.Contains(models.JenisDokumen, jenis[i]) {
+            dokumenError(c, fmt.Errorf("%w: %s", errJenisDokumen, jenis[i]))
+            return
+        }
+        if siap[i], err = siapkanBerkas(f, jenis[i], cfg); err != nil {
+            dokumenError(c, err)
+            return
+        }
+    }
+
+    var saved []models.AnggotaDocument
+    for i := range files {
+        doc, err := simpanBerkas(c, st, anggotaID, jenis[i], siap[i], u.ID)
+        if err != nil {
+            hapusBerkas(c, st, saved)
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save file"})
+            return
+        }
+        doc.PinjamanID = pinjamanID
+        saved = append(saved, doc)
+    }
+    err = db.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Create(&saved).Error; err != nil { return err }
+        for _, d := range saved {
+            if err := catatAudit(tx, u.ID, "dokumen_diunggah", "anggota_document", d.ID, d.Jenis+": "+d.Filename); err != nil { return err }
+        }
+        return nil
+    })
+    if err != nil {
+        hapusBerkas(c, st, saved)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to record document"})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"uploaded": saved})
+}
+
+// POST /api/anggota/:id/documents/:docId/ganti
+// multipart: file, user_id. Versi baru dengan jenis yang sama; versi lama tetap tersimpan sebagai riwayat
+func (h *AnggotaController) GantiDokumen(c *gin.Context) {
+    userID, err := strconv.Atoi(c.PostForm("user_id"))
+    if err != nil || userID <= 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "user_id wajib diisi"})
+        return
+    }
+    // periksa role sebelum berkas disimpan agar penolakan tidak meninggalkan berkas di storage
+    u, err := cariPengguna(h.DB, uint(userID), rolePengelolaKeanggotaan...)
+    if err != nil {
+        dokumenError(c, err)
+        return
+    }
+    var lama models.AnggotaDocument
+    if err := h.DB.Where("id = ? AND anggota_id = ?", c.Param("docId"), c.Param("id")).First(&lama).Error; err != nil {
+        dokumenError(c, err)
+        return
+    }
+    fh, err := c.FormFile("file")
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "file wajib diisi"})
+        return
+    }
+    cfg, err := settings.LoadDokumen(h.DB)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    siap, err := siapkanBerkas(fh, lama.Jenis, cfg)
+    if err != nil {
+        dokumenError(c, err)
+        return
+    }
+    baru, err := simpanBerkas(c, h.Storage, lama.AnggotaID, lama.Jenis, siap, u.ID)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save file"})
+        return
+    }
+    err = h.DB.Transaction(func(tx *gorm.DB) error {
+        // kunci baris lama agar dua penggantian bersamaan tidak sama-sama berhasil
+        if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&lama, lama.ID).Error; err != nil { return err }
+        if lama.DihapusAt != nil || lama.DigantiOlehID != nil { return errDokumenTidakBerlaku }
+        baru.Versi = lama.Versi + 1
+        baru.MenggantikanID = &lama.ID
+        baru.PinjamanID = lama.PinjamanID
+        if err := tx.Create(&baru).Error; err != nil { return err }
+        if err := tx.Model(&lama).Update("diganti_oleh_id", baru.ID).Error; err != nil { return err }
+        return catatAudit(tx, u.ID, "dokumen_diganti", "anggota_document", lama.ID, fmt.Sprintf("diganti oleh dokumen #%d (versi %d)", baru.ID, baru.Versi))
+    })
+    if err != nil {
+        hapusBerkas(c, h.Storage, []models.AnggotaDocument{baru})
+        dokumenError(c, err)
+        return
+    }
+    c.JSON(http.StatusOK, baru)
+}
+
+// DELETE /api/anggota/:id/documents/:docId { user_id, alasan }
+// Hanya menandai dokumen terhapus; berkas dan riwayat tetap disimpan
+func (h *AnggotaController) HapusDokumen(c *gin.Context) {
+    var in struct {
+        UserID uint   `json:"user_id" binding:"required"`
+        Alasan string `json:"alasan"`
+    }
+    if err := c.ShouldBindJSON(&in); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if strings.TrimSpace(in.Alasan) == "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "alasan penghapusan wajib diisi"})
+        return
+    }
+    var doc models.AnggotaDocument
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        u, err := cariPengguna(tx, in.UserID, rolePengelolaKeanggotaan...)
+        if err != nil { return err }
+        if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ? AND anggota_id = ?", c.Param("docId"), c.Param("id")).First(&doc).Error; err != nil {
+            return err
+        }
+        if doc.DihapusAt != nil || doc.DigantiOlehID != nil { return errDokumenTidakBerlaku }
+        now := time.Now()
+        doc.DihapusAt = &now
+        doc.DihapusOleh = &u.ID
+        doc.AlasanHapus = in.Alasan
+        if err := tx.Save(&doc).Error; err != nil { return err }
+        return catatAudit(tx, u.ID, "dokumen_dihapus", "anggota_document", doc.ID, in.Alasan)
+    })
+    if err != nil {
+        dokumenError(c, err)
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+// POST /api/anggota/:id/documents/:docId/verifikasi { status: valid|ditolak, catatan, user_id }
+func (h *AnggotaController) VerifikasiDokumen(c *gin.Context) {
+    var in struct {
+        Status  string `json:"status" binding:"required"`
+        Catatan string `json:"catatan"`
+        UserID  uint   `json:"user_id" binding:"required"`
+    }
+    if err := c.ShouldBindJSON(&in); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    in.Status = strings.ToLower(strings.TrimSpace(in.Status))
+    if in.Status != "valid" && in.Status != "ditolak" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": errStatusVerifikasi.Error()})
+        return
+    }
+    if in.Status == "ditolak" && strings.TrimSpace(in.Catatan) == "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "catatan wajib diisi jika dokumen ditolak"})
+        return
+    }
+    var doc models.AnggotaDocument
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        u, err := cariPengguna(tx, in.UserID, rolePengelolaKeanggotaan...)
+        if err != nil { return err }
+        if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ? AND anggota_id = ?", c.Param("docId"), c.Param("id")).First(&doc).Error; err != nil {
+            return err
+        }
+        if doc.DihapusAt != nil || doc.DigantiOlehID != nil { return errDokumenTidakBerlaku }
+        now := time.Now()
+        doc.StatusVerifikasi = in.Status
+        doc.CatatanVerifikasi = in.Catatan
+        doc.DiverifikasiOleh = &u.ID
+        doc.DiverifikasiAt = &now
+        if err := tx.Save(&doc).Error; err != nil { return err }
+        return catatAudit(tx, u.ID, "dokumen_"+in.Status, "anggota_document", doc.ID, in.Catatan)
+    })
+    if err != nil {
+        dokumenError(c, err)
+        return
+    }
+    c.JSON(http.StatusOK, doc)
+}
+
+// GET /api/anggota/:id/documents?user_id=...&riwayat=true&pinjaman_id=...
+// Bawaan hanya dokumen yang berlaku; riwayat=true menyertakan versi lama dan dokumen terhapus.
+// user_id wajib (rolePembacaDokumen); setiap dokumen diberi url unduh bertanda tangan yang berlaku beberapa menit
+func (h *AnggotaController) ListDocuments(c *gin.Context) {
+    tx := h.DB.Where("anggota_id = ?", c.Param("id"))
+    if p := strings.TrimSpace(c.Query("pinjaman_id")); p != "" { tx = tx.Where("pinjaman_id = ?", p) }
+    daftarDokumen(c, h.DB, tx)
+}
+
+// daftarDokumen menjalankan query dokumen yang sudah difilter lalu mengisi tautan. Daftar dan tautan
+// hanya untuk pengguna dengan rolePembacaDokumen
+func daftarDokumen(c *gin.Context, db *gorm.DB, tx *gorm.DB) {
+    userID, _ := strconv.Atoi(c.Query("user_id"))
+    u, err := cariPengguna(db, uint(userID), rolePembacaDokumen...)
+    if err != nil {
+        dokumenError(c, err)
+        return
+    }
+    var docs []models.AnggotaDocument
+    if c.Query("riwayat") != "true" { tx = dokumenBerlaku(tx) }
+    if err := tx.Order("uploaded_at DESC, id DESC").Find(&docs).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    err = db.Transaction(func(tx *gorm.DB) error {
+        now := time.Now()
+        for i := range docs {
+            if docs[i].DihapusAt != nil { continue }
+            isiTautan(&docs[i], u.ID, now)
+            if err := catatAksesDokumen(tx, c, docs[i].ID, u.ID, "tautan"); err != nil { return err }
+        }
+        return nil
+    })
+    if err != nil {
+        dokumenError(c, err)
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": docs})
+}
+
+// GET /api/anggota/:id/documents/:docId/url?user_id=...
+// Menerbitkan tautan unduh bertanda tangan untuk satu dokumen; hanya untuk rolePembacaDokumen
+func (h *AnggotaController) TautanDokumen(c *gin.Context) {
+    userID, _ := strconv.Atoi(c.Query("user_id"))
+    var doc models.AnggotaDocument
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        u, err := cariPengguna(tx, uint(userID), rolePembacaDokumen...)
+        if err != nil { return err }
+        if err := tx.Where("id = ? AND anggota_id = ?", c.Param("docId"), c.Param("id")).First(&doc).Error; err != nil { return err }
+        // versi lama yang sudah diganti tetap boleh dibuka sebagai riwayat, dokumen terhapus tidak
+        if doc.DihapusAt != nil { return errDokumenTidakBerlaku }
+        isiTautan(&doc, u.ID, time.Now())
+        return catatAksesDokumen(tx, c, doc.ID, u.ID, "tautan")
+    })
+    if err != nil {
+        dokumenError(c, err)
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"url": doc.URL, "thumbnail_url": doc.ThumbnailURL, "berlaku_sampai": time.Now().Add(masaBerlakuTautan)})
+}
+
+// GET /api/anggota/:id/documents/:docId/unduh?u=...&exp=...&sig=...
+// Mengalirkan isi berkas dari storage jika tanda tangan tautan valid dan belum kedaluwarsa
+func (h *AnggotaController) UnduhDokumen(c *gin.Context) { h.sajikanDokumen(c, false) }
+
+// GET /api/anggota/:id/documents/:docId/thumbnail?u=...&exp=...&sig=...
+// Thumbnail JPEG untuk tampilan daftar; penerbitan tautannya sudah tercatat di log akses
+func (h *AnggotaController) ThumbnailDokumen(c *gin.Context) { h.sajikanDokumen(c, true) }
+
+func (h *AnggotaController) sajikanDokumen(c *gin.Context, thumbnail bool) {
+    docID, _ := strconv.Atoi(c.Param("docId"))
+    userID, _ := strconv.Atoi(c.Query("u"))
+    exp, _ := strconv.ParseInt(c.Query("exp"), 10, 64)
+    sig := tandaTanganDokumen(uint(docID), uint(userID), exp)
+    if time.Now().Unix() > exp || !hmac.Equal([]byte(sig), []byte(c.Query("sig"))) {
+        c.JSON(http.StatusForbidden, gin.H{"error": errTautanTidakSah.Error()})
+        return
+    }
+    var doc models.AnggotaDocument
+    if err := h.DB.Where("id = ? AND anggota_id = ?", docID, c.Param("id")).First(&doc).Error; err != nil {
+        dokumenError(c, err)
+        return
+    }
+    if doc.DihapusAt != nil {
+        dokumenError(c, errDokumenTidakBerlaku)
+        return
+    }
+    key, ukuran, contentType, disposisi, nama := doc.StorageKey, doc.Ukuran, doc.ContentType, "inline", doc.Filename
+    if thumbnail {
+        if doc.ThumbnailKey == "" {
+            dokumenError(c, storage.ErrTidakAda)
+            return
+        }
+        key, ukuran, contentType = doc.ThumbnailKey, -1, "image/jpeg"
+        nama = strings.TrimSuffix(nama, filepath.Ext(nama)) + "_thumb.jpg"
+    }
+    rc, err := h.Storage.Get(c.Request.Context(), key)
+    if err != nil {
+        dokumenError(c, err)
+        return
+    }
+    defer rc.Close()
+    if !thumbnail {
+        if err := catatAksesDokumen(h.DB, c, doc.ID, uint(userID), "unduh"); err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+            return
+        }
+    }
+
+    // dokumen lama tidak menyimpan content type
+    if contentType == "" { contentType = mime.TypeByExtension(strings.ToLower(filepath.Ext(doc.Filename))) }
+    if !tipeInline[contentType] { contentType, disposisi = "application/octet-stream", "attachment" }
+    if ukuran <= 0 { ukuran = -1 }
+    header := map[string]string{
+        "Content-Disposition":    mime.FormatMediaType(disposisi, map[string]string{"filename": nama}),
+        "Cache-Control":          "private, no-store",
+        "X-Content-Type-Options": "nosniff",
+    }
+    if !thumbnail { header["X-Checksum-Sha256"] = doc.Checksum }
+    c.DataFromReader(http.StatusOK, ukuran, contentType, rc, header)
+}
+
+// GET /api/anggota/:id/documents/bundel?user_id=...
+// Menggabungkan semua dokumen yang berlaku menjadi satu PDF untuk arsip fisik: halaman sampul berisi
+// data anggota dan daftar dokumen, satu halaman per gambar, dan dokumen PDF dilampirkan utuh
+func (h *AnggotaController) BundelDokumen(c *gin.Context) {
+    userID, _ := strconv.Atoi(c.Query("user_id"))
+    var anggota models.Anggota
+    var docs []models.AnggotaDocument
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        u, err := cariPengguna(tx, uint(userID), rolePengelolaKeanggotaan...)
+        if err != nil { return err }
+        if err := tx.First(&anggota, c.Param("id")).Error; err != nil { return err }
+        if err := dokumenBerlaku(tx).Where("anggota_id = ?", anggota.ID).Order("jenis, id").Find(&docs).Error; err != nil { return err }
+        for _, d := range docs {
+            if err := catatAksesDokumen(tx, c, d.ID, u.ID, "bundel"); err != nil { return err }
+        }
+        return nil
+    })
+    if err != nil {
+        dokumenError(c, err)
+        return
+    }
+
+    judul := fmt.Sprintf("Dokumen Anggota %s - %s", anggota.NomorAnggota, anggota.Nama)
+    sampul := []string{
+        "Nomor anggota : " + anggota.NomorAnggota,
+        "Nama          : " + anggota.Nama,
+        "NIK           : " + anggota.NIK,
+        "Dicetak       : " + time.Now().Format("02-01-2006 15:04"),
+        "",
+        "Daftar dokumen:",
+    }
+    // halaman sampul harus pertama, padahal isinya baru lengkap setelah semua berkas dibaca
+    type halamanGambar struct {
+        ket  string
+        jpg  []byte
+        w, t int
+    }
+    pdf := berkas.NewPDF(judul)
+    var halaman []halamanGambar
+    for i, d := range docs {
+        ket := fmt.Sprintf("%d. %s - %s (versi %d, %s)", i+1, d.Jenis, d.Filename, d.Versi, d.StatusVerifikasi)
+        data, err := h.bacaBerkas(c, d.StorageKey)
+        if err != nil {
+            sampul = append(sampul, ket+" - berkas tidak dapat dibaca")
+            log.Printf("bundel dokumen %d: %v", d.ID, err)
+            continue
+        }
+        switch tipe := http.DetectContentType(data); tipe {
+        case "application/pdf":
+            nama := fmt.Sprintf("%02d-%s-%s", i+1, d.Jenis, d.Filename)
+            pdf.Lampirkan(nama, tipe, data)
+            sampul = append(sampul, ket+" - terlampir sebagai "+nama)
+        case "image/jpeg", "image/png":
+            jpg, w, t, err := berkas.JPEGUntukPDF(data)
+            if err != nil {
+                sampul = append(sampul, ket+" - gambar tidak dapat dibaca")
+                continue
+            }
+            sampul = append(sampul, ket)
+            halaman = append(halaman, halamanGambar{ket, jpg, w, t})
+        default:
+            sampul = append(sampul, ket+" - tipe "+tipe+" tidak dapat dimasukkan")
+        }
+    }
+    if len(docs) == 0 { sampul = append(sampul, "(tidak ada dokumen)") }
+    sampul = append(sampul, "", "Checksum SHA-256 per dokumen:")
+    for i, d := range docs { sampul = append(sampul, fmt.Sprintf("%d. %s", i+1, d.Checksum)) }
+    pdf.TambahTeks(judul, sampul)
+    for _, g := range halaman { pdf.TambahGambar(g.ket, g.jpg, g.w, g.t) }
+
+    var buf bytes.Buffer
+    if err := pdf.Tulis(&buf); err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    nama := fmt.Sprintf("dokumen-%s.pdf", strings.NewReplacer("/", "-", " ", "_").Replace(anggota.NomorAnggota))
+    c.Header("Cache-Control", "private, no-store")
+    c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": nama}))
+    c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+func (h *AnggotaController) bacaBerkas(c *gin.Context, key string) ([]byte, error) {
+    rc, err := h.Storage.Get(c.Request.Context(), key)
+    if err != nil { return nil, err }
+    defer rc.Close()
+    return io.ReadAll(rc)
+}
+
+// GET /api/anggota/:id/documents/:docId/akses?user_id=...
+// Log penerbitan tautan dan unduhan satu dokumen; hanya untuk rolePembacaDokumen
+func (h *AnggotaController) AksesDokumen(c *gin.Context) {
+    userID, _ := strconv.Atoi(c.Query("user_id"))
+    if _, err := cariPengguna(h.DB, uint(userID), rolePembacaDokumen...); err != nil {
+        dokumenError(c, err)
+        return
+    }
+    var doc models.AnggotaDocument
+    if err := h.DB.Where("id = ? AND anggota_id = ?", c.Param("docId"), c.Param("id")).First(&doc).Error; err != nil {
+        dokumenError(c, err)
+        return
+    }
+    var list []models.DokumenAkses
+    if err := h.DB.Where("dokumen_id = ?", doc.ID).Order("created_at DESC, id DESC").Find(&list).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": list})
+}
+
+func dokumenError(c *gin.Context, err error) {
+    switch {
+    case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, storage.ErrTidakAda):
+        c.JSON(http.StatusNotFound, gin.H{"error": "dokumen tidak ditemukan"})
+    case errors.Is(err, errAksesDitolak):
+        c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+    case errors.Is(err, errJenisDokumen):
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    case errors.Is(err, errTipeDokumen):
+        c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+    case errors.Is(err, errUkuranDokumen):
+        c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+    case errors.Is(err, errDokumenTidakBerlaku):
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+    default:
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+    }
+}
@@ -0,0 +1,195 @@
+package controllers
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "slices"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+
+    "koperasi-desa/service/internal/models"
+    "koperasi-desa/service/internal/settings"
+)
+
+var (
+    errTransisiStatus    = errors.New("perubahan status anggota tidak diperbolehkan")
+    errAlasanWajib       = errors.New("alasan wajib diisi untuk status ini")
+    errAnggotaTidakAktif = errors.New("anggota tidak berstatus aktif")
+    errSyaratAktivasi    = errors.New("syarat aktivasi belum terpenuhi")
+)
+
+// transisiStatusAnggota adalah daftar status tujuan yang boleh dicapai dari setiap status.
+// ditolak, keluar dan meninggal adalah status akhir.
+var transisiStatusAnggota = map[string][]string{
+    "pending":   {"verified", "active", "ditolak"},
+    "verified":  {"active", "ditolak"},
+    "active":    {"nonaktif", "keluar", "meninggal"},
+    "nonaktif":  {"active", "keluar", "meninggal"},
+    "ditolak":   {},
+    "keluar":    {},
+    "meninggal": {},
+}
+
+// statusLewatPenyelesaian hanya dicapai lewat penyelesaian keanggotaan (POST /keluar, /klaim-meninggal) yang
+// melunasi angsuran, mengembalikan simpanan dan membagi hak ahli waris; endpoint status umum menolaknya
+var statusLewatPenyelesaian = map[string]string{"keluar": "/keluar", "meninggal": "/klaim-meninggal"}
+
+// statusButuhAlasan adalah status yang wajib disertai alasan
+var statusButuhAlasan = map[string]bool{"ditolak": true, "nonaktif": true, "keluar": true, "meninggal": true}
+
+// ubahStatusAnggota memvalidasi transisi lalu menyimpan status baru dan mencatatnya ke AnggotaActivity.
+// Setiap transisi ke active, dari jalur mana pun, harus memenuhi syarat aktivasi.
+func ubahStatusAnggota(tx *gorm.DB, a *models.Anggota, status, alasan string) error {
+    boleh := false
+    for _, s := range transisiStatusAnggota[a.Status] {
+        if s == status { boleh = true }
+    }
+    if !boleh { return fmt.Errorf("%w: %s ke %s", errTransisiStatus, a.Status, status) }
+    alasan = strings.TrimSpace(alasan)
+    if statusButuhAlasan[status] && alasan == "" { return errAlasanWajib }
+    if status == "active" {
+        unmet, err := cekSyaratAktivasi(tx, a)
+        if err != nil { return err }
+        if len(unmet) > 0 {
+            pesan := make([]string, len(unmet))
+            for i, u := range unmet { pesan[i] = u.Pesan }
+            return fmt.Errorf("%w: %s", errSyaratAktivasi, strings.Join(pesan, "; "))
+        }
+    }
+
+    note := fmt.Sprintf("Status %s ke %s", a.Status, status)
+    if alasan != "" { note += ": " + alasan }
+    a.Status = status
+    if status == "keluar" || status == "meninggal" {
+        now := time.Now()
+        a.TanggalKeluar = &now
+    }
+    if slices.Contains(statusSelesai, status) { a.NIKAktif = nil }
+    if err := tx.Save(a).Error; err != nil { return err }
+    action := status
+    if status == "active" { action = "activated" }
+    return tx.Create(&models.AnggotaActivity{AnggotaID: a.ID, Action: action, Note: note, CreatedAt: time.Now()}).Error
+}
+
+// cekAnggotaAktif menolak transaksi baru (setoran, pinjaman) untuk anggota yang tidak aktif
+func cekAnggotaAktif(a *models.Anggota) error {
+    if a.Status != "active" { return errAnggotaTidakAktif }
+    return nil
+}
+
+// cekBolehSetor seperti cekAnggotaAktif, tetapi calon anggota (pending/verified) boleh menyetor
+// simpanan pokok sebagai syarat aktivasi.
+func cekBolehSetor(a *models.Anggota, jenis string) error {
+    if jenis == "pokok" && (a.Status == "pending" || a.Status == "verified") { return nil }
+    return cekAnggotaAktif(a)
+}
+
+// SyaratAktivasi adalah satu syarat aktivasi yang belum terpenuhi
+type SyaratAktivasi struct {
+    Kode  string `json:"kode"`
+    Pesan string `json:"pesan"`
+}
+
+// cekSyaratAktivasi mengembalikan daftar syarat aktivasi (settings.keanggotaan) yang belum terpenuhi.
+// Anggota nonaktif sudah pernah aktif sehingga syarat verifikasinya dianggap terpenuhi.
+// Dokumen lama tanpa jenis dianggap sesuai jika nama filenya memuat jenis dokumen.
+func cekSyaratAktivasi(tx *gorm.DB, a *models.Anggota) ([]SyaratAktivasi, error) {
+    ks, err := settings.LoadKeanggotaan(tx)
+    if err != nil { return nil, err }
+    syarat := ks.Aktivasi
+    unmet := []SyaratAktivasi{}
+
+    if syarat.WajibVerifikasi && a.Status != "verified" && a.Status != "nonaktif" {
+        unmet = append(unmet, SyaratAktivasi{Kode: "status_verified", Pesan: "anggota belum diverifikasi (status " + a.Status + ")"})
+    }
+    if len(syarat.DokumenWajib) > 0 {
+        var docs []models.AnggotaDocument
+        if err := dokumenBerlaku(tx).Where("anggota_id = ? AND status_verifikasi <> ?", a.ID, "ditolak").Find(&docs).Error; err != nil { return nil, err }
+        for _, jenis := range syarat.DokumenWajib {
+            jenis = strings.ToLower(strings.TrimSpace(jenis))
+            ada, valid := false, false
+            for _, d := range docs {
+                if d.Jenis == jenis || (d.Jenis == "" && strings.Contains(strings.ToLower(d.Filename), jenis)) {
+                    ada = true
+                    if d.StatusVerifikasi == "valid" { valid = true }
+                }
+            }
+            switch {
+            case !ada:
+                unmet = append(unmet, SyaratAktivasi{Kode: "dokumen_" + jenis, Pesan: "dokumen " + jenis + " belum diunggah"})
+            case syarat.DokumenTerverifikasi && !valid:
+                unmet = append(unmet, SyaratAktivasi{Kode: "dokumen_" + jenis, Pesan: "dokumen " + jenis + " belum diverifikasi"})
+            }
+        }
+    }
+    now := time.Now()
+    if syarat.WajibSimpananPokok || syarat.MinimalSimpananPokok > 0 {
+        pokok, err := saldoPada(tx, a.ID, "pokok", now)
+        if err != nil { return nil, err }
+        if pokok <= 0 {
+            unmet = append(unmet, SyaratAktivasi{Kode: "simpanan_pokok", Pesan: "simpanan pokok belum dibayar"})
+        } else if pokok < syarat.MinimalSimpananPokok {
+            unmet = append(unmet, SyaratAktivasi{Kode: "simpanan_pokok", Pesan: fmt.Sprintf("simpanan pokok %.0f belum memenuhi minimal %.0f", pokok, syarat.MinimalSimpananPokok)})
+        }
+    }
+    if syarat.MinimalSimpananWajib > 0 {
+        wajib, err := saldoPada(tx, a.ID, "wajib", now)
+        if err != nil { return nil, err }
+        if wajib < syarat.MinimalSimpananWajib {
+            unmet = append(unmet, SyaratAktivasi{Kode: "simpanan_wajib", Pesan: fmt.Sprintf("simpanan wajib %.0f belum memenuhi minimal %.0f", wajib, syarat.MinimalSimpananWajib)})
+        }
+    }
+    return unmet, nil
+}
+
+// POST /api/anggota/:id/status { status, alasan }
+type UbahStatusAnggotaInput struct {
+    Status string `json:"status" binding:"required"`
+    Alasan string `json:"alasan"`
+}
+
+func (h *AnggotaController) UbahStatus(c *gin.Context) {
+    var in UbahStatusAnggotaInput
+    if err := c.ShouldBindJSON(&in); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    status := strings.ToLower(strings.TrimSpace(in.Status))
+    if _, ok := transisiStatusAnggota[status]; !ok {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "status tidak dikenal"})
+        return
+    }
+    if ep, ok := statusLewatPenyelesaian[status]; ok {
+        c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("status %s hanya lewat POST /api/anggota/:id%s agar simpanan dan pinjaman diselesaikan", status, ep)})
+        return
+    }
+    var anggota models.Anggota
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.First(&anggota, c.Param("id")).Error; err != nil { return err }
+        return ubahStatusAnggota(tx, &anggota, status, in.Alasan)
+    })
+    if err != nil {
+        statusError(c, err)
+        return
+    }
+    c.JSON(http.StatusOK, anggota)
+}
+
+func statusError(c *gin.Context, err error) {
+    switch {
+    case errors.Is(err, gorm.ErrRecordNotFound):
+        c.JSON(http.StatusNotFound, gin.H{"error": "anggota not found"})
+    case errors.Is(err, errTransisiStatus):
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+    case errors.Is(err, errAlasanWajib):
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    case errors.Is(err, errSyaratAktivasi):
+        c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+    default:
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+    }
+}
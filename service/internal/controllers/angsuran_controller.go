@@ -1,6 +1,8 @@
 package controllers
 
 import (
+    "errors"
+    "fmt"
     "net/http"
     "time"
 
@@ -21,11 +23,17 @@ func (h *AngsuranController) ListAngsuran(c *gin.Context) {
     tx := h.DB.Model(&models.Angsuran{})
     if pinjamanID != "" { tx = tx.Where("pinjaman_id = ?", pinjamanID) }
 
-    if err := tx.Order("ke ASC").Find(&list).Error; err != nil {
+    if err := tx.Order("ke ASC, id ASC").Find(&list).Error; err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
     }
-    c.JSON(http.StatusOK, gin.H{"data": list})
+
+    // Riwayat pembayaran yang dibatalkan lewat koreksi
+    koreksi := []models.Koreksi{}
+    if pinjamanID != "" {
+        _ = h.DB.Where("sumber = ? AND ref_id IN (?)", "angsuran", h.DB.Model(&models.Angsuran{}).Select("id").Where("pinjaman_id = ?", pinjamanID)).Order("created_at DESC").Find(&koreksi).Error
+    }
+    c.JSON(http.StatusOK, gin.H{"data": list, "koreksi": koreksi})
 }
 
 // POST /api/angsuran/bayar
@@ -34,6 +42,7 @@ type BayarAngsuranInput struct {
     AngsuranID   uint       `json:"angsuran_id"`
     Jumlah       float64    `json:"jumlah"`
     TanggalBayar *time.Time `json:"tanggal_bayar"`
+    UserID       uint       `json:"user_id"` // teller; uang masuk ke laci sesi kasirnya
 }
 
 func (h *AngsuranController) Bayar(c *gin.Context) {
@@ -50,37 +59,79 @@ func (h *AngsuranController) Bayar(c *gin.Context) {
     err := h.DB.Transaction(func(tx *gorm.DB) error {
         var a models.Angsuran
         if err := tx.First(&a, in.AngsuranID).Error; err != nil { return err }
-        if a.TanggalBayar != nil { return gorm.ErrInvalidTransaction }
+        if a.TanggalBayar != nil || a.DikoreksiOlehID != nil { return gorm.ErrInvalidTransaction }
 
         // validasi jumlah (minimal sama dengan jumlah angsuran)
         if in.Jumlah < a.Jumlah { return gorm.ErrInvalidTransaction }
 
-        // set tanggal bayar, hitung denda sederhana jika terlambat
         tanggal := time.Now()
         if in.TanggalBayar != nil { tanggal = *in.TanggalBayar }
-        a.TanggalBayar = &tanggal
-        if tanggal.After(a.TanggalJatuhTempo) {
-            // denda flat 1% dari jumlah
-            a.Denda = a.Jumlah * 0.01
-        }
-        if err := tx.Save(&a).Error; err != nil { return err }
-
-        // Jika semua angsuran sudah dibayar, set status pinjaman ke 'lunas'
-        var remaining int64
-        if err := tx.Model(&models.Angsuran{}).Where("pinjaman_id = ? AND tanggal_bayar IS NULL", a.PinjamanID).Count(&remaining).Error; err != nil { return err }
-        if remaining == 0 {
-            _ = tx.Model(&models.Pinjaman{}).Where("id = ?", a.PinjamanID).Update("status", "lunas").Error
-        }
-        return nil
+        if err := bayarAngsuran(tx, &a, tanggal); err != nil { return err }
+        return catatTransaksiKasir(tx, in.UserID, &models.TransaksiKasir{
+            Jenis: "angsuran", Sumber: "angsuran", RefID: a.ID, Arah: "masuk", Jumlah: a.Jumlah + a.Denda, NomorBukti: a.NomorBukti,
+            Keterangan: fmt.Sprintf("Angsuran ke-%d pinjaman #%d", a.Ke, a.PinjamanID),
+        })
     })
 
     if err != nil {
         if err == gorm.ErrInvalidTransaction {
             c.JSON(http.StatusBadRequest, gin.H{"error": "transaksi tidak valid"})
+        } else if err == errPeriodeTertutup || errors.Is(err, errSesiKasirTidakAda) {
+            c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
         } else {
             c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         }
         return
     }
     c.JSON(http.StatusOK, gin.H{"ok": true})
-}
\ No newline at end of file
+}
+
+// angsuranBerlaku menyaring baris angsuran yang pembayarannya sudah dibatalkan lewat koreksi;
+// tagihannya diwakili baris pengganti
+func angsuranBerlaku(db *gorm.DB) *gorm.DB { return db.Where("dikoreksi_oleh_id IS NULL") }
+
+// dendaAngsuran menghitung denda flat 1% dari jumlah angsuran jika dibayar melewati tanggal jatuh tempo.
+// Dibandingkan per tanggal kalender karena jatuh tempo membawa jam pencairan; bayar di hari jatuh tempo tidak didenda.
+func dendaAngsuran(a *models.Angsuran, tanggal time.Time) float64 {
+    if tanggalSaja(tanggal) > tanggalSaja(a.TanggalJatuhTempo) { return a.Jumlah * 0.01 }
+    return 0
+}
+
+// pokokAngsuran mengembalikan porsi piutang satu angsuran (bunga flat: (nominal + biaya diangsur) / tenor).
+// Biaya yang diangsur sudah diakui saat pencairan sehingga pelunasannya mengurangi piutang.
+func pokokAngsuran(p *models.Pinjaman, a *models.Angsuran) float64 {
+    if p.TenorBulan > 0 { return (p.Nominal + p.BiayaDiangsur) / float64(p.TenorBulan) }
+    return a.Jumlah
+}
+
+// bayarAngsuran mencatat pembayaran satu angsuran beserta dendanya, memposting jurnal,
+// dan menandai pinjaman lunas jika tidak ada lagi angsuran terutang.
+func bayarAngsuran(tx *gorm.DB, a *models.Angsuran, tanggal time.Time) error {
+    if err := cekPeriodeTerbuka(tx, tanggal); err != nil { return err }
+    a.TanggalBayar = &tanggal
+    a.Denda = dendaAngsuran(a, tanggal)
+    nomor, err := nomorBerikutnya(tx, "kuitansi", tanggal)
+    if err != nil { return err }
+    a.NomorBukti = nomor
+    if err := tx.Save(a).Error; err != nil { return err }
+
+    // Jurnal: kas masuk sebesar angsuran + denda, dipecah ke pokok (piutang), bunga, dan denda
+    var p models.Pinjaman
+    if err := tx.First(&p, a.PinjamanID).Error; err != nil { return err }
+    pokok := pokokAngsuran(&p, a)
+    if _, err := postJurnal(tx, tanggal, "angsuran", a.ID, fmt.Sprintf("Angsuran ke-%d pinjaman %s", a.Ke, p.NomorPinjaman),
+        debit(models.AkunKas, a.Jumlah+a.Denda),
+        kredit(models.AkunPiutangPinjaman, pokok),
+        kredit(models.AkunPendapatanBunga, a.Jumlah-pokok),
+        kredit(models.AkunPendapatanDenda, a.Denda)); err != nil {
+        return err
+    }
+
+    // Jika semua angsuran sudah dibayar, set status pinjaman ke 'lunas'
+    var remaining int64
+    if err := tx.Model(&models.Angsuran{}).Scopes(angsuranBerlaku).Where("pinjaman_id = ? AND tanggal_bayar IS NULL", a.PinjamanID).Count(&remaining).Error; err != nil { return err }
+    if remaining == 0 {
+        return tx.Model(&models.Pinjaman{}).Where("id = ?", a.PinjamanID).Update("status", "lunas").Error
+    }
+    return nil
+}
@@ -0,0 +1,29 @@
+package controllers
+
+import (
+    "testing"
+    "time"
+
+    "koperasi-desa/service/internal/models"
+)
+
+func TestDendaAngsuran(t *testing.T) {
+    // jatuh tempo membawa jam pencairan
+    a := models.Angsuran{Jumlah: 250_000, TanggalJatuhTempo: time.Date(2026, 10, 10, 14, 30, 0, 0, time.Local)}
+    cases := []struct {
+        nama  string
+        bayar time.Time
+        ingin float64
+    }{
+        {"sebelum jatuh tempo", time.Date(2026, 10, 9, 9, 0, 0, 0, time.Local), 0},
+        {"hari jatuh tempo sebelum jam pencairan", time.Date(2026, 10, 10, 8, 0, 0, 0, time.Local), 0},
+        {"hari jatuh tempo sesudah jam pencairan", time.Date(2026, 10, 10, 23, 59, 0, 0, time.Local), 0},
+        {"sehari sesudah jatuh tempo", time.Date(2026, 10, 11, 0, 1, 0, 0, time.Local), 2_500},
+        {"denda flat tidak bertambah per hari", time.Date(2026, 12, 1, 10, 0, 0, 0, time.Local), 2_500},
+    }
+    for _, c := range cases {
+        t.Run(c.nama, func(t *testing.T) {
+            if got := dendaAngsuran(&a, c.bayar); got != c.ingin { t.Errorf("denda = %v, ingin %v", got, c.ingin) }
+        })
+    }
+}
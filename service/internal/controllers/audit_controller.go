@@ -0,0 +1,72 @@
+package controllers
+
+import (
+    "errors"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+
+    "koperasi-desa/service/internal/models"
+)
+
+var errAksesDitolak = errors.New("pengguna tidak memiliki akses untuk aksi ini")
+
+type AuditController struct { DB *gorm.DB }
+func NewAuditController(db *gorm.DB) *AuditController { return &AuditController{DB: db} }
+
+// GET /api/audit-log?entity=...&entity_id=...&user_id=...&page=...&limit=...
+func (h *AuditController) ListAuditLog(c *gin.Context) {
+    var list []models.AuditLog
+    page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+    limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+    if page < 1 { page = 1 }
+    if limit < 1 || limit > 100 { limit = 20 }
+    offset := (page - 1) * limit
+
+    entity := strings.TrimSpace(c.Query("entity"))
+    entityID := strings.TrimSpace(c.Query("entity_id"))
+    userID := strings.TrimSpace(c.Query("user_id"))
+
+    tx := h.DB.Model(&models.AuditLog{})
+    if entity != "" { tx = tx.Where("entity = ?", entity) }
+    if entityID != "" { tx = tx.Where("entity_id = ?", entityID) }
+    if userID != "" { tx = tx.Where("user_id = ?", userID) }
+
+    if err := tx.Order("timestamp DESC, id DESC").Limit(limit).Offset(offset).Find(&list).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": list, "page": page, "limit": limit})
+}
+
+// catatAudit menambahkan baris audit log di dalam transaksi yang sedang berjalan
+func catatAudit(tx *gorm.DB, userID uint, action, entity string, entityID uint, note string) error {
+    return tx.Create(&models.AuditLog{
+        UserID:    userID,
+        Action:    action,
+        Entity:    entity,
+        EntityID:  entityID,
+        Note:      note,
+        Timestamp: time.Now(),
+    }).Error
+}
+
+// cariPengguna memuat user berdasarkan ID dan, jika roles diberikan, memastikan role user termasuk di dalamnya.
+// Mengembalikan errAksesDitolak jika user tidak ada atau role tidak sesuai.
+func cariPengguna(tx *gorm.DB, userID uint, roles ...string) (*models.User, error) {
+    var u models.User
+    if err := tx.First(&u, userID).Error; err != nil {
+        if err == gorm.ErrRecordNotFound { return nil, errAksesDitolak }
+        return nil, err
+    }
+    if len(roles) == 0 { return &u, nil }
+    role := strings.ToLower(strings.TrimSpace(u.Role))
+    for _, r := range roles {
+        if role == r { return &u, nil }
+    }
+    return nil, errAksesDitolak
+}
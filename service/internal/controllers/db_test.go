@@ -0,0 +1,36 @@
+package controllers
+
+import (
+    "fmt"
+    "sync/atomic"
+    "testing"
+
+    "gorm.io/driver/sqlite"
+    "gorm.io/gorm"
+    "gorm.io/gorm/logger"
+
+    "koperasi-desa/service/internal/models"
+)
+
+var nomorDBUji atomic.Int64
+
+// dbUji membuka database SQLite di memori yang terpisah untuk setiap test, dengan tabel yang
+// dipakai perhitungan simpanan, pinjaman dan jurnal serta bagan akun bawaan
+func dbUji(t *testing.T) *gorm.DB {
+    t.Helper()
+    dsn := fmt.Sprintf("file:uji%d?mode=memory&cache=shared", nomorDBUji.Add(1))
+    db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+    if err != nil { t.Fatal(err) }
+    sqlDB, err := db.DB()
+    if err != nil { t.Fatal(err) }
+    t.Cleanup(func() { sqlDB.Close() })
+    if err := db.AutoMigrate(
+        &models.User{}, &models.Anggota{}, &models.Simpanan{}, &models.SimpananBerjangka{}, &models.Pinjaman{}, &models.Angsuran{},
+        &models.BiayaPinjaman{}, &models.AutoDebitLog{}, &models.SkorKredit{}, &models.Setting{}, &models.PeriodeAkuntansi{},
+        &models.Akun{}, &models.Jurnal{}, &models.JurnalDetail{}, &models.TutupBuku{}, &models.NomorUrut{},
+    ); err != nil {
+        t.Fatal(err)
+    }
+    if err := db.Create(&models.BaganAkunDefault).Error; err != nil { t.Fatal(err) }
+    return db
+}
@@ -0,0 +1,324 @@
+package controllers
+
+import (
+    "errors"
+    "fmt"
+    "math"
+    "net/http"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+
+    "koperasi-desa/service/internal/models"
+)
+
+var (
+    errJurnalTidakSeimbang = errors.New("jurnal tidak seimbang")
+    errAkunTidakValid      = errors.New("akun tidak valid")
+)
+
+type JurnalController struct { DB *gorm.DB }
+func NewJurnalController(db *gorm.DB) *JurnalController { return &JurnalController{DB: db} }
+
+// barisJurnal adalah satu baris debit/kredit yang akan diposting
+type barisJurnal struct {
+    Akun   string
+    Debit  float64
+    Kredit float64
+}
+
+func debit(akun string, n float64) barisJurnal  { return barisJurnal{Akun: akun, Debit: n} }
+func kredit(akun string, n float64) barisJurnal { return barisJurnal{Akun: akun, Kredit: n} }
+
+// postJurnal memposting jurnal setelah memastikan periode tanggal masih terbuka
+func postJurnal(tx *gorm.DB, tanggal time.Time, sumber string, refID uint, keterangan string, lines ...barisJurnal) (*models.Jurnal, error) {
+    if err := cekPeriodeTerbuka(tx, tanggal); err != nil { return nil, err }
+    return simpanJurnal(tx, tanggal, sumber, refID, keterangan, lines...)
+}
+
+// simpanJurnal menyimpan jurnal tanpa cek periode (dipakai proses tutup buku).
+// Baris bernilai nol dilewati; total debit harus sama dengan total kredit.
+func simpanJurnal(tx *gorm.DB, tanggal time.Time, sumber string, refID uint, keterangan string, lines ...barisJurnal) (*models.Jurnal, error) {
+    j := models.Jurnal{Tanggal: tanggal, Sumber: sumber, RefID: refID, Keterangan: keterangan}
+    var d, k float64
+    for _, l := range lines {
+        if math.Abs(l.Debit) < 0.005 && math.Abs(l.Kredit) < 0.005 { continue }
+        j.Details = append(j.Details, models.JurnalDetail{AkunKode: l.Akun, Debit: l.Debit, Kredit: l.Kredit})
+        d += l.Debit
+        k += l.Kredit
+    }
+    if len(j.Details) == 0 { return &j, nil }
+    if math.Abs(d-k) > 0.01 { return nil, errJurnalTidakSeimbang }
+    if err := tx.Create(&j).Error; err != nil { return nil, err }
+    return &j, nil
+}
+
+// balikJurnal memposting jurnal pembalik sebesar saldo bersih seluruh jurnal milik sumber+refID,
+// sehingga setelahnya pengaruh transaksi asal terhadap setiap akun menjadi nol.
+func balikJurnal(tx *gorm.DB, sumber string, refID uint, tanggal time.Time, keterangan string) error {
+    type net struct {
+        AkunKode string
+        Debit    float64
+        Kredit   float64
+    }
+    var rows []net
+    err := tx.Model(&models.JurnalDetail{}).
+        Select("jurnal_details.akun_kode, SUM(jurnal_details.debit) AS debit, SUM(jurnal_details.kredit) AS kredit").
+        Joins("JOIN jurnals ON jurnals.id = jurnal_details.jurnal_id").
+        Where("jurnals.sumber = ? AND jurnals.ref_id = ?", sumber, refID).
+        Group("jurnal_details.akun_kode").Scan(&rows).Error
+    if err != nil { return err }
+    var lines []barisJurnal
+    for _, r := range rows {
+        selisih := r.Debit - r.Kredit
+        if selisih > 0 {
+            lines = append(lines, kredit(r.AkunKode, selisih))
+        } else if selisih < 0 {
+            lines = append(lines, debit(r.AkunKode, -selisih))
+        }
+    }
+    _, err = postJurnal(tx, tanggal, sumber, refID, keterangan, lines...)
+    return err
+}
+
+// akunSimpanan memetakan jenis simpanan ke akun jurnal
+func akunSimpanan(jenis string) string {
+    switch jenis {
+    case "pokok":
+        return models.AkunSimpananPokok
+    case "wajib":
+        return models.AkunSimpananWajib
+    case "khusus":
+        return models.AkunSimpananKhusus
+    default:
+        return models.AkunSimpananSukarela
+    }
+}
+
+// jurnalSimpanan memposting jurnal untuk satu transaksi simpanan.
+// akunLawan kosong berarti akun lawan default menurut tipe (kas, beban bunga, atau utang pajak).
+func jurnalSimpanan(tx *gorm.DB, rec *models.Simpanan, akunLawan string) error {
+    if akunLawan == "" {
+        switch rec.Tipe {
+        case "bunga":
+            akunLawan = models.AkunBebanBungaSimpan
+        case "pajak_bunga":
+            akunLawan = models.AkunUtangPajakBunga
+        default:
+            akunLawan = models.AkunKas
+        }
+    }
+    akun := akunSimpanan(rec.Jenis)
+    ket := rec.Keterangan
+    if ket == "" { ket = rec.Tipe + " simpanan " + rec.Jenis }
+    var err error
+    if saldoDelta(rec.Tipe, rec.Jumlah) > 0 {
+        _, err = postJurnal(tx, rec.Tanggal, "simpanan", rec.ID, ket, debit(akunLawan, rec.Jumlah), kredit(akun, rec.Jumlah))
+    } else {
+        _, err = postJurnal(tx, rec.Tanggal, "simpanan", rec.ID, ket, debit(akun, rec.Jumlah), kredit(akunLawan, rec.Jumlah))
+    }
+    return err
+}
+
+// SaldoAkun adalah saldo satu akun pada neraca saldo.
+// Saldo mengikuti saldo normal akun: aset/beban = debit - kredit, lainnya = kredit - debit.
+type SaldoAkun struct {
+    Kode   string  `json:"kode"`
+    Nama   string  `json:"nama"`
+    Tipe   string  `json:"tipe"`
+    Debit  float64 `json:"debit"`
+    Kredit float64 `json:"kredit"`
+    Saldo  float64 `json:"saldo"`
+}
+
+// hitungSaldoAkun menjumlahkan jurnal per akun untuk tanggal dalam [dari, sampai).
+// dari nol berarti sejak awal; sumber yang disebut di kecuali tidak dihitung.
+func hitungSaldoAkun(db *gorm.DB, dari, sampai time.Time, kecuali ...string) ([]SaldoAkun, error) {
+    var akun []models.Akun
+    if err := db.Order("kode ASC").Find(&akun).Error; err != nil { return nil, err }
+    type agg struct {
+        AkunKode string
+        Debit    float64
+        Kredit   float64
+    }
+    q := db.Model(&models.JurnalDetail{}).
+        Select("jurnal_details.akun_kode, SUM(jurnal_details.debit) AS debit, SUM(jurnal_details.kredit) AS kredit").
+        Joins("JOIN jurnals ON jurnals.id = jurnal_details.jurnal_id").
+        Where("jurnals.tanggal < ?", sampai)
+    if !dari.IsZero() { q = q.Where("jurnals.tanggal >= ?", dari) }
+    if len(kecuali) > 0 { q = q.Where("jurnals.sumber NOT IN ?", kecuali) }
+    var rows []agg
+    if err := q.Group("jurnal_details.akun_kode").Scan(&rows).Error; err != nil { return nil, err }
+    byKode := map[string]agg{}
+    for _, r := range rows { byKode[r.AkunKode] = r }
+
+    out := []SaldoAkun{}
+    for _, a := range akun {
+        r := byKode[a.Kode]
+        s := SaldoAkun{Kode: a.Kode, Nama: a.Nama, Tipe: a.Tipe, Debit: r.Debit, Kredit: r.Kredit}
+        if a.Tipe == "aset" || a.Tipe == "beban" {
+            s.Saldo = r.Debit - r.Kredit
+        } else {
+            s.Saldo = r.Kredit - r.Debit
+        }
+        out = append(out, s)
+    }
+    sort.Slice(out, func(i, j int) bool { return out[i].Kode < out[j].Kode })
+    return out, nil
+}
+
+// GET /api/akun
+func (h *JurnalController) ListAkun(c *gin.Context) {
+    var list []models.Akun
+    if err := h.DB.Order("kode ASC").Find(&list).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": list})
+}
+
+// GET /api/jurnal?dari=YYYY-MM-DD&sampai=YYYY-MM-DD&sumber=...&ref_id=...&page=...&limit=...
+func (h *JurnalController) ListJurnal(c *gin.Context) {
+    var list []models.Jurnal
+    page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+    limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+    if page < 1 { page = 1 }
+    if limit < 1 || limit > 100 { limit = 20 }
+    offset := (page - 1) * limit
+
+    tx := h.DB.Model(&models.Jurnal{})
+    if t, err := time.ParseInLocation("2006-01-02", c.Query("dari"), time.Local); err == nil { tx = tx.Where("tanggal >= ?", t) }
+    if t, err := time.ParseInLocation("2006-01-02", c.Query("sampai"), time.Local); err == nil { tx = tx.Where("tanggal < ?", t.AddDate(0, 0, 1)) }
+    if sumber := strings.TrimSpace(c.Query("sumber")); sumber != "" { tx = tx.Where("sumber = ?", sumber) }
+    if refID := strings.TrimSpace(c.Query("ref_id")); refID != "" { tx = tx.Where("ref_id = ?", refID) }
+
+    if err := tx.Preload("Details").Order("tanggal DESC, id DESC").Limit(limit).Offset(offset).Find(&list).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": list, "page": page, "limit": limit})
+}
+
+// GET /api/jurnal/neraca-saldo?tanggal=YYYY-MM-DD
+// Jika tahun dari tanggal sudah tutup buku dan tanggal = akhir tahun, gunakan /api/tutup-buku/:tahun.
+func (h *JurnalController) NeracaSaldo(c *gin.Context) {
+    sampai := time.Now()
+    if t, err := time.ParseInLocation("2006-01-02", c.Query("tanggal"), time.Local); err == nil { sampai = t.AddDate(0, 0, 1) }
+    list, err := hitungSaldoAkun(h.DB, time.Time{}, sampai)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": list})
+}
+
+// GET /api/kas?dari=YYYY-MM-DD&sampai=YYYY-MM-DD&page=...&limit=...
+// Buku kas umum: seluruh mutasi akun kas dari jurnal.
+func (h *JurnalController) ListKas(c *gin.Context) {
+    type mutasi struct {
+        JurnalID   uint      `json:"jurnal_id"`
+        Tanggal    time.Time `json:"tanggal"`
+        Keterangan string    `json:"keterangan"`
+        Sumber     string    `json:"sumber"`
+        RefID      uint      `json:"ref_id"`
+        Debit      float64   `json:"masuk"`
+        Kredit     float64   `json:"keluar"`
+    }
+    page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+    limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+    if page < 1 { page = 1 }
+    if limit < 1 || limit > 100 { limit = 20 }
+    offset := (page - 1) * limit
+
+    q := h.DB.Model(&models.JurnalDetail{}).
+        Joins("JOIN jurnals ON jurnals.id = jurnal_details.jurnal_id").
+        Where("jurnal_details.akun_kode = ?", models.AkunKas)
+    if t, err := time.ParseInLocation("2006-01-02", c.Query("dari"), time.Local); err == nil { q = q.Where("jurnals.tanggal >= ?", t) }
+    if t, err := time.ParseInLocation("2006-01-02", c.Query("sampai"), time.Local); err == nil { q = q.Where("jurnals.tanggal < ?", t.AddDate(0, 0, 1)) }
+
+    var list []mutasi
+    err := q.Session(&gorm.Session{}).
+        Select("jurnals.id AS jurnal_id, jurnals.tanggal, jurnals.keterangan, jurnals.sumber, jurnals.ref_id, jurnal_details.debit, jurnal_details.kredit").
+        Order("jurnals.tanggal DESC, jurnals.id DESC").Limit(limit).Offset(offset).Scan(&list).Error
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    var saldo float64
+    if err := h.DB.Model(&models.JurnalDetail{}).Where("akun_kode = ?", models.AkunKas).Select("COALESCE(SUM(debit - kredit), 0)").Scan(&saldo).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": list, "page": page, "limit": limit, "saldo": saldo})
+}
+
+// POST /api/kas/in  { jumlah, keterangan, akun_lawan?, ref?, tanggal?, user_id? }
+// POST /api/kas/out { jumlah, keterangan, akun_lawan?, ref?, tanggal?, user_id? }
+// akun_lawan hanya akun pendapatan atau beban; simpanan, pinjaman dan ekuitas dicatat lewat modulnya sendiri
+// agar buku besar tetap sesuai buku pembantu.
+type KasInput struct {
+    Jumlah     float64    `json:"jumlah" binding:"required,gt=0"`
+    Keterangan string     `json:"keterangan" binding:"required"`
+    AkunLawan  string     `json:"akun_lawan"`
+    Ref        string     `json:"ref"`
+    Tanggal    *time.Time `json:"tanggal"`
+    UserID     uint       `json:"user_id"` // teller; uang masuk/keluar lewat laci sesi kasirnya
+}
+
+func (h *JurnalController) KasMasuk(c *gin.Context) { h.catatKas(c, "masuk") }
+func (h *JurnalController) KasKeluar(c *gin.Context) { h.catatKas(c, "keluar") }
+
+func (h *JurnalController) catatKas(c *gin.Context, jenis string) {
+    var in KasInput
+    if err := c.ShouldBindJSON(&in); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    tanggal := time.Now()
+    if in.Tanggal != nil { tanggal = *in.Tanggal }
+    if err := cekTanggalMasaDepan(tanggal); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    lawan := strings.TrimSpace(in.AkunLawan)
+    if lawan == "" {
+        lawan = models.AkunPendapatanLain
+        if jenis == "keluar" { lawan = models.AkunBebanOperasional }
+    }
+
+    k := models.Kas{Tanggal: tanggal, Jenis: jenis, Keterangan: in.Keterangan, Jumlah: in.Jumlah, AkunLawan: lawan, Ref: in.Ref}
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        var akun models.Akun
+        if err := tx.First(&akun, "kode = ?", lawan).Error; err != nil { return errAkunTidakValid }
+        if akun.Tipe != "pendapatan" && akun.Tipe != "beban" { return fmt.Errorf("%w: akun lawan kas harus akun pendapatan atau beban", errAkunTidakValid) }
+        if err := cekPeriodeTerbuka(tx, tanggal); err != nil { return err }
+        var err error
+        if k.NomorBukti, err = nomorBerikutnya(tx, "kuitansi", tanggal); err != nil { return err }
+        if err := tx.Create(&k).Error; err != nil { return err }
+        if jenis == "masuk" {
+            _, err = postJurnal(tx, tanggal, "kas", k.ID, in.Keterangan, debit(models.AkunKas, in.Jumlah), kredit(lawan, in.Jumlah))
+        } else {
+            _, err = postJurnal(tx, tanggal, "kas", k.ID, in.Keterangan, debit(lawan, in.Jumlah), kredit(models.AkunKas, in.Jumlah))
+        }
+        if err != nil { return err }
+        return catatTransaksiKasir(tx, in.UserID, &models.TransaksiKasir{
+            Jenis: "kas_" + jenis, Sumber: "kas", RefID: k.ID, Arah: jenis, Jumlah: in.Jumlah, NomorBukti: k.NomorBukti, Keterangan: in.Keterangan,
+        })
+    })
+    if err != nil {
+        switch {
+        case errors.Is(err, errAkunTidakValid):
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        case err == errPeriodeTertutup, errors.Is(err, errSesiKasirTidakAda), errors.Is(err, errKasLaciKurang):
+            c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+        default:
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+    c.JSON(http.StatusCreated, k)
+}
@@ -0,0 +1,358 @@
+package controllers
+
+import (
+    "errors"
+    "fmt"
+    "math"
+    "net/http"
+    "slices"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+
+    "koperasi-desa/service/internal/models"
+    "koperasi-desa/service/internal/settings"
+)
+
+var (
+    errSesiKasirTidakAda  = errors.New("petugas belum membuka sesi kasir")
+    errSesiKasirMasihBuka = errors.New("petugas masih memiliki sesi kasir yang terbuka")
+    errSesiKasirStatus    = errors.New("status sesi kasir tidak sesuai untuk aksi ini")
+    errKasLaciKurang      = errors.New("uang di laci kasir tidak cukup")
+    errInputKasir         = errors.New("input sesi kasir tidak valid")
+    errPenyetujuTeller    = errors.New("sesi kasir harus disetujui oleh supervisor selain teller")
+)
+
+// roleKasir boleh membuka sesi laci kas di loket; roleSupervisorKasir menyetujui hasil tutup kas
+var (
+    roleKasir           = []string{"kasir", "petugas", "admin", "bendahara"}
+    roleSupervisorKasir = []string{"admin", "bendahara", "ketua"}
+)
+
+type KasirController struct { DB *gorm.DB }
+func NewKasirController(db *gorm.DB) *KasirController { return &KasirController{DB: db} }
+
+func kasirError(c *gin.Context, err error) {
+    switch {
+    case errors.Is(err, gorm.ErrRecordNotFound):
+        c.JSON(http.StatusNotFound, gin.H{"error": "sesi kasir tidak ditemukan"})
+    case errors.Is(err, errInputKasir):
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    case errors.Is(err, errAksesDitolak), errors.Is(err, errPenyetujuTeller):
+        c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+    case errors.Is(err, errSesiKasirTidakAda), errors.Is(err, errSesiKasirMasihBuka), errors.Is(err, errSesiKasirStatus),
+        errors.Is(err, errKasLaciKurang), errors.Is(err, errPeriodeTertutup):
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+    default:
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+    }
+}
+
+// saldoLaci adalah uang yang seharusnya ada di laci sesi saat ini
+func saldoLaci(s *models.SesiKasir) float64 {
+    return bulatSen(s.SaldoAwal + s.TotalMasuk - s.TotalKeluar)
+}
+
+func bulatSen(n float64) float64 { return math.Round(n*100) / 100 }
+
+// catatTransaksiKasir mengikat transaksi tunai di loket ke sesi kasir yang sedang dibuka userID dan
+// memperbarui total sesi. Tanpa sesi terbuka transaksi ditolak bila settings.kasir.wajib_sesi; jika
+// tidak wajib, transaksi tetap berjalan tanpa ikatan. Pengeluaran melebihi isi laci ditolak.
+func catatTransaksiKasir(tx *gorm.DB, userID uint, t *models.TransaksiKasir) error {
+    cfg, err := settings.LoadKasir(tx)
+    if err != nil { return err }
+    var s models.SesiKasir
+    err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ? AND status = ?", userID, "buka").First(&s).Error
+    if errors.Is(err, gorm.ErrRecordNotFound) || userID == 0 {
+        if cfg.WajibSesi { return errSesiKasirTidakAda }
+        return nil
+    }
+    if err != nil { return err }
+    return catatKeSesi(tx, &s, t)
+}
+
+// catatKeSesi mencatat transaksi pada sesi s yang sudah dikunci pemanggil dan memperbarui totalnya
+func catatKeSesi(tx *gorm.DB, s *models.SesiKasir, t *models.TransaksiKasir) error {
+    if t.Arah == "keluar" {
+        if t.Jumlah > saldoLaci(s) { return fmt.Errorf("%w: isi laci %s, dibutuhkan %s", errKasLaciKurang, rupiah(saldoLaci(s)), rupiah(t.Jumlah)) }
+        s.TotalKeluar = bulatSen(s.TotalKeluar + t.Jumlah)
+    } else {
+        s.TotalMasuk = bulatSen(s.TotalMasuk + t.Jumlah)
+    }
+    t.SesiID = s.ID
+    if err := tx.Create(t).Error; err != nil { return err }
+    return tx.Model(s).Updates(map[string]interface{}{"total_masuk": s.TotalMasuk, "total_keluar": s.TotalKeluar}).Error
+}
+
+// POST /api/kasir/sesi/buka { user_id, saldo_awal, catatan }
+// Membuka sesi laci kas dengan modal awal (uang yang diterima teller dari brankas). Modal awal tidak
+// dijurnal karena laci dan brankas sama-sama akun Kas.
+type BukaSesiInput struct {
+    UserID    uint    `json:"user_id" binding:"required"`
+    SaldoAwal float64 `json:"saldo_awal"`
+    Catatan   string  `json:"catatan"`
+}
+
+func (h *KasirController) BukaSesi(c *gin.Context) {
+    var in BukaSesiInput
+    if err := c.ShouldBindJSON(&in); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if in.SaldoAwal < 0 {
+        kasirError(c, fmt.Errorf("%w: saldo_awal tidak boleh negatif", errInputKasir))
+        return
+    }
+    var s models.SesiKasir
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        u, err := cariPengguna(tx, in.UserID, roleKasir...)
+        if err != nil { return err }
+        // baris pengguna dikunci agar dua permintaan buka sesi tidak berjalan bersamaan
+        if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.User{}, u.ID).Error; err != nil { return err }
+        var n int64
+        if err := tx.Model(&models.SesiKasir{}).Where("user_id = ? AND status = ?", u.ID, "buka").Count(&n).Error; err != nil { return err }
+        if n > 0 { return errSesiKasirMasihBuka }
+        now := time.Now()
+        s = models.SesiKasir{
+            UserID:      u.ID,
+            Tanggal:     tanggalSaja(now),
+            Status:      "buka",
+            SaldoAwal:   bulatSen(in.SaldoAwal),
+            CatatanBuka: strings.TrimSpace(in.Catatan),
+            DibukaAt:    now,
+        }
+        if err := tx.Create(&s).Error; err != nil { return err }
+        return catatAudit(tx, u.ID, "sesi_kasir_dibuka", "sesi_kasir", s.ID, rupiah(s.SaldoAwal))
+    })
+    if err != nil {
+        kasirError(c, err)
+        return
+    }
+    c.JSON(http.StatusCreated, gin.H{"data": s})
+}
+
+// GET /api/kasir/sesi/aktif?user_id=
+// Sesi yang sedang dibuka petugas (null jika belum ada) beserta isi laci saat ini
+func (h *KasirController) SesiAktif(c *gin.Context) {
+    var s models.SesiKasir
+    err := h.DB.Where("user_id = ? AND status = ?", c.Query("user_id"), "buka").First(&s).Error
+    if errors.Is(err, gorm.ErrRecordNotFound) {
+        c.JSON(http.StatusOK, gin.H{"data": nil})
+        return
+    }
+    if err != nil {
+        kasirError(c, err)
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": s, "saldo_laci": saldoLaci(&s)})
+}
+
+// GET /api/kasir/sesi?tanggal=YYYY-MM-DD&user_id=&status=
+func (h *KasirController) ListSesi(c *gin.Context) {
+    tx := h.DB.Model(&models.SesiKasir{})
+    if t := strings.TrimSpace(c.Query("tanggal")); t != "" { tx = tx.Where("tanggal = ?", t) }
+    if u := strings.TrimSpace(c.Query("user_id")); u != "" { tx = tx.Where("user_id = ?", u) }
+    if s := strings.TrimSpace(c.Query("status")); s != "" { tx = tx.Where("status = ?", s) }
+    list := []models.SesiKasir{}
+    if err := tx.Order("dibuka_at DESC, id DESC").Limit(200).Find(&list).Error; err != nil {
+        kasirError(c, err)
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": list})
+}
+
+// GET /api/kasir/sesi/:id → sesi beserta transaksi dan hitungan pecahan
+func (h *KasirController) GetSesi(c *gin.Context) {
+    var s models.SesiKasir
+    err := h.DB.Preload("Transaksi", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+        Preload("Hitungan", func(db *gorm.DB) *gorm.DB { return db.Order("pecahan DESC") }).
+        First(&s, c.Param("id")).Error
+    if err != nil {
+        kasirError(c, err)
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": s, "saldo_laci": saldoLaci(&s)})
+}
+
+// POST /api/kasir/sesi/:id/tutup { user_id, pecahan: [{ pecahan, lembar }], catatan }
+// Teller (atau supervisor bila teller berhalangan) menutup sesi dengan hasil hitung uang per pecahan.
+// Selisih = hitungan - saldo seharusnya; sesi menunggu persetujuan supervisor.
+type HitunganInput struct {
+    Pecahan float64 `json:"pecahan"`
+    Lembar  int     `json:"lembar"`
+}
+
+type TutupSesiInput struct {
+    UserID  uint            `json:"user_id" binding:"required"`
+    Pecahan []HitunganInput `json:"pecahan"`
+    Catatan string          `json:"catatan"`
+}
+
+func (h *KasirController) TutupSesi(c *gin.Context) {
+    var in TutupSesiInput
+    if err := c.ShouldBindJSON(&in); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    var s models.SesiKasir
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        u, err := cariPengguna(tx, in.UserID)
+        if err != nil { return err }
+        if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&s, c.Param("id")).Error; err != nil { return err }
+        if s.UserID != u.ID {
+            if _, err := cariPengguna(tx, u.ID, roleSupervisorKasir...); err != nil { return err }
+        }
+        if s.Status != "buka" { return errSesiKasirStatus }
+        cfg, err := settings.LoadKasir(tx)
+        if err != nil { return err }
+
+        var hitungan []models.HitunganKasir
+        dihitung := 0.0
+        for _, p := range in.Pecahan {
+            if !slices.Contains(cfg.Pecahan, p.Pecahan) { return fmt.Errorf("%w: pecahan %v tidak dikenal", errInputKasir, p.Pecahan) }
+            if p.Lembar < 0 { return fmt.Errorf("%w: lembar pecahan %v tidak boleh negatif", errInputKasir, p.Pecahan) }
+            if slices.ContainsFunc(hitungan, func(x models.HitunganKasir) bool { return x.Pecahan == p.Pecahan }) {
+                return fmt.Errorf("%w: pecahan %v ganda", errInputKasir, p.Pecahan)
+            }
+            if p.Lembar == 0 { continue }
+            sub := p.Pecahan * float64(p.Lembar)
+            hitungan = append(hitungan, models.HitunganKasir{SesiID: s.ID, Pecahan: p.Pecahan, Lembar: p.Lembar, Subtotal: sub})
+            dihitung += sub
+        }
+
+        now := time.Now()
+        s.Status, s.DitutupAt = "ditutup", &now
+        s.SaldoSeharusnya = saldoLaci(&s)
+        s.SaldoDihitung = dihitung
+        s.Selisih = bulatSen(dihitung - s.SaldoSeharusnya)
+        s.CatatanTutup = strings.TrimSpace(in.Catatan)
+        if err := tx.Save(&s).Error; err != nil { return err }
+        if len(hitungan) > 0 {
+            if err := tx.Create(&hitungan).Error; err != nil { return err }
+        }
+        s.Hitungan = hitungan
+        return catatAudit(tx, u.ID, "sesi_kasir_ditutup", "sesi_kasir", s.ID, "selisih "+rupiah(s.Selisih))
+    })
+    if err != nil {
+        kasirError(c, err)
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": s})
+}
+
+// POST /api/kasir/sesi/:id/setujui { user_id, catatan }
+// Supervisor memeriksa hasil tutup kas. Catatan wajib jika ada selisih; selisih dijurnal:
+// kas kurang Dr Beban Lain-lain / Cr Kas, kas lebih Dr Kas / Cr Pendapatan Lain-lain.
+type SetujuiSesiInput struct {
+    UserID  uint   `json:"user_id" binding:"required"`
+    Catatan string `json:"catatan"`
+}
+
+func (h *KasirController) SetujuiSesi(c *gin.Context) {
+    var in SetujuiSesiInput
+    if err := c.ShouldBindJSON(&in); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    var s models.SesiKasir
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        u, err := cariPengguna(tx, in.UserID, roleSupervisorKasir...)
+        if err != nil { return err }
+        if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&s, c.Param("id")).Error; err != nil { return err }
+        if s.Status != "ditutup" { return errSesiKasirStatus }
+        if s.UserID == u.ID { return errPenyetujuTeller }
+        catatan := strings.TrimSpace(in.Catatan)
+        if s.Selisih != 0 && catatan == "" { return fmt.Errorf("%w: catatan wajib diisi karena ada selisih %s", errInputKasir, rupiah(s.Selisih)) }
+
+        now := time.Now()
+        if s.Selisih != 0 {
+            if err := cekPeriodeTerbuka(tx, now); err != nil { return err }
+            ket := fmt.Sprintf("Selisih kas sesi kasir #%d tanggal %s", s.ID, s.Tanggal)
+            lines := []barisJurnal{debit(models.AkunBebanLain, -s.Selisih), kredit(models.AkunKas, -s.Selisih)}
+            if s.Selisih > 0 { lines = []barisJurnal{debit(models.AkunKas, s.Selisih), kredit(models.AkunPendapatanLain, s.Selisih)} }
+            if _, err := postJurnal(tx, now, "sesi_kasir", s.ID, ket, lines...); err != nil { return err }
+        }
+        s.Status, s.DisetujuiOleh, s.DisetujuiAt, s.CatatanSupervisor = "disetujui", &u.ID, &now, catatan
+        if err := tx.Save(&s).Error; err != nil { return err }
+        return catatAudit(tx, u.ID, "sesi_kasir_disetujui", "sesi_kasir", s.ID, catatan)
+    })
+    if err != nil {
+        kasirError(c, err)
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": s})
+}
+
+// LaporanTeller merangkum satu sesi kasir untuk laporan harian
+type LaporanTeller struct {
+    Sesi            models.SesiKasir   `json:"sesi"`
+    NamaTeller      string             `json:"nama_teller"`
+    SaldoLaci       float64            `json:"saldo_laci"`
+    JumlahTransaksi int                `json:"jumlah_transaksi"`
+    PerJenis        map[string]float64 `json:"per_jenis"`
+}
+
+// RingkasanKasir adalah total seluruh teller pada satu tanggal
+type RingkasanKasir struct {
+    Sesi           int                `json:"sesi"`
+    BelumDitutup   int                `json:"belum_ditutup"`
+    BelumDisetujui int                `json:"belum_disetujui"`
+    SaldoAwal      float64            `json:"saldo_awal"`
+    TotalMasuk     float64            `json:"total_masuk"`
+    TotalKeluar    float64            `json:"total_keluar"`
+    Selisih        float64            `json:"selisih"`
+    PerJenis       map[string]float64 `json:"per_jenis"`
+}
+
+// GET /api/kasir/laporan?tanggal=YYYY-MM-DD (default hari ini)
+// Laporan harian teller: per sesi yang dibuka pada tanggal itu, total per jenis transaksi, isi laci,
+// hasil hitung dan selisih, serta ringkasan seluruh teller
+func (h *KasirController) LaporanHarian(c *gin.Context) {
+    tanggal := strings.TrimSpace(c.DefaultQuery("tanggal", tanggalSaja(time.Now())))
+    if _, err := time.Parse("2006-01-02", tanggal); err != nil {
+        kasirError(c, fmt.Errorf("%w: tanggal harus YYYY-MM-DD", errInputKasir))
+        return
+    }
+    var sesi []models.SesiKasir
+    if err := h.DB.Where("tanggal = ?", tanggal).Order("dibuka_at ASC, id ASC").Find(&sesi).Error; err != nil {
+        kasirError(c, err)
+        return
+    }
+
+    list := []LaporanTeller{}
+    sum := RingkasanKasir{PerJenis: map[string]float64{}}
+    for _, s := range sesi {
+        var trx []models.TransaksiKasir
+        if err := h.DB.Where("sesi_id = ?", s.ID).Find(&trx).Error; err != nil {
+            kasirError(c, err)
+            return
+        }
+        var u models.User
+        if err := h.DB.First(&u, s.UserID).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+            kasirError(c, err)
+            return
+        }
+        l := LaporanTeller{Sesi: s, NamaTeller: u.Name, SaldoLaci: saldoLaci(&s), JumlahTransaksi: len(trx), PerJenis: map[string]float64{}}
+        for _, t := range trx {
+            l.PerJenis[t.Jenis] = bulatSen(l.PerJenis[t.Jenis] + t.Jumlah)
+            sum.PerJenis[t.Jenis] = bulatSen(sum.PerJenis[t.Jenis] + t.Jumlah)
+        }
+        list = append(list, l)
+
+        sum.Sesi++
+        switch s.Status {
+        case "buka":
+            sum.BelumDitutup++
+        case "ditutup":
+            sum.BelumDisetujui++
+        }
+        sum.SaldoAwal = bulatSen(sum.SaldoAwal + s.SaldoAwal)
+        sum.TotalMasuk = bulatSen(sum.TotalMasuk + s.TotalMasuk)
+        sum.TotalKeluar = bulatSen(sum.TotalKeluar + s.TotalKeluar)
+        sum.Selisih = bulatSen(sum.Selisih + s.Selisih)
+    }
+    c.JSON(http.StatusOK, gin.H{"tanggal": tanggal, "data": list, "ringkasan": sum})
+}
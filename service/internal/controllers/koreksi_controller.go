@@ -0,0 +1,276 @@
+package controllers
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+
+    "koperasi-desa/service/internal/models"
+)
+
+var (
+    errKoreksiSudahDiproses    = errors.New("koreksi sudah diproses")
+    errKoreksiPenyetujuSama    = errors.New("penyetuju koreksi harus berbeda dengan pengaju")
+    errTransaksiSudahDikoreksi = errors.New("transaksi sudah dikoreksi atau sedang menunggu koreksi")
+    errTransaksiTidakDikoreksi = errors.New("transaksi ini tidak dapat dikoreksi")
+    errTransaksiSistem         = errors.New("hanya setoran/penarikan dan pembayaran angsuran di loket yang dapat dikoreksi; transaksi buatan sistem mengikuti transaksi asalnya")
+)
+
+// rolePenyetujuKoreksi adalah role yang boleh menyetujui atau menolak koreksi
+var rolePenyetujuKoreksi = []string{"admin", "bendahara", "ketua"}
+
+type KoreksiController struct { DB *gorm.DB }
+func NewKoreksiController(db *gorm.DB) *KoreksiController { return &KoreksiController{DB: db} }
+
+// GET /api/koreksi?status=...&sumber=...&ref_id=...&page=...&limit=...
+func (h *KoreksiController) ListKoreksi(c *gin.Context) {
+    var list []models.Koreksi
+    page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+    limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+    if page < 1 { page = 1 }
+    if limit < 1 || limit > 100 { limit = 10 }
+    offset := (page - 1) * limit
+
+    status := strings.TrimSpace(c.Query("status"))
+    sumber := strings.TrimSpace(c.Query("sumber"))
+    refID := strings.TrimSpace(c.Query("ref_id"))
+
+    tx := h.DB.Model(&models.Koreksi{})
+    if status != "" { tx = tx.Where("status = ?", strings.ToLower(status)) }
+    if sumber != "" { tx = tx.Where("sumber = ?", strings.ToLower(sumber)) }
+    if refID != "" { tx = tx.Where("ref_id = ?", refID) }
+
+    if err := tx.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&list).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": list, "page": page, "limit": limit})
+}
+
+// POST /api/koreksi
+// { sumber: simpanan|angsuran, ref_id, alasan, diajukan_oleh }
+type AjukanKoreksiInput struct {
+    Sumber       string `json:"sumber" binding:"required"`
+    RefID        uint   `json:"ref_id" binding:"required"`
+    Alasan       string `json:"alasan" binding:"required"`
+    DiajukanOleh uint   `json:"diajukan_oleh" binding:"required"`
+}
+
+func (h *KoreksiController) AjukanKoreksi(c *gin.Context) {
+    var in AjukanKoreksiInput
+    if err := c.ShouldBindJSON(&in); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    sumber := strings.ToLower(strings.TrimSpace(in.Sumber))
+    if sumber != "simpanan" && sumber != "angsuran" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "sumber harus simpanan/angsuran"})
+        return
+    }
+    if strings.TrimSpace(in.Alasan) == "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "alasan wajib diisi"})
+        return
+    }
+
+    k := models.Koreksi{Sumber: sumber, RefID: in.RefID, Alasan: strings.TrimSpace(in.Alasan), Status: "menunggu", DiajukanOleh: in.DiajukanOleh}
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        if _, err := cariPengguna(tx, in.DiajukanOleh); err != nil { return err }
+        if sumber == "simpanan" {
+            var s models.Simpanan
+            if err := tx.First(&s, in.RefID).Error; err != nil { return err }
+            if err := cekKoreksiSimpanan(tx, &s); err != nil { return err }
+        } else {
+            var a models.Angsuran
+            if err := tx.First(&a, in.RefID).Error; err != nil { return err }
+            if err := cekKoreksiAngsuran(tx, &a); err != nil { return err }
+        }
+        var pending int64
+        if err := tx.Model(&models.Koreksi{}).Where("sumber = ? AND ref_id = ? AND status = ?", sumber, in.RefID, "menunggu").Count(&pending).Error; err != nil { return err }
+        if pending > 0 { return errTransaksiSudahDikoreksi }
+
+        if err := tx.Create(&k).Error; err != nil { return err }
+        return catatAudit(tx, in.DiajukanOleh, "koreksi_diajukan", "koreksi", k.ID, fmt.Sprintf("%s #%d: %s", sumber, in.RefID, k.Alasan))
+    })
+    if err != nil {
+        h.koreksiError(c, err)
+        return
+    }
+    c.JSON(http.StatusCreated, k)
+}
+
+// POST /api/koreksi/:id/setujui { disetujui_oleh, catatan }
+// POST /api/koreksi/:id/tolak { disetujui_oleh, catatan }
+type PutusKoreksiInput struct {
+    DisetujuiOleh uint   `json:"disetujui_oleh" binding:"required"`
+    Catatan       string `json:"catatan"`
+}
+
+func (h *KoreksiController) SetujuiKoreksi(c *gin.Context) { h.putuskan(c, true) }
+func (h *KoreksiController) TolakKoreksi(c *gin.Context) { h.putuskan(c, false) }
+
+func (h *KoreksiController) putuskan(c *gin.Context, setuju bool) {
+    var in PutusKoreksiInput
+    if err := c.ShouldBindJSON(&in); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    var k models.Koreksi
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.First(&k, c.Param("id")).Error; err != nil { return err }
+        if k.Status != "menunggu" { return errKoreksiSudahDiproses }
+        penyetuju, err := cariPengguna(tx, in.DisetujuiOleh, rolePenyetujuKoreksi...)
+        if err != nil { return err }
+        if penyetuju.ID == k.DiajukanOleh { return errKoreksiPenyetujuSama }
+
+        now := time.Now()
+        k.DisetujuiOleh = &penyetuju.ID
+        k.DiputuskanAt = &now
+        k.Catatan = in.Catatan
+        if !setuju {
+            k.Status = "ditolak"
+            if err := tx.Save(&k).Error; err != nil { return err }
+            return catatAudit(tx, penyetuju.ID, "koreksi_ditolak", "koreksi", k.ID, in.Catatan)
+        }
+
+        if k.Sumber == "simpanan" {
+            if err := balikSimpanan(tx, &k); err != nil { return err }
+        } else {
+            if err := balikAngsuran(tx, &k); err != nil { return err }
+        }
+        if err := balikTransaksiKasir(tx, &k); err != nil { return err }
+        k.Status = "disetujui"
+        if err := tx.Save(&k).Error; err != nil { return err }
+        if err := catatAudit(tx, penyetuju.ID, "koreksi_disetujui", "koreksi", k.ID, in.Catatan); err != nil { return err }
+        return catatAudit(tx, penyetuju.ID, "dibatalkan", k.Sumber, k.RefID, fmt.Sprintf("koreksi #%d: %s", k.ID, k.Alasan))
+    })
+    if err != nil {
+        h.koreksiError(c, err)
+        return
+    }
+    c.JSON(http.StatusOK, k)
+}
+
+// cekKoreksiSimpanan hanya mengizinkan koreksi setoran/penarikan loket pada periode yang masih terbuka.
+// Transaksi buatan sistem (bunga, auto-debit, pencairan, simpanan berjangka, penyelesaian) adalah satu
+// kaki dari transaksi gabungan; membaliknya sendiri merusak keadaan transaksi lainnya.
+func cekKoreksiSimpanan(tx *gorm.DB, s *models.Simpanan) error {
+    if s.KoreksiDariID != nil { return errTransaksiTidakDikoreksi }
+    if s.DikoreksiOlehID != nil { return errTransaksiSudahDikoreksi }
+    if s.Sumber != "loket" || (s.Tipe != "setoran" && s.Tipe != "penarikan") { return errTransaksiSistem }
+    return cekPeriodeTerbuka(tx, s.Tanggal)
+}
+
+// cekKoreksiAngsuran hanya mengizinkan koreksi pembayaran angsuran di loket pada periode yang masih terbuka.
+// Angsuran yang dibayar auto-debit atau penyelesaian keluar terikat dengan penarikan simpanannya.
+func cekKoreksiAngsuran(tx *gorm.DB, a *models.Angsuran) error {
+    if a.TanggalBayar == nil { return errTransaksiTidakDikoreksi }
+    if a.DikoreksiOlehID != nil { return errTransaksiSudahDikoreksi }
+    var n int64
+    if err := tx.Model(&models.AutoDebitLog{}).Where("angsuran_id = ? AND status = ?", a.ID, "berhasil").Count(&n).Error; err != nil { return err }
+    if n > 0 { return errTransaksiSistem }
+    var p models.Pinjaman
+    if err := tx.First(&p, a.PinjamanID).Error; err != nil { return err }
+    if err := tx.Model(&models.PenyelesaianKeluar{}).Where("anggota_id = ?", p.AnggotaID).Count(&n).Error; err != nil { return err }
+    if n > 0 { return errTransaksiSistem }
+    return cekPeriodeTerbuka(tx, *a.TanggalBayar)
+}
+
+// balikSimpanan membuat entri pembalik bertanggal sama dengan transaksi asal lalu menghitung ulang saldo
+// transaksi sesudahnya. Seperti angsuran, transaksi pada periode yang sudah ditutup tidak dapat dikoreksi.
+func balikSimpanan(tx *gorm.DB, k *models.Koreksi) error {
+    var asal models.Simpanan
+    if err := tx.First(&asal, k.RefID).Error; err != nil { return err }
+    if err := cekKoreksiSimpanan(tx, &asal); err != nil { return err }
+    tanggal := asal.Tanggal
+
+    tipe := "koreksi_kredit"
+    if saldoDelta(asal.Tipe, asal.Jumlah) > 0 { tipe = "koreksi_debit" }
+    pembalik := models.Simpanan{
+        AnggotaID:     asal.AnggotaID,
+        Jenis:         asal.Jenis,
+        Tipe:          tipe,
+        Tanggal:       tanggal,
+        Jumlah:        asal.Jumlah,
+        Keterangan:    fmt.Sprintf("Koreksi #%d atas transaksi #%d: %s", k.ID, asal.ID, k.Alasan),
+        KoreksiDariID: &asal.ID,
+        Sumber:        "koreksi",
+    }
+    if err := catatSimpanan(tx, &pembalik, ""); err != nil { return err }
+    if err := balikJurnal(tx, "simpanan", asal.ID, tanggal, pembalik.Keterangan); err != nil { return err }
+    if err := tx.Model(&asal).Update("dikoreksi_oleh_id", pembalik.ID).Error; err != nil { return err }
+
+    k.PembalikID = &pembalik.ID
+    k.TanggalTransaksi = &asal.Tanggal
+    k.Jumlah = asal.Jumlah
+    return nil
+}
+
+// balikAngsuran membatalkan pembayaran angsuran tanpa mengubah baris pembayarannya: dibuat baris
+// pengganti belum dibayar untuk angsuran ke- yang sama, lalu baris asal ditautkan ke pengganti itu.
+func balikAngsuran(tx *gorm.DB, k *models.Koreksi) error {
+    var a models.Angsuran
+    if err := tx.First(&a, k.RefID).Error; err != nil { return err }
+    if err := cekKoreksiAngsuran(tx, &a); err != nil { return err }
+    if err := balikJurnal(tx, "angsuran", a.ID, *a.TanggalBayar, fmt.Sprintf("Koreksi #%d atas angsuran #%d: %s", k.ID, a.ID, k.Alasan)); err != nil { return err }
+
+    k.TanggalTransaksi = a.TanggalBayar
+    k.Jumlah = a.Jumlah
+    k.Denda = a.Denda
+    pengganti := models.Angsuran{
+        PinjamanID:        a.PinjamanID,
+        Ke:                a.Ke,
+        TanggalJatuhTempo: a.TanggalJatuhTempo,
+        Jumlah:            a.Jumlah,
+        KoreksiDariID:     &a.ID,
+    }
+    if err := tx.Create(&pengganti).Error; err != nil { return err }
+    if err := tx.Model(&a).Update("dikoreksi_oleh_id", pengganti.ID).Error; err != nil { return err }
+    k.PembalikID = &pengganti.ID
+    return tx.Model(&models.Pinjaman{}).Where("id = ? AND status = ?", a.PinjamanID, "lunas").Update("status", "berjalan").Error
+}
+
+// balikTransaksiKasir membalik uang laci transaksi asal yang tercatat di sesi kasir: setoran/angsuran tunai
+// dikembalikan ke anggota, penarikan tunai diterima kembali. Pembalikan dicatat di sesi transaksi asal bila
+// masih dibuka; bila sudah ditutup (laci sudah dihitung) dicatat di sesi yang sedang dibuka teller yang sama.
+// Transaksi tanpa catatan laci (auto-debit, pemindahbukuan) tidak melibatkan uang tunai sehingga dilewati.
+func balikTransaksiKasir(tx *gorm.DB, k *models.Koreksi) error {
+    var asal models.TransaksiKasir
+    err := tx.Where("sumber = ? AND ref_id = ?", k.Sumber, k.RefID).First(&asal).Error
+    if errors.Is(err, gorm.ErrRecordNotFound) { return nil }
+    if err != nil { return err }
+    var sesi models.SesiKasir
+    if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&sesi, asal.SesiID).Error; err != nil { return err }
+    arah := "keluar"
+    if asal.Arah == "keluar" { arah = "masuk" }
+    t := &models.TransaksiKasir{
+        Jenis: "koreksi", Sumber: "koreksi", RefID: k.ID, Arah: arah, Jumlah: asal.Jumlah, NomorBukti: asal.NomorBukti,
+        Keterangan: fmt.Sprintf("Koreksi #%d atas %s #%d", k.ID, k.Sumber, k.RefID),
+    }
+    if sesi.Status == "buka" { return catatKeSesi(tx, &sesi, t) }
+    return catatTransaksiKasir(tx, sesi.UserID, t)
+}
+
+func (h *KoreksiController) koreksiError(c *gin.Context, err error) {
+    switch {
+    case errors.Is(err, gorm.ErrRecordNotFound):
+        c.JSON(http.StatusNotFound, gin.H{"error": "data tidak ditemukan"})
+    case errors.Is(err, errAksesDitolak), errors.Is(err, errKoreksiPenyetujuSama):
+        c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+    case errors.Is(err, errKoreksiSudahDiproses), errors.Is(err, errTransaksiSudahDikoreksi), errors.Is(err, errPeriodeTertutup),
+        errors.Is(err, errSesiKasirTidakAda), errors.Is(err, errKasLaciKurang):
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+    case errors.Is(err, errTransaksiTidakDikoreksi), errors.Is(err, errTransaksiSistem):
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    case errors.Is(err, gorm.ErrInvalidTransaction):
+        c.JSON(http.StatusConflict, gin.H{"error": "koreksi membuat saldo menjadi negatif"})
+    default:
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+    }
+}
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+    "errors"
+    "strconv"
+    "strings"
+    "time"
+    "unicode"
+
+    "gorm.io/gorm"
+
+    "koperasi-desa/service/internal/models"
+)
+
+var (
+    errNIKFormat    = errors.New("NIK harus 16 digit angka")
+    errNIKWilayah   = errors.New("kode provinsi/kabupaten/kecamatan pada NIK tidak valid")
+    errNIKTanggal   = errors.New("tanggal lahir pada NIK tidak valid")
+    errNIKUrut      = errors.New("nomor urut pada NIK tidak valid")
+    errNIKTerdaftar = errors.New("NIK sudah terdaftar pada anggota lain yang belum keluar")
+
+    errKemungkinanDuplikat = errors.New("kemungkinan anggota ganda, periksa lalu kirim ulang dengan konfirmasi_duplikat")
+)
+
+// kodeProvinsi adalah dua digit awal NIK yang valid (kode wilayah Kemendagri)
+var kodeProvinsi = map[string]bool{
+    "11": true, "12": true, "13": true, "14": true, "15": true, "16": true, "17": true, "18": true, "19": true,
+    "21": true, "31": true, "32": true, "33": true, "34": true, "35": true, "36": true,
+    "51": true, "52": true, "53": true, "61": true, "62": true, "63": true, "64": true, "65": true,
+    "71": true, "72": true, "73": true, "74": true, "75": true, "76": true,
+    "81": true, "82": true, "91": true, "92": true, "93": true, "94": true, "95": true, "96": true,
+}
+
+// statusSelesai adalah status anggota yang sudah berakhir; NIK-nya boleh didaftarkan ulang
+var statusSelesai = []string{"ditolak", "keluar", "meninggal"}
+
+// DataNIK adalah informasi yang dibaca dari NIK
+type DataNIK struct {
+    TanggalLahir time.Time
+    JenisKelamin string // L | P
+}
+
+// validasiNIK memeriksa format NIK: PP KK CC DDMMYY SSSS.
+// Untuk perempuan tanggal lahir ditambah 40; tahun dua digit di atas tahun berjalan dianggap 19xx.
+func validasiNIK(nik string) (DataNIK, error) {
+    var d DataNIK
+    if len(nik) != 16 { return d, errNIKFormat }
+    for _, r := range nik {
+        if r < '0' || r > '9' { return d, errNIKFormat }
+    }
+    if !kodeProvinsi[nik[0:2]] || nik[2:4] == "00" || nik[4:6] == "00" { return d, errNIKWilayah }
+    if nik[12:16] == "0000" { return d, errNIKUrut }
+
+    hari, _ := strconv.Atoi(nik[6:8])
+    bulan, _ := strconv.Atoi(nik[8:10])
+    tahun, _ := strconv.Atoi(nik[10:12])
+    d.JenisKelamin = "L"
+    if hari > 40 {
+        hari -= 40
+        d.JenisKelamin = "P"
+    }
+    now := time.Now()
+    if tahun > now.Year()%100 {
+        tahun += 1900
+    } else {
+        tahun += 2000
+    }
+    if hari < 1 || bulan < 1 || bulan > 12 { return d, errNIKTanggal }
+    t := time.Date(tahun, time.Month(bulan), hari, 0, 0, 0, 0, time.Local)
+    if t.Day() != hari || t.After(now) { return d, errNIKTanggal }
+    d.TanggalLahir = t
+    return d, nil
+}
+
+// cekNIKTerdaftar menolak NIK yang masih dipakai anggota lain yang belum keluar
+func cekNIKTerdaftar(tx *gorm.DB, nik string, kecualiID uint) error {
+    var n int64
+    if err := tx.Model(&models.Anggota{}).Where("nik = ? AND id <> ? AND status NOT IN ?", nik, kecualiID, statusSelesai).Count(&n).Error; err != nil {
+        return err
+    }
+    if n > 0 { return errNIKTerdaftar }
+    return nil
+}
+
+// KemungkinanDuplikat adalah anggota terdaftar yang mirip dengan calon anggota
+type KemungkinanDuplikat struct {
+    ID           uint    `json:"id"`
+    NomorAnggota string  `json:"nomor_anggota"`
+    Nama         string  `json:"nama"`
+    Alamat       string  `json:"alamat"`
+    Status       string  `json:"status"`
+    SkorNama     float64 `json:"skor_nama"`
+    SkorAlamat   float64 `json:"skor_alamat"`
+}
+
+// cariDuplikat mencari anggota yang belum keluar dengan nama dan alamat mirip.
+// Jika tanggal lahir keduanya diketahui dan sama, ambang kemiripan nama diturunkan;
+// jika berbeda, anggota tersebut bukan duplikat.
+func cariDuplikat(tx *gorm.DB, nama, alamat string, tanggalLahir *time.Time) ([]KemungkinanDuplikat, error) {
+    var list []models.Anggota
+    if err := tx.Where("status NOT IN ?", statusSelesai).Find(&list).Error; err != nil { return nil, err }
+    hasil := []KemungkinanDuplikat{}
+    for _, a := range list {
+        sn := kemiripan(nama, a.Nama)
+        sa := kemiripan(alamat, a.Alamat)
+        cocok := false
+        if tanggalLahir != nil && a.TanggalLahir != nil {
+            if !tanggalLahir.Equal(*a.TanggalLahir) { continue }
+            cocok = sn >= 0.8 || (sn >= 0.6 && sa >= 0.6)
+        } else {
+            cocok = sn >= 0.9 && sa >= 0.6
+        }
+        if cocok {
+            hasil = append(hasil, KemungkinanDuplikat{ID: a.ID, NomorAnggota: a.NomorAnggota, Nama: a.Nama, Alamat: a.Alamat, Status: a.Status, SkorNama: sn, SkorAlamat: sa})
+        }
+    }
+    return hasil, nil
+}
+
+// normalisasiTeks menjadikan huruf kecil, membuang tanda baca dan spasi ganda
+func normalisasiTeks(s string) []rune {
+    var b strings.Builder
+    spasi := true
+    for _, r := range strings.ToLower(s) {
+        if unicode.IsLetter(r) || unicode.IsDigit(r) {
+            b.WriteRune(r)
+            spasi = false
+        } else if !spasi {
+            b.WriteRune(' ')
+            spasi = true
+        }
+    }
+    return []rune(strings.TrimSpace(b.String()))
+}
+
+// kemiripan mengembalikan 1 - jarak Levenshtein / panjang teks terpanjang (0..1)
+func kemiripan(a, b string) float64 {
+    x, y := normalisasiTeks(a), normalisasiTeks(b)
+    if len(x) == 0 || len(y) == 0 { return 0 }
+    prev := make([]int, len(y)+1)
+    cur := make([]int, len(y)+1)
+    for j := range prev { prev[j] = j }
+    for i := 1; i <= len(x); i++ {
+        cur[0] = i
+        for j := 1; j <= len(y); j++ {
+            biaya := 1
+            if x[i-1] == y[j-1] { biaya = 0 }
+            cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+biaya)
+        }
+        prev, cur = cur, prev
+    }
+    panjang := max(len(x), len(y))
+    return 1 - float64(prev[len(y)])/float64(panjang)
+}
@@ -0,0 +1,39 @@
+package controllers
+
+import (
+    "errors"
+    "testing"
+    "time"
+)
+
+func TestValidasiNIK(t *testing.T) {
+    cases := []struct {
+        nama    string
+        nik     string
+        err     error
+        lahir   time.Time
+        kelamin string
+    }{
+        {"laki-laki", "3201010107900001", nil, time.Date(1990, 7, 1, 0, 0, 0, 0, time.Local), "L"},
+        {"perempuan tanggal +40", "3201014107900001", nil, time.Date(1990, 7, 1, 0, 0, 0, 0, time.Local), "P"},
+        {"tahun 00 dianggap 2000", "3201010101000001", nil, time.Date(2000, 1, 1, 0, 0, 0, 0, time.Local), "L"},
+        {"kurang dari 16 digit", "320101010790001", errNIKFormat, time.Time{}, ""},
+        {"mengandung huruf", "32010101079000AB", errNIKFormat, time.Time{}, ""},
+        {"kode provinsi tidak dikenal", "9901010107900001", errNIKWilayah, time.Time{}, ""},
+        {"kode kabupaten 00", "3200010107900001", errNIKWilayah, time.Time{}, ""},
+        {"kode kecamatan 00", "3201000107900001", errNIKWilayah, time.Time{}, ""},
+        {"nomor urut 0000", "3201010107900000", errNIKUrut, time.Time{}, ""},
+        {"31 Februari", "3201013102900001", errNIKTanggal, time.Time{}, ""},
+        {"bulan 13", "3201010113900001", errNIKTanggal, time.Time{}, ""},
+        {"hari 00", "3201010007900001", errNIKTanggal, time.Time{}, ""},
+    }
+    for _, c := range cases {
+        t.Run(c.nama, func(t *testing.T) {
+            d, err := validasiNIK(c.nik)
+            if !errors.Is(err, c.err) { t.Fatalf("err = %v, ingin %v", err, c.err) }
+            if c.err != nil { return }
+            if !d.TanggalLahir.Equal(c.lahir) { t.Errorf("tanggal lahir = %v, ingin %v", d.TanggalLahir, c.lahir) }
+            if d.JenisKelamin != c.kelamin { t.Errorf("jenis kelamin = %s, ingin %s", d.JenisKelamin, c.kelamin) }
+        })
+    }
+}
@@ -0,0 +1,56 @@
+package controllers
+
+import (
+    "fmt"
+    "regexp"
+    "strconv"
+    "strings"
+    "time"
+
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+
+    "koperasi-desa/service/internal/models"
+    "koperasi-desa/service/internal/settings"
+)
+
+var tokenSeq = regexp.MustCompile(`\{SEQ(?::(\d+))?\}`)
+
+// nomorBerikutnya mengambil nomor urut berikutnya untuk jenis dokumen dan merendernya sesuai settings.format.
+// Harus dipanggil di dalam transaksi yang membuat dokumennya: baris urutan dikunci sampai commit
+// dan ikut di-rollback jika transaksi gagal, sehingga nomor tidak loncat.
+func nomorBerikutnya(tx *gorm.DB, jenis string, t time.Time) (string, error) {
+    formats, err := settings.LoadFormat(tx)
+    if err != nil { return "", err }
+    f, ok := formats[jenis]
+    if !ok { return "", fmt.Errorf("format nomor %s belum diatur", jenis) }
+
+    periode := "-"
+    switch f.Reset {
+    case "tahunan":
+        periode = t.Format("2006")
+    case "bulanan":
+        periode = t.Format("2006-01")
+    }
+
+    urut := models.NomorUrut{Jenis: jenis, Periode: periode}
+    if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&urut).Error; err != nil { return "", err }
+    if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("jenis = ? AND periode = ?", jenis, periode).First(&urut).Error; err != nil {
+        return "", err
+    }
+    urut.Nilai++
+    if err := tx.Model(&models.NomorUrut{}).Where("jenis = ? AND periode = ?", jenis, periode).Update("nilai", urut.Nilai).Error; err != nil {
+        return "", err
+    }
+    return renderNomor(f.Template, t, urut.Nilai), nil
+}
+
+// renderNomor mengganti token tanggal dan {SEQ:n} pada template
+func renderNomor(template string, t time.Time, seq int64) string {
+    s := tokenSeq.ReplaceAllStringFunc(template, func(m string) string {
+        lebar := 0
+        if sub := tokenSeq.FindStringSubmatch(m); sub[1] != "" { lebar, _ = strconv.Atoi(sub[1]) }
+        return fmt.Sprintf("%0*d", lebar, seq)
+    })
+    return strings.NewReplacer("{YYYY}", t.Format("2006"), "{YY}", t.Format("06"), "{MM}", t.Format("01"), "{DD}", t.Format("02")).Replace(s)
+}
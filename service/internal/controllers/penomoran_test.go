@@ -0,0 +1,29 @@
+package controllers
+
+import (
+    "testing"
+    "time"
+)
+
+func TestRenderNomor(t *testing.T) {
+    tgl := time.Date(2026, 3, 7, 10, 0, 0, 0, time.Local)
+    cases := []struct {
+        nama     string
+        template string
+        seq      int64
+        ingin    string
+    }{
+        {"anggota tahunan", "AG-{YYYY}-{SEQ:4}", 12, "AG-2026-0012"},
+        {"kuitansi bulanan", "KW/{YYYY}{MM}/{SEQ:5}", 1, "KW/202603/00001"},
+        {"tahun dua digit dan tanggal", "BL{YY}{MM}{DD}-{SEQ:3}", 45, "BL260307-045"},
+        {"tanpa lebar", "PJ-{SEQ}", 123, "PJ-123"},
+        {"urutan melebihi lebar", "AG-{SEQ:2}", 1234, "AG-1234"},
+        {"dua token urutan", "{SEQ:3}/{SEQ}", 7, "007/7"},
+        {"tanpa token", "TETAP", 5, "TETAP"},
+    }
+    for _, c := range cases {
+        t.Run(c.nama, func(t *testing.T) {
+            if got := renderNomor(c.template, tgl, c.seq); got != c.ingin { t.Errorf("renderNomor(%q) = %q, ingin %q", c.template, got, c.ingin) }
+        })
+    }
+}
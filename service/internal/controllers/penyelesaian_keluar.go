@@ -0,0 +1,208 @@
+package controllers
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+
+    "koperasi-desa/service/internal/models"
+)
+
+var errSimpananBerjangkaAktif = errors.New("anggota masih memiliki simpanan berjangka aktif, cairkan terlebih dahulu")
+
+// rolePengelolaKeanggotaan adalah role yang boleh memproses anggota keluar/meninggal
+var rolePengelolaKeanggotaan = []string{"admin", "bendahara", "ketua"}
+
+// jenisSimpananKeluar adalah jenis simpanan yang dikembalikan saat anggota keluar
+var jenisSimpananKeluar = []string{"pokok", "wajib", "sukarela", "khusus"}
+
+// hitungPenyelesaian menghitung saldo simpanan yang dikembalikan dan kewajiban pinjaman yang belum dibayar
+// per tanggal. Seluruh angsuran terutang dilunasi, angsuran lewat jatuh tempo dikenakan denda.
+func hitungPenyelesaian(tx *gorm.DB, anggotaID uint, tanggal time.Time) (models.PenyelesaianKeluar, []models.Angsuran, error) {
+    p := models.PenyelesaianKeluar{AnggotaID: anggotaID, Tanggal: tanggal}
+    var berjangka int64
+    if err := tx.Model(&models.SimpananBerjangka{}).Where("anggota_id = ? AND status = ?", anggotaID, "aktif").Count(&berjangka).Error; err != nil {
+        return p, nil, err
+    }
+    if berjangka > 0 { return p, nil, errSimpananBerjangkaAktif }
+
+    for _, j := range jenisSimpananKeluar {
+        saldo, err := saldoPada(tx, anggotaID, j, tanggal)
+        if err != nil { return p, nil, err }
+        switch j {
+        case "pokok":
+            p.SimpananPokok = saldo
+        case "wajib":
+            p.SimpananWajib = saldo
+        case "sukarela":
+            p.SimpananSukarela = saldo
+        case "khusus":
+            p.SimpananKhusus = saldo
+        }
+        p.TotalSimpanan += saldo
+    }
+
+    var pinjaman []models.Pinjaman
+    if err := tx.Where("anggota_id = ? AND status = ?", anggotaID, "berjalan").Find(&pinjaman).Error; err != nil { return p, nil, err }
+    var terutang []models.Angsuran
+    for i := range pinjaman {
+        var list []models.Angsuran
+        if err := tx.Scopes(angsuranBerlaku).Where("pinjaman_id = ? AND tanggal_bayar IS NULL", pinjaman[i].ID).Order("ke ASC").Find(&list).Error; err != nil { return p, nil, err }
+        for j := range list {
+            pokok := pokokAngsuran(&pinjaman[i], &list[j])
+            p.PokokPinjaman += pokok
+            p.BungaPinjaman += list[j].Jumlah - pokok
+            p.DendaPinjaman += dendaAngsuran(&list[j], tanggal)
+        }
+        terutang = append(terutang, list...)
+    }
+    p.TotalKewajiban = p.PokokPinjaman + p.BungaPinjaman + p.DendaPinjaman
+    p.Selisih = p.TotalSimpanan - p.TotalKewajiban
+    return p, terutang, nil
+}
+
+// selesaikanKeanggotaan melunasi seluruh angsuran terutang, menarik seluruh saldo simpanan,
+// mengubah status anggota ke status (keluar/meninggal) dan menyimpan rincian penyelesaiannya.
+// Pinjaman yang belum dicairkan (pengajuan/disetujui) ditolak agar tidak dapat dicairkan sesudahnya.
+func selesaikanKeanggotaan(tx *gorm.DB, a *models.Anggota, status, alasan string, userID uint) (*models.PenyelesaianKeluar, error) {
+    tanggal := time.Now()
+    p, terutang, err := hitungPenyelesaian(tx, a.ID, tanggal)
+    if err != nil { return nil, err }
+    if err := ubahStatusAnggota(tx, a, status, alasan); err != nil { return nil, err }
+    if err := tolakPinjamanBelumCair(tx, a.ID, status, userID, tanggal); err != nil { return nil, err }
+
+    for i := range terutang {
+        if err := bayarAngsuran(tx, &terutang[i], tanggal); err != nil { return nil, err }
+    }
+    for _, j := range jenisSimpananKeluar {
+        saldo, err := saldoPada(tx, a.ID, j, tanggal)
+        if err != nil { return nil, err }
+        if saldo <= 0 { continue }
+        tarik := models.Simpanan{
+            AnggotaID:  a.ID,
+            Jenis:      j,
+            Tipe:       "penarikan",
+            Tanggal:    tanggal,
+            Jumlah:     saldo,
+            Keterangan: fmt.Sprintf("Pengembalian simpanan %s anggota %s", j, status),
+            Sumber:     "penyelesaian",
+        }
+        if err := catatSimpanan(tx, &tarik, ""); err != nil { return nil, err }
+    }
+
+    p.Status = status
+    p.Alasan = alasan
+    p.DiprosesOleh = userID
+    if err := tx.Create(&p).Error; err != nil { return nil, err }
+    if err := catatAudit(tx, userID, "penyelesaian_"+status, "anggota", a.ID, fmt.Sprintf("selisih %.2f: %s", p.Selisih, alasan)); err != nil { return nil, err }
+    return &p, nil
+}
+
+// catatKasPenyelesaian mencatat uang tunai penyelesaian ke laci sesi kasir userID. Jurnalnya melunasi
+// angsuran lewat Kas dan menarik simpanan lewat Kas, sehingga yang berpindah tangan hanya selisihnya:
+// selisih positif dibayarkan ke anggota, selisih negatif diterima dari anggota.
+func catatKasPenyelesaian(tx *gorm.DB, userID uint, p *models.PenyelesaianKeluar) error {
+    t := models.TransaksiKasir{
+        Jenis: "penyelesaian", Sumber: "penyelesaian", RefID: p.ID, Arah: "keluar", Jumlah: bulatSen(p.Selisih),
+        Keterangan: fmt.Sprintf("Penyelesaian anggota #%d (%s)", p.AnggotaID, p.Status),
+    }
+    if p.Selisih < 0 { t.Arah, t.Jumlah = "masuk", bulatSen(-p.Selisih) }
+    if t.Jumlah == 0 { return nil }
+    return catatTransaksiKasir(tx, userID, &t)
+}
+
+// tolakPinjamanBelumCair menolak pinjaman anggota yang masih pengajuan/disetujui, membatalkan tahap
+// persetujuan yang menunggu dan mencabut mandat auto-debitnya
+func tolakPinjamanBelumCair(tx *gorm.DB, anggotaID uint, status string, userID uint, tanggal time.Time) error {
+    var list []models.Pinjaman
+    if err := tx.Where("anggota_id = ? AND status IN ?", anggotaID, []string{"pengajuan", "disetujui"}).Find(&list).Error; err != nil { return err }
+    for i := range list {
+        p := &list[i]
+        if err := tx.Model(p).Updates(map[string]any{"status": "ditolak", "tanggal_ditolak": tanggal}).Error; err != nil { return err }
+        if err := tx.Model(&models.TahapPersetujuan{}).Where("pinjaman_id = ? AND status = ?", p.ID, "menunggu").
+            Updates(map[string]any{"status": "dibatalkan", "selesai_at": tanggal}).Error; err != nil {
+            return err
+        }
+        if err := tx.Model(&models.MandatAutoDebit{}).Where("pinjaman_id = ? AND aktif = ?", p.ID, true).
+            Updates(map[string]any{"aktif": false, "dicabut_oleh": userID, "dicabut_at": tanggal}).Error; err != nil {
+            return err
+        }
+        if err := catatAudit(tx, userID, "pinjaman_ditolak", "pinjaman", p.ID, fmt.Sprintf("%s: anggota %s", p.NomorPinjaman, status)); err != nil { return err }
+    }
+    return nil
+}
+
+// GET /api/anggota/:id/penyelesaian-keluar
+// Mengembalikan simulasi perhitungan, atau rincian tersimpan jika anggota sudah keluar/meninggal.
+func (h *AnggotaController) PenyelesaianKeluar(c *gin.Context) {
+    var a models.Anggota
+    if err := h.DB.First(&a, c.Param("id")).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "anggota not found"})
+        return
+    }
+    var tersimpan models.PenyelesaianKeluar
+    if err := h.DB.Where("anggota_id = ?", a.ID).Order("id DESC").First(&tersimpan).Error; err == nil {
+        bagian := []models.PembagianWaris{}
+        _ = h.DB.Where("penyelesaian_id = ?", tersimpan.ID).Order("id ASC").Find(&bagian).Error
+        c.JSON(http.StatusOK, gin.H{"data": tersimpan, "final": true, "pembagian": bagian})
+        return
+    }
+    p, _, err := hitungPenyelesaian(h.DB, a.ID, time.Now())
+    if err != nil {
+        if errors.Is(err, errSimpananBerjangkaAktif) {
+            c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": p, "final": false})
+}
+
+// POST /api/anggota/:id/keluar { alasan, user_id }
+// user_id juga teller yang membayarkan/menerima selisih penyelesaian lewat laci sesi kasirnya
+type KeluarAnggotaInput struct {
+    Alasan string `json:"alasan" binding:"required"`
+    UserID uint   `json:"user_id" binding:"required"`
+}
+
+func (h *AnggotaController) Keluar(c *gin.Context) {
+    var in KeluarAnggotaInput
+    if err := c.ShouldBindJSON(&in); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    var p *models.PenyelesaianKeluar
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        if _, err := cariPengguna(tx, in.UserID, rolePengelolaKeanggotaan...); err != nil { return err }
+        var a models.Anggota
+        if err := tx.First(&a, c.Param("id")).Error; err != nil { return err }
+        var err error
+        p, err = selesaikanKeanggotaan(tx, &a, "keluar", in.Alasan, in.UserID)
+        if err != nil { return err }
+        return catatKasPenyelesaian(tx, in.UserID, p)
+    })
+    if err != nil {
+        penyelesaianError(c, err)
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": p})
+}
+
+func penyelesaianError(c *gin.Context, err error) {
+    switch {
+    case errors.Is(err, errAksesDitolak):
+        c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+    case errors.Is(err, errSimpananBerjangkaAktif), errors.Is(err, errPeriodeTertutup), errors.Is(err, errSesiKasirTidakAda),
+        errors.Is(err, errKasLaciKurang):
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+    case errors.Is(err, gorm.ErrInvalidTransaction):
+        c.JSON(http.StatusConflict, gin.H{"error": "saldo simpanan berubah saat diproses, ulangi"})
+    default:
+        statusError(c, err)
+    }
+}
@@ -0,0 +1,178 @@
+package controllers
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+
+    "koperasi-desa/service/internal/models"
+)
+
+var (
+    errPeriodeTertutup     = errors.New("periode akuntansi untuk tanggal transaksi sudah ditutup")
+    errPeriodeBelumSelesai = errors.New("periode belum berakhir sehingga belum dapat ditutup")
+    errPeriodeStatus       = errors.New("status periode tidak sesuai untuk aksi ini")
+    errPeriodeBerurutan    = errors.New("periode harus ditutup berurutan, bulan sebelumnya masih terbuka")
+)
+
+// ErrPeriodeTertutup diekspor agar job harian dapat membedakan periode yang sudah dikunci dari kegagalan
+var ErrPeriodeTertutup = errPeriodeTertutup
+
+// rolePengelolaPeriode adalah role yang boleh menutup dan membuka kembali periode
+var rolePengelolaPeriode = []string{"admin", "bendahara"}
+
+type PeriodeController struct { DB *gorm.DB }
+func NewPeriodeController(db *gorm.DB) *PeriodeController { return &PeriodeController{DB: db} }
+
+// cekPeriodeTerbuka mengembalikan errPeriodeTertutup jika bulan dari tanggal t sudah ditutup
+// atau tahun bukunya sudah tutup buku
+func cekPeriodeTerbuka(tx *gorm.DB, t time.Time) error {
+    ditutup, err := tahunDitutup(tx, t.Year())
+    if err != nil { return err }
+    if ditutup { return errPeriodeTertutup }
+    var n int64
+    if err := tx.Model(&models.PeriodeAkuntansi{}).Where("tahun = ? AND bulan = ? AND status = ?", t.Year(), int(t.Month()), "ditutup").Count(&n).Error; err != nil {
+        return err
+    }
+    if n > 0 { return errPeriodeTertutup }
+    return nil
+}
+
+// cekPeriodeSebelumnya memastikan setiap bulan sejak transaksi pertama sampai sebelum awal sudah ditutup,
+// sehingga tidak ada bulan terbuka yang terjepit di antara bulan tertutup
+func cekPeriodeSebelumnya(tx *gorm.DB, awal time.Time) error {
+    pertama := awal
+    var s models.Simpanan
+    if err := tx.Where("tanggal < ?", awal).Order("tanggal ASC").Limit(1).Find(&s).Error; err != nil { return err }
+    if s.ID != 0 && s.Tanggal.Before(pertama) { pertama = s.Tanggal }
+    var j models.Jurnal
+    if err := tx.Where("tanggal < ?", awal).Order("tanggal ASC").Limit(1).Find(&j).Error; err != nil { return err }
+    if j.ID != 0 && j.Tanggal.Before(pertama) { pertama = j.Tanggal }
+
+    for t := time.Date(pertama.Year(), pertama.Month(), 1, 0, 0, 0, 0, time.Local); t.Before(awal); t = t.AddDate(0, 1, 0) {
+        err := cekPeriodeTerbuka(tx, t)
+        if err == nil { return fmt.Errorf("%w: %04d-%02d", errPeriodeBerurutan, t.Year(), int(t.Month())) }
+        if err != errPeriodeTertutup { return err }
+    }
+    return nil
+}
+
+// GET /api/periode?tahun=...
+func (h *PeriodeController) ListPeriode(c *gin.Context) {
+    var list []models.PeriodeAkuntansi
+    tx := h.DB.Model(&models.PeriodeAkuntansi{})
+    if tahun := strings.TrimSpace(c.Query("tahun")); tahun != "" { tx = tx.Where("tahun = ?", tahun) }
+    if err := tx.Order("tahun DESC, bulan DESC").Find(&list).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": list})
+}
+
+// POST /api/periode/tutup { tahun, bulan, user_id, catatan }
+// POST /api/periode/buka { tahun, bulan, user_id, catatan }
+type PeriodeActionInput struct {
+    Tahun   int    `json:"tahun" binding:"required"`
+    Bulan   int    `json:"bulan" binding:"required,min=1,max=12"`
+    UserID  uint   `json:"user_id" binding:"required"`
+    Catatan string `json:"catatan"`
+}
+
+// TutupPeriode mengunci bulan yang sudah berakhir; setelah ditutup tidak ada transaksi bertanggal di bulan itu.
+// Bulan ditutup berurutan: bulan sebelumnya yang memiliki transaksi harus sudah ditutup lebih dulu.
+func (h *PeriodeController) TutupPeriode(c *gin.Context) {
+    var in PeriodeActionInput
+    if err := c.ShouldBindJSON(&in); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    awal := time.Date(in.Tahun, time.Month(in.Bulan), 1, 0, 0, 0, 0, time.Local)
+    if awal.AddDate(0, 1, 0).After(time.Now()) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": errPeriodeBelumSelesai.Error()})
+        return
+    }
+
+    var p models.PeriodeAkuntansi
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        u, err := cariPengguna(tx, in.UserID, rolePengelolaPeriode...)
+        if err != nil { return err }
+        if err := tx.Where(models.PeriodeAkuntansi{Tahun: in.Tahun, Bulan: in.Bulan}).Attrs(models.PeriodeAkuntansi{Status: "terbuka"}).FirstOrCreate(&p).Error; err != nil {
+            return err
+        }
+        if p.Status == "ditutup" { return errPeriodeStatus }
+        if err := cekPeriodeSebelumnya(tx, awal); err != nil { return err }
+        now := time.Now()
+        p.Status = "ditutup"
+        p.DitutupOleh = &u.ID
+        p.DitutupAt = &now
+        p.Catatan = in.Catatan
+        if err := tx.Save(&p).Error; err != nil { return err }
+        return catatAudit(tx, u.ID, "periode_ditutup", "periode", p.ID, fmt.Sprintf("%04d-%02d %s", p.Tahun, p.Bulan, in.Catatan))
+    })
+    if err != nil {
+        h.periodeError(c, err)
+        return
+    }
+    c.JSON(http.StatusOK, p)
+}
+
+// BukaPeriode membuka kembali periode yang ditutup. Hanya periode tertutup terakhir yang boleh dibuka
+// agar tidak ada bulan terbuka yang terjepit di antara bulan tertutup.
+func (h *PeriodeController) BukaPeriode(c *gin.Context) {
+    var in PeriodeActionInput
+    if err := c.ShouldBindJSON(&in); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if strings.TrimSpace(in.Catatan) == "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "catatan alasan pembukaan wajib diisi"})
+        return
+    }
+
+    var p models.PeriodeAkuntansi
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        u, err := cariPengguna(tx, in.UserID, rolePengelolaPeriode...)
+        if err != nil { return err }
+        if err := tx.Where("tahun = ? AND bulan = ?", in.Tahun, in.Bulan).First(&p).Error; err != nil { return err }
+        if p.Status != "ditutup" { return errPeriodeStatus }
+        // Bulan pada tahun yang sudah tutup buku tidak dapat dibuka kembali
+        ditutup, err := tahunDitutup(tx, p.Tahun)
+        if err != nil { return err }
+        if ditutup { return errTahunSudahDitutup }
+        var later int64
+        if err := tx.Model(&models.PeriodeAkuntansi{}).Where("status = ? AND (tahun > ? OR (tahun = ? AND bulan > ?))", "ditutup", p.Tahun, p.Tahun, p.Bulan).Count(&later).Error; err != nil {
+            return err
+        }
+        if later > 0 { return errPeriodeStatus }
+        now := time.Now()
+        p.Status = "terbuka"
+        p.DibukaOleh = &u.ID
+        p.DibukaAt = &now
+        p.Catatan = in.Catatan
+        if err := tx.Save(&p).Error; err != nil { return err }
+        return catatAudit(tx, u.ID, "periode_dibuka", "periode", p.ID, fmt.Sprintf("%04d-%02d %s", p.Tahun, p.Bulan, in.Catatan))
+    })
+    if err != nil {
+        h.periodeError(c, err)
+        return
+    }
+    c.JSON(http.StatusOK, p)
+}
+
+func (h *PeriodeController) periodeError(c *gin.Context, err error) {
+    switch {
+    case errors.Is(err, gorm.ErrRecordNotFound):
+        c.JSON(http.StatusNotFound, gin.H{"error": "periode tidak ditemukan"})
+    case errors.Is(err, errAksesDitolak):
+        c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+    case errors.Is(err, errPeriodeStatus), errors.Is(err, errTahunSudahDitutup), errors.Is(err, errPeriodeBerurutan):
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+    default:
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+    }
+}
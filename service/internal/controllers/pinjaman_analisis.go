@@ -0,0 +1,266 @@
+package controllers
+
+import (
+    "errors"
+    "fmt"
+    "math"
+    "net/http"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+
+    "koperasi-desa/service/internal/models"
+    "koperasi-desa/service/internal/settings"
+)
+
+var (
+    errInputAnalisis    = errors.New("data analisis tidak valid")
+    errAnalisisTerkunci = errors.New("analisis tidak dapat diubah setelah proses persetujuan dimulai")
+)
+
+// roleAnalisKredit adalah role yang boleh mengisi analisis kredit
+var roleAnalisKredit = []string{"petugas", "admin", "bendahara", "ketua"}
+
+// urutanRekomendasi dari yang terbaik; rekomendasi akhir adalah yang terburuk dari seluruh pemeriksaan
+var urutanRekomendasi = []string{"layak", "layak_bersyarat", "tidak_layak"}
+
+// RiwayatKredit adalah data riwayat anggota yang diambil otomatis untuk analisis
+type RiwayatKredit struct {
+    PinjamanSebelumnya int      `json:"pinjaman_sebelumnya"`
+    AngsuranTepatWaktu int      `json:"angsuran_tepat_waktu"`
+    AngsuranTerlambat  int      `json:"angsuran_terlambat"`
+    RasioTepatWaktu    *float64 `json:"rasio_tepat_waktu"`
+    AngsuranBerjalan   float64  `json:"angsuran_berjalan"`
+    SaldoSimpanan      float64  `json:"saldo_simpanan"`
+    SimpananBerjangka  float64  `json:"simpanan_berjangka"`
+}
+
+// angsuranBulanan menghitung angsuran bunga flat: (nominal + bunga) / tenor
+func angsuranBulanan(p *models.Pinjaman) float64 {
+    if p.TenorBulan <= 0 { return 0 }
+    return (p.Nominal + p.Nominal*p.BungaPersen/100.0 + p.BiayaDiangsur) / float64(p.TenorBulan)
+}
+
+func tanggalSaja(t time.Time) string { return t.Format("2006-01-02") }
+
+// ketepatanBayar mengelompokkan angsuran: "tepat" jika dibayar paling lambat tanggal jatuh tempo,
+// "terlambat" jika dibayar setelahnya atau belum dibayar padahal jatuh tempo sudah lewat, "" jika belum jatuh tempo
+func ketepatanBayar(a *models.Angsuran, hariIni string) string {
+    jatuhTempo := tanggalSaja(a.TanggalJatuhTempo)
+    switch {
+    case a.TanggalBayar != nil && tanggalSaja(*a.TanggalBayar) <= jatuhTempo:
+        return "tepat"
+    case a.TanggalBayar != nil || jatuhTempo < hariIni:
+        return "terlambat"
+    }
+    return ""
+}
+
+// riwayatKredit mengumpulkan ketepatan bayar angsuran pinjaman lain milik peminjam (berjalan/lunas),
+// angsuran bulanan yang masih berjalan, serta saldo simpanan dan simpanan berjangka aktif per tanggal.
+func riwayatKredit(tx *gorm.DB, p *models.Pinjaman, now time.Time) (RiwayatKredit, error) {
+    var r RiwayatKredit
+    var lain []models.Pinjaman
+    if err := tx.Where("anggota_id = ? AND id <> ? AND status IN ?", p.AnggotaID, p.ID, []string{"berjalan", "lunas"}).Find(&lain).Error; err != nil {
+        return r, err
+    }
+    r.PinjamanSebelumnya = len(lain)
+    hariIni := tanggalSaja(now)
+    for i := range lain {
+        var list []models.Angsuran
+        if err := tx.Scopes(angsuranBerlaku).Where("pinjaman_id = ?", lain[i].ID).Order("ke ASC").Find(&list).Error; err != nil { return r, err }
+        berikutnya := true
+        for _, a := range list {
+            switch ketepatanBayar(&a, hariIni) {
+            case "tepat":
+                r.AngsuranTepatWaktu++
+            case "terlambat":
+                r.AngsuranTerlambat++
+            }
+            if a.TanggalBayar == nil && berikutnya && lain[i].Status == "berjalan" {
+                r.AngsuranBerjalan += a.Jumlah
+                berikutnya = false
+            }
+        }
+    }
+    if n := r.AngsuranTepatWaktu + r.AngsuranTerlambat; n > 0 {
+        rasio := math.Round(float64(r.AngsuranTepatWaktu)/float64(n)*10000) / 100
+        r.RasioTepatWaktu = &rasio
+    }
+
+    for _, j := range jenisSimpananKeluar {
+        saldo, err := saldoPada(tx, p.AnggotaID, j, now)
+        if err != nil { return r, err }
+        r.SaldoSimpanan += saldo
+    }
+    if err := tx.Model(&models.SimpananBerjangka{}).Where("anggota_id = ? AND status = ?", p.AnggotaID, "aktif").
+        Select("COALESCE(SUM(nominal), 0)").Scan(&r.SimpananBerjangka).Error; err != nil {
+        return r, err
+    }
+    return r, nil
+}
+
+// hitungAnalisis mengisi rasio, skor berbobot dan rekomendasi dari isian analis dan riwayat
+func hitungAnalisis(a *models.AnalisisKredit, p *models.Pinjaman, r RiwayatKredit, cfg settings.AnalisisKredit) {
+    a.PinjamanSebelumnya = r.PinjamanSebelumnya
+    a.AngsuranTepatWaktu = r.AngsuranTepatWaktu
+    a.AngsuranTerlambat = r.AngsuranTerlambat
+    a.RasioTepatWaktu = r.RasioTepatWaktu
+    a.AngsuranBerjalan = r.AngsuranBerjalan
+    a.SaldoSimpanan = r.SaldoSimpanan
+    a.SimpananBerjangka = r.SimpananBerjangka
+
+    penghasilan := a.PenghasilanBulanan + a.PenghasilanLain
+    a.AngsuranDiajukan = angsuranBulanan(p)
+    a.SisaPenghasilan = penghasilan - a.PengeluaranBulanan - a.KewajibanLain - a.AngsuranBerjalan
+    a.RasioAngsuranPersen = pembulatan((a.AngsuranDiajukan + a.AngsuranBerjalan + a.KewajibanLain) / penghasilan * 100)
+    a.RasioKapasitasPersen = 0
+    if a.SisaPenghasilan > 0 { a.RasioKapasitasPersen = pembulatan(a.AngsuranDiajukan / a.SisaPenghasilan * 100) }
+
+    skor := map[string]int{
+        "character": a.SkorCharacter, "capacity": a.SkorCapacity, "capital": a.SkorCapital,
+        "collateral": a.SkorCollateral, "condition": a.SkorCondition,
+    }
+    var total, bobot float64
+    for _, aspek := range settings.AspekAnalisis {
+        total += cfg.Bobot[aspek] * float64(skor[aspek])
+        bobot += cfg.Bobot[aspek]
+    }
+    a.SkorTotal = pembulatan(total / bobot)
+
+    tingkat := 0
+    var alasan []string
+    turunkan := func(ke int, pesan string) {
+        tingkat = max(tingkat, ke)
+        alasan = append(alasan, pesan)
+    }
+    switch {
+    case a.SkorTotal >= cfg.SkorLayak:
+        alasan = append(alasan, fmt.Sprintf("skor %.2f memenuhi ambang layak %.2f", a.SkorTotal, cfg.SkorLayak))
+    case a.SkorTotal >= cfg.SkorBersyarat:
+        turunkan(1, fmt.Sprintf("skor %.2f di bawah ambang layak %.2f", a.SkorTotal, cfg.SkorLayak))
+    default:
+        turunkan(2, fmt.Sprintf("skor %.2f di bawah ambang bersyarat %.2f", a.SkorTotal, cfg.SkorBersyarat))
+    }
+    if a.SisaPenghasilan <= a.AngsuranDiajukan {
+        turunkan(2, fmt.Sprintf("sisa penghasilan %.0f tidak cukup untuk angsuran %.0f", a.SisaPenghasilan, a.AngsuranDiajukan))
+    }
+    if a.RasioAngsuranPersen > cfg.MaksRasioAngsuranPersen {
+        turunkan(1, fmt.Sprintf("rasio angsuran %.2f%% melebihi batas %.0f%%", a.RasioAngsuranPersen, cfg.MaksRasioAngsuranPersen))
+    }
+    if a.RasioTepatWaktu != nil && *a.RasioTepatWaktu < cfg.MinimalRasioTepatWaktu {
+        turunkan(1, fmt.Sprintf("angsuran tepat waktu %.2f%% di bawah minimal %.0f%%", *a.RasioTepatWaktu, cfg.MinimalRasioTepatWaktu))
+    }
+    for _, aspek := range settings.AspekAnalisis {
+        if skor[aspek] == 1 { turunkan(1, "aspek "+aspek+" bernilai 1") }
+    }
+    a.Rekomendasi = urutanRekomendasi[tingkat]
+    a.AlasanRekomendasi = strings.Join(alasan, "; ")
+}
+
+func pembulatan(v float64) float64 { return math.Round(v*100) / 100 }
+
+// PUT /api/pinjaman/:id/analisis
+// { user_id, penghasilan_bulanan, penghasilan_lain, pengeluaran_bulanan, kewajiban_lain,
+//   skor_character, skor_capacity, skor_capital, skor_collateral, skor_condition, catatan }
+// Membuat atau memperbarui analisis selama pinjaman pengajuan dan belum ada keputusan persetujuan
+type AnalisisInput struct {
+    UserID             uint    `json:"user_id"`
+    PenghasilanBulanan float64 `json:"penghasilan_bulanan"`
+    PenghasilanLain    float64 `json:"penghasilan_lain"`
+    PengeluaranBulanan float64 `json:"pengeluaran_bulanan"`
+    KewajibanLain      float64 `json:"kewajiban_lain"`
+    SkorCharacter      int     `json:"skor_character"`
+    SkorCapacity       int     `json:"skor_capacity"`
+    SkorCapital        int     `json:"skor_capital"`
+    SkorCollateral     int     `json:"skor_collateral"`
+    SkorCondition      int     `json:"skor_condition"`
+    Catatan            string  `json:"catatan"`
+}
+
+func (in *AnalisisInput) validasi() error {
+    if in.PenghasilanBulanan <= 0 { return fmt.Errorf("%w: penghasilan_bulanan wajib diisi", errInputAnalisis) }
+    if in.PenghasilanLain < 0 || in.PengeluaranBulanan < 0 || in.KewajibanLain < 0 {
+        return fmt.Errorf("%w: nilai penghasilan/pengeluaran tidak boleh negatif", errInputAnalisis)
+    }
+    for _, s := range []int{in.SkorCharacter, in.SkorCapacity, in.SkorCapital, in.SkorCollateral, in.SkorCondition} {
+        if s < 1 || s > 5 { return fmt.Errorf("%w: setiap skor 5C harus 1-5", errInputAnalisis) }
+    }
+    return nil
+}
+
+func (h *PinjamanController) SimpanAnalisis(c *gin.Context) {
+    var in AnalisisInput
+    if err := c.ShouldBindJSON(&in); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if err := in.validasi(); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    var a models.AnalisisKredit
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        u, err := cariPengguna(tx, in.UserID, roleAnalisKredit...)
+        if err != nil { return err }
+        var p models.Pinjaman
+        if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&p, c.Param("id")).Error; err != nil { return err }
+        if p.Status != "pengajuan" { return fmt.Errorf("%w: status %s", errPinjamanTidakMenunggu, p.Status) }
+        var diputus int64
+        if err := tx.Model(&models.KeputusanPersetujuan{}).Where("pinjaman_id = ?", p.ID).Count(&diputus).Error; err != nil { return err }
+        if diputus > 0 { return errAnalisisTerkunci }
+        cfg, err := settings.LoadPinjaman(tx)
+        if err != nil { return err }
+        r, err := riwayatKredit(tx, &p, time.Now())
+        if err != nil { return err }
+
+        if err := tx.Where("pinjaman_id = ?", p.ID).First(&a).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) { return err }
+        a.PinjamanID = p.ID
+        a.AnalisOleh = u.ID
+        a.PenghasilanBulanan, a.PenghasilanLain = in.PenghasilanBulanan, in.PenghasilanLain
+        a.PengeluaranBulanan, a.KewajibanLain = in.PengeluaranBulanan, in.KewajibanLain
+        a.SkorCharacter, a.SkorCapacity, a.SkorCapital = in.SkorCharacter, in.SkorCapacity, in.SkorCapital
+        a.SkorCollateral, a.SkorCondition = in.SkorCollateral, in.SkorCondition
+        a.Catatan = strings.TrimSpace(in.Catatan)
+        hitungAnalisis(&a, &p, r, cfg.Analisis)
+        if err := tx.Save(&a).Error; err != nil { return err }
+        return catatAudit(tx, u.ID, "analisis_kredit", "pinjaman", p.ID, fmt.Sprintf("%s, skor %.2f", a.Rekomendasi, a.SkorTotal))
+    })
+    if err != nil {
+        switch {
+        case errors.Is(err, errAnalisisTerkunci):
+            c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+        default:
+            h.persetujuanError(c, err)
+        }
+        return
+    }
+    c.JSON(http.StatusOK, a)
+}
+
+// GET /api/pinjaman/:id/analisis → analisis tersimpan (null jika belum ada) dan riwayat terkini peminjam
+func (h *PinjamanController) Analisis(c *gin.Context) {
+    var p models.Pinjaman
+    if err := h.DB.First(&p, c.Param("id")).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "pinjaman tidak ditemukan"})
+        return
+    }
+    var a *models.AnalisisKredit
+    var rec models.AnalisisKredit
+    switch err := h.DB.Where("pinjaman_id = ?", p.ID).First(&rec).Error; {
+    case err == nil:
+        a = &rec
+    case !errors.Is(err, gorm.ErrRecordNotFound):
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    r, err := riwayatKredit(h.DB, &p, time.Now())
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"analisis": a, "riwayat": r, "angsuran_diajukan": angsuranBulanan(&p)})
+}
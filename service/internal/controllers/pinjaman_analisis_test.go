@@ -0,0 +1,60 @@
+package controllers
+
+import (
+    "testing"
+
+    "koperasi-desa/service/internal/models"
+    "koperasi-desa/service/internal/settings"
+)
+
+func TestHitungAnalisis(t *testing.T) {
+    cfg := settings.AnalisisKredit{
+        Bobot:                   map[string]float64{"character": 25, "capacity": 30, "capital": 15, "collateral": 15, "condition": 15},
+        MaksRasioAngsuranPersen: 40,
+        MinimalRasioTepatWaktu:  80,
+        SkorLayak:               3.5,
+        SkorBersyarat:           2.5,
+    }
+    // angsuran diajukan (6.000.000 + 20%) / 12 = 600.000
+    p := models.Pinjaman{Nominal: 6_000_000, TenorBulan: 12, BungaPersen: 20}
+    rasio := func(v float64) *float64 { return &v }
+    skor := func(ch, ca, cp, co, cd int) models.AnalisisKredit {
+        return models.AnalisisKredit{SkorCharacter: ch, SkorCapacity: ca, SkorCapital: cp, SkorCollateral: co, SkorCondition: cd}
+    }
+    cases := []struct {
+        nama        string
+        a           models.AnalisisKredit
+        penghasilan float64
+        pengeluaran float64
+        r           RiwayatKredit
+        skorTotal   float64
+        sisa        float64
+        rasioAngs   float64
+        kapasitas   float64
+        rekomendasi string
+    }{
+        {"layak", skor(4, 4, 4, 4, 4), 3_000_000, 1_000_000, RiwayatKredit{}, 4, 2_000_000, 20, 30, "layak"},
+        {"skor berbobot", skor(5, 2, 4, 4, 4), 3_000_000, 1_000_000, RiwayatKredit{}, 3.65, 2_000_000, 20, 30, "layak"},
+        {"skor di bawah ambang layak", skor(3, 3, 3, 3, 3), 3_000_000, 1_000_000, RiwayatKredit{}, 3, 2_000_000, 20, 30, "layak_bersyarat"},
+        {"skor di bawah ambang bersyarat", skor(2, 2, 2, 2, 2), 3_000_000, 1_000_000, RiwayatKredit{}, 2, 2_000_000, 20, 30, "tidak_layak"},
+        {"rasio angsuran melebihi batas", skor(4, 4, 4, 4, 4), 1_400_000, 0, RiwayatKredit{}, 4, 1_400_000, 42.86, 42.86, "layak_bersyarat"},
+        {"sisa penghasilan tidak cukup", skor(5, 5, 5, 5, 5), 1_000_000, 500_000, RiwayatKredit{}, 5, 500_000, 60, 120, "tidak_layak"},
+        {"angsuran berjalan mengurangi sisa", skor(4, 4, 4, 4, 4), 3_000_000, 1_000_000, RiwayatKredit{AngsuranBerjalan: 400_000, RasioTepatWaktu: rasio(90)}, 4, 1_600_000, 33.33, 37.5, "layak"},
+        {"riwayat tepat waktu di bawah minimal", skor(4, 4, 4, 4, 4), 3_000_000, 1_000_000, RiwayatKredit{RasioTepatWaktu: rasio(75)}, 4, 2_000_000, 20, 30, "layak_bersyarat"},
+        {"aspek bernilai 1", skor(1, 5, 5, 5, 5), 3_000_000, 1_000_000, RiwayatKredit{}, 4, 2_000_000, 20, 30, "layak_bersyarat"},
+    }
+    for _, c := range cases {
+        t.Run(c.nama, func(t *testing.T) {
+            a := c.a
+            a.PenghasilanBulanan = c.penghasilan
+            a.PengeluaranBulanan = c.pengeluaran
+            hitungAnalisis(&a, &p, c.r, cfg)
+            if a.AngsuranDiajukan != 600_000 { t.Errorf("angsuran diajukan = %v, ingin 600000", a.AngsuranDiajukan) }
+            if a.SkorTotal != c.skorTotal { t.Errorf("skor total = %v, ingin %v", a.SkorTotal, c.skorTotal) }
+            if a.SisaPenghasilan != c.sisa { t.Errorf("sisa penghasilan = %v, ingin %v", a.SisaPenghasilan, c.sisa) }
+            if a.RasioAngsuranPersen != c.rasioAngs { t.Errorf("rasio angsuran = %v, ingin %v", a.RasioAngsuranPersen, c.rasioAngs) }
+            if a.RasioKapasitasPersen != c.kapasitas { t.Errorf("rasio kapasitas = %v, ingin %v", a.RasioKapasitasPersen, c.kapasitas) }
+            if a.Rekomendasi != c.rekomendasi { t.Errorf("rekomendasi = %s, ingin %s (%s)", a.Rekomendasi, c.rekomendasi, a.AlasanRekomendasi) }
+        })
+    }
+}
@@ -0,0 +1,234 @@
+package controllers
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+
+    "koperasi-desa/service/internal/models"
+    "koperasi-desa/service/internal/settings"
+)
+
+// rolePengelolaAutoDebit boleh mendaftarkan dan mencabut mandat auto-debit atas permintaan anggota
+var rolePengelolaAutoDebit = []string{"petugas", "admin", "bendahara", "ketua"}
+
+var (
+    errMandatPinjaman        = errors.New("mandat auto-debit hanya untuk pinjaman yang belum lunas atau ditolak")
+    errMandatTidakAda        = errors.New("pinjaman tidak memiliki mandat auto-debit aktif")
+    errSaldoAutoDebitKurang  = errors.New("saldo simpanan sukarela tidak cukup")
+    errAutoDebitTidakBerlaku = errors.New("pinjaman tidak berstatus berjalan")
+)
+
+func autoDebitError(c *gin.Context, err error) {
+    switch {
+    case errors.Is(err, gorm.ErrRecordNotFound):
+        c.JSON(http.StatusNotFound, gin.H{"error": "pinjaman tidak ditemukan"})
+    case errors.Is(err, errAksesDitolak):
+        c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+    case errors.Is(err, errMandatPinjaman), errors.Is(err, errMandatTidakAda), errors.Is(err, errAnggotaTidakAktif):
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+    default:
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+    }
+}
+
+// PUT /api/pinjaman/:id/auto-debit { user_id, aktif, catatan }
+// Mendaftarkan (aktif=true) atau mencabut (aktif=false) mandat auto-debit angsuran dari simpanan sukarela.
+// Pendaftaran hanya untuk anggota aktif dan pinjaman pengajuan/disetujui/berjalan.
+type MandatAutoDebitInput struct {
+    UserID  uint   `json:"user_id"`
+    Aktif   bool   `json:"aktif"`
+    Catatan string `json:"catatan"`
+}
+
+func (h *PinjamanController) SimpanMandatAutoDebit(c *gin.Context) {
+    var in MandatAutoDebitInput
+    if err := c.ShouldBindJSON(&in); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    var m models.MandatAutoDebit
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        u, err := cariPengguna(tx, in.UserID, rolePengelolaAutoDebit...)
+        if err != nil { return err }
+        var p models.Pinjaman
+        if err := tx.First(&p, c.Param("id")).Error; err != nil { return err }
+        err = tx.Where("pinjaman_id = ?", p.ID).First(&m).Error
+        if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) { return err }
+        ada := err == nil
+
+        if !in.Aktif {
+            if !ada || !m.Aktif { return errMandatTidakAda }
+            now := time.Now()
+            m.Aktif, m.DicabutOleh, m.DicabutAt = false, &u.ID, &now
+            if err := tx.Save(&m).Error; err != nil { return err }
+            return catatAudit(tx, u.ID, "auto_debit_dicabut", "pinjaman", p.ID, strings.TrimSpace(in.Catatan))
+        }
+
+        if p.Status != "pengajuan" && p.Status != "disetujui" && p.Status != "berjalan" { return errMandatPinjaman }
+        var a models.Anggota
+        if err := tx.First(&a, p.AnggotaID).Error; err != nil { return err }
+        if err := cekAnggotaAktif(&a); err != nil { return err }
+        m.PinjamanID, m.AnggotaID, m.Aktif = p.ID, p.AnggotaID, true
+        m.DibuatOleh, m.Catatan = u.ID, strings.TrimSpace(in.Catatan)
+        m.DicabutOleh, m.DicabutAt = nil, nil
+        if err := tx.Save(&m).Error; err != nil { return err }
+        return catatAudit(tx, u.ID, "auto_debit_didaftarkan", "pinjaman", p.ID, m.Catatan)
+    })
+    if err != nil {
+        autoDebitError(c, err)
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": m})
+}
+
+// GET /api/pinjaman/:id/auto-debit
+// Mandat auto-debit pinjaman (null jika belum pernah didaftarkan) beserta log pendebetan terbaru
+func (h *PinjamanController) MandatAutoDebit(c *gin.Context) {
+    var p models.Pinjaman
+    if err := h.DB.First(&p, c.Param("id")).Error; err != nil {
+        autoDebitError(c, err)
+        return
+    }
+    var mandat *models.MandatAutoDebit
+    var m models.MandatAutoDebit
+    if err := h.DB.Where("pinjaman_id = ?", p.ID).First(&m).Error; err == nil {
+        mandat = &m
+    } else if !errors.Is(err, gorm.ErrRecordNotFound) {
+        autoDebitError(c, err)
+        return
+    }
+    logs := []models.AutoDebitLog{}
+    if err := h.DB.Where("pinjaman_id = ?", p.ID).Order("tanggal DESC, id DESC").Limit(50).Find(&logs).Error; err != nil {
+        autoDebitError(c, err)
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": mandat, "log": logs})
+}
+
+// GET /api/pinjaman/auto-debit/log?status=gagal&tanggal=YYYY-MM-DD&page=&limit=
+// Daftar hasil auto-debit untuk ditindaklanjuti petugas, terbaru lebih dulu
+func (h *PinjamanController) LogAutoDebit(c *gin.Context) {
+    page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+    limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+    if page < 1 { page = 1 }
+    if limit < 1 || limit > 200 { limit = 50 }
+
+    tx := h.DB.Model(&models.AutoDebitLog{})
+    if s := strings.TrimSpace(c.Query("status")); s != "" { tx = tx.Where("status = ?", s) }
+    if t := strings.TrimSpace(c.Query("tanggal")); t != "" { tx = tx.Where("tanggal = ?", t) }
+    list := []models.AutoDebitLog{}
+    if err := tx.Order("tanggal DESC, id DESC").Limit(limit).Offset((page - 1) * limit).Find(&list).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": list, "page": page, "limit": limit})
+}
+
+// HasilAutoDebit merangkum satu kali proses auto-debit
+type HasilAutoDebit struct {
+    Mandat   int `json:"mandat"`
+    Berhasil int `json:"berhasil"`
+    Gagal    int `json:"gagal"`
+}
+
+// ProsesAutoDebit mendebit simpanan sukarela untuk setiap angsuran yang jatuh tempo paling lambat hari ini dan belum dibayar
+// pada pinjaman bermandat aktif, mulai angsuran terlama. Setiap angsuran diproses dalam satu transaksi:
+// penarikan sukarela dan pembayaran angsuran (beserta denda) memakai nomor bukti yang sama. Kegagalan
+// dicatat di AutoDebitLog dan angsuran berikutnya pada pinjaman itu tidak dicoba.
+func (h *PinjamanController) ProsesAutoDebit(now time.Time) (HasilAutoDebit, error) {
+    var hasil HasilAutoDebit
+    cfg, err := settings.LoadPinjaman(h.DB)
+    if err != nil { return hasil, err }
+    var mandat []models.MandatAutoDebit
+    if err := h.DB.Where("aktif = ?", true).Order("id ASC").Find(&mandat).Error; err != nil { return hasil, err }
+    hasil.Mandat = len(mandat)
+    // jatuh tempo membawa jam pencairan; angsuran yang jatuh tempo hari ini ikut didebit walau job berjalan dini hari
+    besok := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+
+    for _, m := range mandat {
+        var jatuhTempo []models.Angsuran
+        if err := h.DB.Scopes(angsuranBerlaku).Where("pinjaman_id = ? AND tanggal_bayar IS NULL AND tanggal_jatuh_tempo < ?", m.PinjamanID, besok).
+            Order("ke ASC").Find(&jatuhTempo).Error; err != nil {
+            return hasil, err
+        }
+        for i := range jatuhTempo {
+            entri := models.AutoDebitLog{MandatID: m.ID, PinjamanID: m.PinjamanID, AngsuranID: jatuhTempo[i].ID, Tanggal: tanggalSaja(now)}
+            err := h.DB.Transaction(func(tx *gorm.DB) error {
+                if err := debitAngsuran(tx, &m, &jatuhTempo[i], now, cfg.AutoDebit, &entri); err != nil { return err }
+                entri.Status = "berhasil"
+                return simpanLogAutoDebit(tx, &entri)
+            })
+            if err == nil {
+                hasil.Berhasil++
+                continue
+            }
+            hasil.Gagal++
+            entri.ID, entri.NomorBukti, entri.Status, entri.Alasan = 0, "", "gagal", err.Error()
+            if err := simpanLogAutoDebit(h.DB, &entri); err != nil { return hasil, err }
+            break
+        }
+    }
+    return hasil, nil
+}
+
+// debitAngsuran menarik tagihan satu angsuran dari simpanan sukarela lalu membayarnya. Saldo sukarela
+// setelah didebit tidak boleh kurang dari settings.pinjaman.auto_debit.saldo_minimal. Jurnalnya mengikuti
+// alur manual (penarikan sukarela lalu pembayaran angsuran tunai) sehingga koreksi salah satu transaksi
+// tetap menghasilkan buku yang seimbang.
+func debitAngsuran(tx *gorm.DB, m *models.MandatAutoDebit, a *models.Angsuran, now time.Time, cfg settings.AutoDebit, entri *models.AutoDebitLog) error {
+    var p models.Pinjaman
+    if err := tx.First(&p, a.PinjamanID).Error; err != nil { return err }
+    if p.Status != "berjalan" { return errAutoDebitTidakBerlaku }
+
+    entri.Tagihan = a.Jumlah + dendaAngsuran(a, now)
+    saldo, err := saldoPada(tx, m.AnggotaID, "sukarela", now)
+    if err != nil { return err }
+    entri.SaldoSukarela = saldo
+    if saldo-entri.Tagihan < cfg.SaldoMinimal {
+        return fmt.Errorf("%w: saldo %s, tagihan %s, saldo minimal %s", errSaldoAutoDebitKurang, rupiah(saldo), rupiah(entri.Tagihan), rupiah(cfg.SaldoMinimal))
+    }
+
+    if err := bayarAngsuran(tx, a, now); err != nil { return err }
+    tarik := models.Simpanan{
+        AnggotaID:  m.AnggotaID,
+        Jenis:      "sukarela",
+        Tipe:       "penarikan",
+        Tanggal:    now,
+        Jumlah:     a.Jumlah + a.Denda,
+        Keterangan: fmt.Sprintf("Auto-debit angsuran ke-%d pinjaman %s", a.Ke, p.NomorPinjaman),
+        NomorBukti: a.NomorBukti,
+        Sumber:     "auto_debit",
+    }
+    if err := catatSimpanan(tx, &tarik, ""); err != nil { return err }
+    entri.NomorBukti = a.NomorBukti
+    return nil
+}
+
+func simpanLogAutoDebit(tx *gorm.DB, l *models.AutoDebitLog) error {
+    var lama models.AutoDebitLog
+    err := tx.Where("angsuran_id = ? AND tanggal = ?", l.AngsuranID, l.Tanggal).First(&lama).Error
+    switch {
+    case err == nil:
+        l.ID, l.CreatedAt = lama.ID, lama.CreatedAt
+    case !errors.Is(err, gorm.ErrRecordNotFound):
+        return err
+    }
+    return tx.Save(l).Error
+}
+
+// POST /api/pinjaman/auto-debit/proses → sama dengan job harian, untuk dijalankan manual
+func (h *PinjamanController) ProsesAutoDebitManual(c *gin.Context) {
+    hasil, err := h.ProsesAutoDebit(time.Now())
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "data": hasil})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": hasil})
+}
@@ -0,0 +1,255 @@
+package controllers
+
+import (
+    "bytes"
+    "errors"
+    "fmt"
+    "math"
+    "mime"
+    "net/http"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+
+    "koperasi-desa/service/internal/berkas"
+    "koperasi-desa/service/internal/models"
+    "koperasi-desa/service/internal/settings"
+)
+
+var (
+    errBiayaMelebihiPinjaman  = errors.New("biaya yang dipotong saat pencairan tidak boleh melebihi nominal pinjaman")
+    errPinjamanBelumDicairkan = errors.New("pinjaman belum dicairkan")
+)
+
+// akunBiayaPinjaman memetakan jenis biaya ke akun kreditnya. Premi asuransi bukan pendapatan koperasi
+// melainkan titipan yang diteruskan ke perusahaan asuransi.
+var akunBiayaPinjaman = map[string]string{
+    "administrasi": models.AkunPendapatanAdmin,
+    "provisi":      models.AkunPendapatanProvisi,
+    "asuransi":     models.AkunUtangPremi,
+}
+
+var namaBiayaPinjaman = map[string]string{
+    "administrasi": "Biaya administrasi",
+    "provisi":      "Provisi",
+    "asuransi":     "Premi asuransi jiwa kredit",
+}
+
+// hitungBiayaPinjaman menghitung komponen biaya satu pinjaman dari settings.financial.biaya_pinjaman,
+// dibulatkan ke rupiah penuh. Komponen bernilai nol dilewati.
+func hitungBiayaPinjaman(fs settings.Financial, p *models.Pinjaman) []models.BiayaPinjaman {
+    var list []models.BiayaPinjaman
+    for _, b := range fs.BiayaUntuk(p.Kategori) {
+        persen := b.Persen
+        if b.PerTahun { persen = b.Persen * float64(p.TenorBulan) / 12 }
+        jumlah := max(math.Round(b.Nominal+p.Nominal*persen/100), b.Minimal)
+        if jumlah <= 0 { continue }
+        nama := strings.TrimSpace(b.Nama)
+        if nama == "" { nama = namaBiayaPinjaman[b.Jenis] }
+        pembebanan := b.Pembebanan
+        if pembebanan == "" { pembebanan = "potong" }
+        list = append(list, models.BiayaPinjaman{
+            PinjamanID: p.ID,
+            Jenis:      b.Jenis,
+            Nama:       nama,
+            Persen:     persen,
+            Jumlah:     jumlah,
+            Pembebanan: pembebanan,
+            AkunKode:   akunBiayaPinjaman[b.Jenis],
+        })
+    }
+    return list
+}
+
+// terapkanBiaya mengisi total biaya dan pencairan bersih pinjaman dari rincian biayanya
+func terapkanBiaya(p *models.Pinjaman, biaya []models.BiayaPinjaman) error {
+    p.BiayaPotongan, p.BiayaDiangsur = 0, 0
+    for _, b := range biaya {
+        if b.Pembebanan == "angsuran" {
+            p.BiayaDiangsur += b.Jumlah
+        } else {
+            p.BiayaPotongan += b.Jumlah
+        }
+    }
+    p.PencairanBersih = p.Nominal - p.BiayaPotongan
+    if p.PencairanBersih <= 0 { return fmt.Errorf("%w: potongan %.0f, nominal %.0f", errBiayaMelebihiPinjaman, p.BiayaPotongan, p.Nominal) }
+    return nil
+}
+
+// RincianPencairan adalah rincian dana pinjaman: nominal, biaya yang dipotong, pencairan bersih dan
+// biaya yang ditambahkan ke angsuran. Simulasi bernilai true bila pinjaman belum dicairkan sehingga
+// biaya dihitung dari pengaturan saat ini dan dapat berubah.
+type RincianPencairan struct {
+    PinjamanID       uint                   `json:"pinjaman_id"`
+    NomorPinjaman    string                 `json:"nomor_pinjaman"`
+    NomorBukti       string                 `json:"nomor_bukti"`
+    TanggalPencairan *time.Time             `json:"tanggal_pencairan"`
+    Simulasi         bool                   `json:"simulasi"`
+    MetodePencairan  string                 `json:"metode_pencairan"`
+    Nominal          float64                `json:"nominal"`
+    Biaya            []models.BiayaPinjaman `json:"biaya"`
+    BiayaPotongan    float64                `json:"biaya_potongan"`
+    BiayaDiangsur    float64                `json:"biaya_diangsur"`
+    PencairanBersih  float64                `json:"pencairan_bersih"`
+    TenorBulan       int                    `json:"tenor_bulan"`
+    AngsuranBulanan  float64                `json:"angsuran_bulanan"`
+}
+
+func rincianPencairan(p *models.Pinjaman) RincianPencairan {
+    r := RincianPencairan{
+        PinjamanID:       p.ID,
+        NomorPinjaman:    p.NomorPinjaman,
+        NomorBukti:       p.NomorBuktiPencairan,
+        TanggalPencairan: p.TanggalPencairan,
+        Simulasi:         p.TanggalPencairan == nil,
+        MetodePencairan:  p.MetodePencairan,
+        Nominal:          p.Nominal,
+        Biaya:            p.Biaya,
+        BiayaPotongan:    p.BiayaPotongan,
+        BiayaDiangsur:    p.BiayaDiangsur,
+        PencairanBersih:  p.PencairanBersih,
+        TenorBulan:       p.TenorBulan,
+        AngsuranBulanan:  angsuranBulanan(p),
+    }
+    if r.Biaya == nil { r.Biaya = []models.BiayaPinjaman{} }
+    return r
+}
+
+// muatRincianPencairan memuat pinjaman beserta biayanya; untuk pinjaman yang belum dicairkan biaya
+// dihitung dari settings.financial tanpa disimpan
+func muatRincianPencairan(db *gorm.DB, id string) (*models.Pinjaman, error) {
+    var p models.Pinjaman
+    if err := db.Preload("Biaya", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).First(&p, id).Error; err != nil { return nil, err }
+    if p.TanggalPencairan != nil { return &p, nil }
+    fs, err := settings.LoadFinancial(db)
+    if err != nil { return nil, err }
+    p.Biaya = hitungBiayaPinjaman(fs, &p)
+    return &p, terapkanBiaya(&p, p.Biaya)
+}
+
+func biayaError(c *gin.Context, err error) {
+    switch {
+    case errors.Is(err, gorm.ErrRecordNotFound):
+        c.JSON(http.StatusNotFound, gin.H{"error": "pinjaman tidak ditemukan"})
+    case errors.Is(err, errBiayaMelebihiPinjaman):
+        c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+    case errors.Is(err, errPinjamanBelumDicairkan):
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+    default:
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+    }
+}
+
+// GET /api/pinjaman/:id/pencairan
+// Rincian pencairan: biaya yang dipotong, dana bersih yang diterima anggota dan angsuran per bulan.
+// Sebelum pencairan berupa simulasi dari settings.financial.biaya_pinjaman.
+func (h *PinjamanController) RincianPencairan(c *gin.Context) {
+    p, err := muatRincianPencairan(h.DB, c.Param("id"))
+    if err != nil {
+        biayaError(c, err)
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": rincianPencairan(p)})
+}
+
+// GET /api/pinjaman/:id/slip-pencairan
+// Slip pencairan (PDF) berisi rincian biaya untuk ditandatangani anggota dan petugas
+func (h *PinjamanController) SlipPencairan(c *gin.Context) {
+    p, err := muatRincianPencairan(h.DB, c.Param("id"))
+    if err == nil && p.TanggalPencairan == nil { err = errPinjamanBelumDicairkan }
+    if err != nil {
+        biayaError(c, err)
+        return
+    }
+    var a models.Anggota
+    if err := h.DB.First(&a, p.AnggotaID).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    profil, err := settings.LoadProfile(h.DB)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    r := rincianPencairan(p)
+    var baris []string
+    if profil.Name != "" { baris = append(baris, profil.Name, profil.Address, "") }
+    baris = append(baris,
+        "Nomor bukti    : "+r.NomorBukti,
+        "Tanggal        : "+p.TanggalPencairan.Format("02-01-2006 15:04"),
+        "Anggota        : "+a.NomorAnggota+" - "+a.Nama,
+        "Nomor pinjaman : "+p.NomorPinjaman,
+        fmt.Sprintf("Tenor / bunga  : %d bulan / %.2f%% flat", p.TenorBulan, p.BungaPersen),
+        "",
+        "Nominal pinjaman                 "+rupiah(r.Nominal),
+    )
+    for _, b := range r.Biaya {
+        if b.Pembebanan == "potong" { baris = append(baris, "  dikurangi "+keteranganBiaya(b)+"   "+rupiah(b.Jumlah)) }
+    }
+    baris = append(baris,
+        "Total potongan                   "+rupiah(r.BiayaPotongan),
+        "Diterima anggota                 "+rupiah(r.PencairanBersih),
+    )
+    baris = append(baris, "Disalurkan melalui               "+saluranPencairan(p))
+    if r.BiayaDiangsur > 0 {
+        baris = append(baris, "", "Biaya yang ditambahkan ke angsuran:")
+        for _, b := range r.Biaya {
+            if b.Pembebanan == "angsuran" { baris = append(baris, "  "+keteranganBiaya(b)+"   "+rupiah(b.Jumlah)) }
+        }
+    }
+    baris = append(baris,
+        "",
+        fmt.Sprintf("Angsuran per bulan               %s x %d", rupiah(r.AngsuranBulanan), p.TenorBulan),
+        "",
+        "",
+        "Petugas,                                                    Penerima,",
+        "",
+        "",
+        "",
+        "(                              )                            ( "+a.Nama+" )",
+    )
+
+    pdf := berkas.NewPDF("Slip Pencairan " + p.NomorPinjaman)
+    pdf.TambahTeks("Slip Pencairan Pinjaman", baris)
+    var buf bytes.Buffer
+    if err := pdf.Tulis(&buf); err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    nama := fmt.Sprintf("slip-pencairan-%s.pdf", strings.NewReplacer("/", "-", " ", "_").Replace(p.NomorPinjaman))
+    c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": nama}))
+    c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+// saluranPencairan menjelaskan metode pencairan untuk slip
+func saluranPencairan(p *models.Pinjaman) string {
+    switch p.MetodePencairan {
+    case "sukarela":
+        return "setoran simpanan sukarela anggota"
+    case "transfer":
+        s := fmt.Sprintf("transfer %s %s", p.BankTujuan, p.RekeningTujuan)
+        if p.AtasNamaRekening != "" { s += " a.n. " + p.AtasNamaRekening }
+        return s + ", ref. " + p.ReferensiTransfer
+    }
+    return "tunai"
+}
+
+func keteranganBiaya(b models.BiayaPinjaman) string {
+    if b.Persen > 0 { return fmt.Sprintf("%s (%.2f%%)", b.Nama, b.Persen) }
+    return b.Nama
+}
+
+// rupiah memformat nominal dengan pemisah ribuan, mis. Rp 1.250.000
+func rupiah(n float64) string {
+    s := fmt.Sprintf("%.0f", math.Abs(n))
+    var b strings.Builder
+    for i, r := range s {
+        if i > 0 && (len(s)-i)%3 == 0 { b.WriteByte('.') }
+        b.WriteRune(r)
+    }
+    if n < 0 { return "Rp -" + b.String() }
+    return "Rp " + b.String()
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+    "errors"
+    "testing"
+
+    "koperasi-desa/service/internal/models"
+    "koperasi-desa/service/internal/settings"
+)
+
+func TestHitungBiayaPinjaman(t *testing.T) {
+    biaya := map[string][]settings.BiayaPinjaman{
+        "default": {
+            {Jenis: "administrasi", Nominal: 50_000},
+            {Jenis: "provisi", Persen: 1, Minimal: 25_000},
+        },
+        "produktif": {
+            {Jenis: "asuransi", Persen: 0.6, PerTahun: true, Pembebanan: "angsuran"},
+            {Jenis: "administrasi", Nama: "Materai", Nominal: 10_000},
+            {Jenis: "provisi", Persen: 0},
+        },
+    }
+    type rinci struct {
+        jenis      string
+        nama       string
+        jumlah     float64
+        pembebanan string
+        akun       string
+    }
+    cases := []struct {
+        nama  string
+        fs    settings.Financial
+        p     models.Pinjaman
+        ingin []rinci
+    }{
+        {"tanpa pengaturan biaya", settings.Financial{}, models.Pinjaman{Nominal: 10_000_000, TenorBulan: 12}, nil},
+        {"biaya_admin_default lama", settings.Financial{BiayaAdminDefault: 15_000}, models.Pinjaman{Nominal: 10_000_000, TenorBulan: 12},
+            []rinci{{"administrasi", "Biaya administrasi", 15_000, "potong", models.AkunPendapatanAdmin}}},
+        {"kategori tidak diatur memakai default", settings.Financial{BiayaPinjaman: biaya}, models.Pinjaman{Nominal: 10_000_000, TenorBulan: 12, Kategori: "konsumtif"},
+            []rinci{{"administrasi", "Biaya administrasi", 50_000, "potong", models.AkunPendapatanAdmin}, {"provisi", "Provisi", 100_000, "potong", models.AkunPendapatanProvisi}}},
+        {"provisi minimal", settings.Financial{BiayaPinjaman: biaya}, models.Pinjaman{Nominal: 1_000_000, TenorBulan: 6},
+            []rinci{{"administrasi", "Biaya administrasi", 50_000, "potong", models.AkunPendapatanAdmin}, {"provisi", "Provisi", 25_000, "potong", models.AkunPendapatanProvisi}}},
+        {"persen per tahun mengikuti tenor, komponen nol dilewati", settings.Financial{BiayaPinjaman: biaya}, models.Pinjaman{Nominal: 20_000_000, TenorBulan: 18, Kategori: "produktif"},
+            []rinci{{"asuransi", "Premi asuransi jiwa kredit", 180_000, "angsuran", models.AkunUtangPremi}, {"administrasi", "Materai", 10_000, "potong", models.AkunPendapatanAdmin}}},
+        {"dibulatkan ke rupiah penuh", settings.Financial{BiayaPinjaman: biaya}, models.Pinjaman{Nominal: 3_333_333, TenorBulan: 12},
+            []rinci{{"administrasi", "Biaya administrasi", 50_000, "potong", models.AkunPendapatanAdmin}, {"provisi", "Provisi", 33_333, "potong", models.AkunPendapatanProvisi}}},
+    }
+    for _, c := range cases {
+        t.Run(c.nama, func(t *testing.T) {
+            got := hitungBiayaPinjaman(c.fs, &c.p)
+            if len(got) != len(c.ingin) { t.Fatalf("jumlah komponen = %d, ingin %d: %+v", len(got), len(c.ingin), got) }
+            for i, w := range c.ingin {
+                g := got[i]
+                if g.Jenis != w.jenis || g.Nama != w.nama || g.Jumlah != w.jumlah || g.Pembebanan != w.pembebanan || g.AkunKode != w.akun {
+                    t.Errorf("komponen %d = %s/%s/%v/%s/%s, ingin %+v", i, g.Jenis, g.Nama, g.Jumlah, g.Pembebanan, g.AkunKode, w)
+                }
+            }
+        })
+    }
+}
+
+func TestTerapkanBiaya(t *testing.T) {
+    cases := []struct {
+        nama     string
+        nominal  float64
+        biaya    []models.BiayaPinjaman
+        potongan float64
+        diangsur float64
+        bersih   float64
+        err      error
+    }{
+        {"tanpa biaya", 5_000_000, nil, 0, 0, 5_000_000, nil},
+        {"dipotong dan diangsur", 5_000_000, []models.BiayaPinjaman{{Jumlah: 50_000, Pembebanan: "potong"}, {Jumlah: 30_000, Pembebanan: "angsuran"}, {Jumlah: 20_000}}, 70_000, 30_000, 4_930_000, nil},
+        {"seluruhnya diangsur", 5_000_000, []models.BiayaPinjaman{{Jumlah: 100_000, Pembebanan: "angsuran"}}, 0, 100_000, 5_000_000, nil},
+        {"potongan sama dengan nominal", 100_000, []models.BiayaPinjaman{{Jumlah: 100_000, Pembebanan: "potong"}}, 100_000, 0, 0, errBiayaMelebihiPinjaman},
+        {"potongan melebihi nominal", 100_000, []models.BiayaPinjaman{{Jumlah: 150_000, Pembebanan: "potong"}}, 150_000, 0, -50_000, errBiayaMelebihiPinjaman},
+    }
+    for _, c := range cases {
+        t.Run(c.nama, func(t *testing.T) {
+            p := models.Pinjaman{Nominal: c.nominal, BiayaPotongan: 1, BiayaDiangsur: 1}
+            err := terapkanBiaya(&p, c.biaya)
+            if !errors.Is(err, c.err) { t.Fatalf("err = %v, ingin %v", err, c.err) }
+            if p.BiayaPotongan != c.potongan || p.BiayaDiangsur != c.diangsur || p.PencairanBersih != c.bersih {
+                t.Errorf("potongan/diangsur/bersih = %v/%v/%v, ingin %v/%v/%v", p.BiayaPotongan, p.BiayaDiangsur, p.PencairanBersih, c.potongan, c.diangsur, c.bersih)
+            }
+        })
+    }
+}
@@ -1,20 +1,30 @@
 package controllers
 
 import (
+    "errors"
     "fmt"
     "net/http"
+    "slices"
     "strconv"
     "strings"
     "time"
 
     "github.com/gin-gonic/gin"
     "gorm.io/gorm"
+    "gorm.io/gorm/clause"
 
     "koperasi-desa/service/internal/models"
+    "koperasi-desa/service/internal/settings"
+    "koperasi-desa/service/internal/storage"
 )
 
-type PinjamanController struct { DB *gorm.DB }
-func NewPinjamanController(db *gorm.DB) *PinjamanController { return &PinjamanController{DB: db} }
+var errPinjamanBelumDisetujui = errors.New("hanya pinjaman berstatus disetujui yang dapat dicairkan")
+
+type PinjamanController struct {
+    DB      *gorm.DB
+    Storage storage.Storage
+}
+func NewPinjamanController(db *gorm.DB, st storage.Storage) *PinjamanController { return &PinjamanController{DB: db, Storage: st} }
 
 // GET /api/pinjaman?anggota_id=...&status=...&page=...&limit=...
 func (h *PinjamanController) ListPinjaman(c *gin.Context) {
@@ -32,6 +42,11 @@ func (h *PinjamanController) ListPinjaman(c *gin.Context) {
     if anggotaID != "" { tx = tx.Where("anggota_id = ?", anggotaID) }
     if status != "" { tx = tx.Where("status = ?", strings.ToLower(status)) }
 
+    tx = tx.Preload("Persetujuan", func(db *gorm.DB) *gorm.DB { return db.Order("urutan ASC") }).
+        Preload("Persetujuan.Keputusan", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+        Preload("Analisis").
+        Preload("Biaya").
+        Preload("AutoDebit")
     if err := tx.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&list).Error; err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
@@ -40,15 +55,23 @@ func (h *PinjamanController) ListPinjaman(c *gin.Context) {
 }
 
 // POST /api/pinjaman/pengajuan
-// { anggota_id, nominal, tenor_bulan, bunga_persen, tanggal_pengajuan? }
+// { anggota_id, nominal, tenor_bulan, bunga_persen, tanggal_pengajuan?, kategori?, user_id }
+// Rantai tahap persetujuan ditetapkan saat pengajuan dari nominal dan kategori. user_id (petugas
+// yang menginput, wajib dan harus berperan rolePengajuPinjaman) tidak dapat menyetujui pinjaman ini. Ditolak (422) bila skor kredit anggota di bawah
+// settings.skor_kredit.minimal_pengajuan.
 type PinjamanPengajuanInput struct {
     AnggotaID    uint       `json:"anggota_id"`
     Nominal      float64    `json:"nominal"`
     TenorBulan   int        `json:"tenor_bulan"`
     BungaPersen  float64    `json:"bunga_persen"`
     Tanggal      *time.Time `json:"tanggal_pengajuan"`
+    Kategori     string     `json:"kategori"`
+    UserID       uint       `json:"user_id" binding:"required"`
 }
 
+// rolePengajuPinjaman boleh menginput pengajuan pinjaman
+var rolePengajuPinjaman = []string{"petugas", "admin", "bendahara", "ketua"}
+
 func (h *PinjamanController) Pengajuan(c *gin.Context) {
     var in PinjamanPengajuanInput
     if err := c.ShouldBindJSON(&in); err != nil {
@@ -65,6 +88,23 @@ func (h *PinjamanController) Pengajuan(c *gin.Context) {
         c.JSON(http.StatusBadRequest, gin.H{"error": "anggota tidak ditemukan"})
         return
     }
+    if err := cekAnggotaAktif(&a); err != nil {
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+        return
+    }
+
+    kategori := strings.ToLower(strings.TrimSpace(in.Kategori))
+    if kategori != "" {
+        cat, err := settings.LoadCategories(h.DB)
+        if err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+            return
+        }
+        if !slices.Contains(cat.Pinjaman, kategori) {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "kategori pinjaman tidak dikenal"})
+            return
+        }
+    }
 
     tanggal := time.Now()
     if in.Tanggal != nil { tanggal = *in.Tanggal }
@@ -75,60 +115,83 @@ func (h *PinjamanController) Pengajuan(c *gin.Context) {
         Nominal:          in.Nominal,
         TenorBulan:       in.TenorBulan,
         BungaPersen:      in.BungaPersen,
+        Kategori:         kategori,
+        DiajukanOleh:     &in.UserID,
         Status:           "pengajuan",
     }
-    if err := h.DB.Create(&p).Error; err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+    // nomor pinjaman diambil dari urutan settings.format dalam transaksi yang sama
+    var skor *models.SkorKredit
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        var err error
+        if _, err := cariPengguna(tx, in.UserID, rolePengajuPinjaman...); err != nil { return err }
+        if skor, err = cekSkorPengajuan(tx, &a); err != nil { return err }
+        if p.NomorPinjaman, err = nomorBerikutnya(tx, "pinjaman", tanggal); err != nil { return err }
+        if err := tx.Create(&p).Error; err != nil { return err }
+        p.Persetujuan, err = rantaiPersetujuan(tx, &p)
+        return err
+    })
+    if err != nil {
+        if errors.Is(err, errAksesDitolak) {
+            c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+        } else if errors.Is(err, errSkorKreditKurang) {
+            c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "skor_kredit": skor})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
         return
     }
-    // generate nomor pinjaman setelah ID tersedia
-    p.NomorPinjaman = fmt.Sprintf("PJ-%d-%06d", time.Now().Year(), p.ID)
-    _ = h.DB.Save(&p).Error
 
     c.JSON(http.StatusCreated, p)
 }
 
-// POST /api/pinjaman/verifikasi { pinjaman_id }
-type PinjamanActionInput struct { PinjamanID uint `json:"pinjaman_id"` }
-func (h *PinjamanController) Verifikasi(c *gin.Context) {
-    var in PinjamanActionInput
-    if err := c.ShouldBindJSON(&in); err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-        return
-    }
-    var p models.Pinjaman
-    if err := h.DB.First(&p, in.PinjamanID).Error; err != nil {
-        c.JSON(http.StatusNotFound, gin.H{"error": "pinjaman tidak ditemukan"})
-        return
-    }
-    now := time.Now()
-    p.TanggalDisetujui = &now
-    p.Status = "disetujui"
-    if err := h.DB.Save(&p).Error; err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-        return
-    }
-    c.JSON(http.StatusOK, gin.H{"ok": true})
-}
-
-// POST /api/pinjaman/pencairan { pinjaman_id }
+// Pencairan menetapkan biaya dari settings.financial.biaya_pinjaman: yang dipotong mengurangi dana yang
+// diterima anggota, yang diangsur ditambahkan rata ke setiap angsuran. Dana disalurkan sesuai metode.
 func (h *PinjamanController) Pencairan(c *gin.Context) {
-    var in PinjamanActionInput
+    var in PencairanInput
     if err := c.ShouldBindJSON(&in); err != nil {
         c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
         return
     }
+    var p models.Pinjaman
     err := h.DB.Transaction(func(tx *gorm.DB) error {
-        var p models.Pinjaman
-        if err := tx.First(&p, in.PinjamanID).Error; err != nil { return err }
+        if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&p, in.PinjamanID).Error; err != nil { return err }
+        if p.Status != "disetujui" { return fmt.Errorf("%w: status %s", errPinjamanBelumDisetujui, p.Status) }
+        var a models.Anggota
+        if err := tx.First(&a, p.AnggotaID).Error; err != nil { return err }
+        if err := cekAnggotaAktif(&a); err != nil { return err }
+        if err := terapkanMetodePencairan(&p, &in); err != nil { return err }
+        if in.UserID != 0 {
+            u, err := cariPengguna(tx, in.UserID)
+            if err != nil { return err }
+            p.DicairkanOleh = &u.ID
+        }
         now := time.Now()
+        if err := cekPeriodeTerbuka(tx, now); err != nil { return err }
+        fs, err := settings.LoadFinancial(tx)
+        if err != nil { return err }
+        biaya := hitungBiayaPinjaman(fs, &p)
+        if err := terapkanBiaya(&p, biaya); err != nil { return err }
+        if p.NomorBuktiPencairan, err = nomorBerikutnya(tx, "kuitansi", now); err != nil { return err }
         p.TanggalPencairan = &now
         p.Status = "berjalan"
         if err := tx.Save(&p).Error; err != nil { return err }
+        if len(biaya) > 0 {
+            if err := tx.Create(&biaya).Error; err != nil { return err }
+        }
+        p.Biaya = biaya
+        if err := salurkanPencairan(tx, &p, biaya, now); err != nil { return err }
+        if p.MetodePencairan == "tunai" {
+            if err := catatTransaksiKasir(tx, in.UserID, &models.TransaksiKasir{
+                Jenis: "pencairan", Sumber: "pinjaman", RefID: p.ID, Arah: "keluar", Jumlah: p.PencairanBersih, NomorBukti: p.NomorBuktiPencairan,
+                Keterangan: "Pencairan pinjaman " + p.NomorPinjaman,
+            }); err != nil { return err }
+        }
+        if p.DicairkanOleh != nil {
+            if err := catatAudit(tx, *p.DicairkanOleh, "pinjaman_dicairkan", "pinjaman", p.ID, fmt.Sprintf("%s %s %.0f", p.NomorPinjaman, p.MetodePencairan, p.PencairanBersih)); err != nil { return err }
+        }
 
-        // generate schedule angsuran sederhana: (nominal + bunga_flat) / tenor
-        total := p.Nominal + (p.Nominal * p.BungaPersen / 100.0)
-        angsur := total / float64(p.TenorBulan)
+        // generate schedule angsuran sederhana: (nominal + bunga_flat + biaya diangsur) / tenor
+        angsur := angsuranBulanan(&p)
         baseDate := now
         var batch []models.Angsuran
         for i := 1; i <= p.TenorBulan; i++ {
@@ -145,8 +208,21 @@ func (h *PinjamanController) Pencairan(c *gin.Context) {
         return nil
     })
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        if errors.Is(err, gorm.ErrRecordNotFound) {
+            c.JSON(http.StatusNotFound, gin.H{"error": "pinjaman tidak ditemukan"})
+        } else if errors.Is(err, errInputPencairan) {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        } else if errors.Is(err, errAksesDitolak) {
+            c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+        } else if err == errPeriodeTertutup || errors.Is(err, errPinjamanBelumDisetujui) || errors.Is(err, errAnggotaTidakAktif) ||
+            errors.Is(err, errSesiKasirTidakAda) || errors.Is(err, errKasLaciKurang) {
+            c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+        } else if errors.Is(err, errBiayaMelebihiPinjaman) {
+            c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
         return
     }
-    c.JSON(http.StatusOK, gin.H{"ok": true})
+    c.JSON(http.StatusOK, gin.H{"ok": true, "nomor_bukti": p.NomorBuktiPencairan, "rincian": rincianPencairan(&p)})
 }
\ No newline at end of file
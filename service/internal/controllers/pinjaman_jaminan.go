@@ -0,0 +1,455 @@
+package controllers
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "slices"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+
+    "koperasi-desa/service/internal/models"
+    "koperasi-desa/service/internal/settings"
+)
+
+// rolePengelolaPinjaman adalah role yang boleh mengubah penjamin dan agunan (anggota komite kredit)
+var rolePengelolaPinjaman = []string{"admin", "bendahara", "ketua"}
+
+// statusPinjamanTerbuka adalah status pinjaman yang masih menjadi tanggungan penjamin.
+// Penjamin pinjaman lunas otomatis tidak dihitung lagi ke eksposurnya.
+var statusPinjamanTerbuka = []string{"pengajuan", "disetujui", "berjalan"}
+
+var (
+    errInputJaminan             = errors.New("data jaminan tidak valid")
+    errPinjamanBukanPengajuan   = errors.New("penjamin dan agunan hanya dapat diubah selama pinjaman berstatus pengajuan")
+    errPenjaminDiriSendiri      = errors.New("peminjam tidak dapat menjadi penjamin pinjamannya sendiri")
+    errPenjaminGanda            = errors.New("penjamin sudah terdaftar pada pinjaman ini")
+    errEksposurPenjamin         = errors.New("eksposur penjamin melebihi batas")
+    errJenisAgunan              = errors.New("jenis agunan tidak dikenal")
+    errStatusAgunan             = errors.New("perubahan status agunan tidak diperbolehkan")
+    errAgunanMasihDijaminkan    = errors.New("agunan hanya dapat dikembalikan setelah pinjaman lunas atau ditolak")
+    errPinjamanDalamPersetujuan = errors.New("penjamin dan agunan tidak dapat diubah setelah proses persetujuan dimulai")
+)
+
+// transisiAgunan adalah status penyimpanan tujuan yang boleh dicapai dari setiap status
+var transisiAgunan = map[string][]string{
+    "diajukan":     {"disimpan"},
+    "disimpan":     {"dikembalikan"},
+    "dikembalikan": {},
+}
+
+// SyaratPinjaman adalah satu syarat verifikasi pinjaman yang belum terpenuhi
+type SyaratPinjaman struct {
+    Kode  string `json:"kode"`
+    Pesan string `json:"pesan"`
+}
+
+// RingkasanJaminan merangkum penjamin dan agunan aktif satu pinjaman
+type RingkasanJaminan struct {
+    TotalPenjaminan    float64 `json:"total_penjaminan"`
+    TotalTaksiran      float64 `json:"total_taksiran"`
+    MaksPinjamanAgunan float64 `json:"maks_pinjaman_agunan"` // jumlah nilai taksiran x batas LTV per jenis
+    LTVPersen          float64 `json:"ltv_persen"`           // nominal / total taksiran; 0 jika tanpa agunan
+}
+
+// cariPinjamanPengajuan mengunci pinjaman dan memastikan jaminannya masih boleh diubah
+func cariPinjamanPengajuan(tx *gorm.DB, id string) (*models.Pinjaman, error) {
+    var p models.Pinjaman
+    if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&p, id).Error; err != nil { return nil, err }
+    if p.Status != "pengajuan" { return nil, errPinjamanBukanPengajuan }
+    // setelah ada keputusan persetujuan, jaminan yang sudah dinilai tidak boleh berubah
+    var diputus int64
+    if err := tx.Model(&models.KeputusanPersetujuan{}).Where("pinjaman_id = ?", p.ID).Count(&diputus).Error; err != nil { return nil, err }
+    if diputus > 0 { return nil, errPinjamanDalamPersetujuan }
+    return &p, nil
+}
+
+// eksposurPenjamin menjumlahkan nilai penjaminan aktif seseorang pada pinjaman yang belum lunas.
+// Penjamin dikenali dari anggota_id atau NIK, sehingga orang luar yang menjamin beberapa pinjaman tetap terhitung.
+func eksposurPenjamin(tx *gorm.DB, pj *models.Penjamin) (float64, int, error) {
+    terbuka := tx.Model(&models.Pinjaman{}).Select("id").Where("status IN ?", statusPinjamanTerbuka)
+    q := tx.Model(&models.Penjamin{}).Where("status = ? AND pinjaman_id IN (?) AND id <> ?", "aktif", terbuka, pj.ID)
+    switch {
+    case pj.AnggotaID != nil && pj.NIK != "":
+        q = q.Where("(anggota_id = ? OR nik = ?)", *pj.AnggotaID, pj.NIK)
+    case pj.AnggotaID != nil:
+        q = q.Where("anggota_id = ?", *pj.AnggotaID)
+    default:
+        q = q.Where("nik = ?", pj.NIK)
+    }
+    var r struct {
+        Total  float64
+        Jumlah int
+    }
+    if err := q.Select("COALESCE(SUM(nilai_penjaminan), 0) AS total, COUNT(*) AS jumlah").Scan(&r).Error; err != nil { return 0, 0, err }
+    return r.Total, r.Jumlah, nil
+}
+
+// cekEksposurPenjamin memeriksa batas settings.pinjaman jika pj ditambahkan ke eksposurnya saat ini
+func cekEksposurPenjamin(tx *gorm.DB, pj *models.Penjamin, cfg settings.Pinjaman) error {
+    total, jumlah, err := eksposurPenjamin(tx, pj)
+    if err != nil { return err }
+    if cfg.MaksEksposurPenjamin > 0 && total+pj.NilaiPenjaminan > cfg.MaksEksposurPenjamin {
+        return fmt.Errorf("%w: %s sudah menjamin %.0f, ditambah %.0f melebihi %.0f", errEksposurPenjamin, pj.Nama, total, pj.NilaiPenjaminan, cfg.MaksEksposurPenjamin)
+    }
+    if cfg.MaksPinjamanDijamin > 0 && jumlah+1 > cfg.MaksPinjamanDijamin {
+        return fmt.Errorf("%w: %s sudah menjamin %d pinjaman, maksimal %d", errEksposurPenjamin, pj.Nama, jumlah, cfg.MaksPinjamanDijamin)
+    }
+    return nil
+}
+
+func ringkasJaminan(p *models.Pinjaman, penjamin []models.Penjamin, agunan []models.Agunan, cfg settings.Pinjaman) RingkasanJaminan {
+    var r RingkasanJaminan
+    for _, pj := range penjamin {
+        if pj.Status == "aktif" { r.TotalPenjaminan += pj.NilaiPenjaminan }
+    }
+    for _, a := range agunan {
+        if a.StatusPenyimpanan == "dikembalikan" { continue }
+        r.TotalTaksiran += a.NilaiTaksiran
+        r.MaksPinjamanAgunan += a.NilaiTaksiran * cfg.MaksLTV(a.Jenis) / 100
+    }
+    if r.TotalTaksiran > 0 { r.LTVPersen = p.Nominal / r.TotalTaksiran * 100 }
+    return r
+}
+
+// cekSyaratVerifikasiPinjaman mengembalikan syarat settings.pinjaman yang belum terpenuhi: dokumen wajib,
+// penjamin dan agunan untuk nominal di atas batas, eksposur tiap penjamin, dan loan-to-value agunan
+func cekSyaratVerifikasiPinjaman(tx *gorm.DB, p *models.Pinjaman) ([]SyaratPinjaman, error) {
+    cfg, err := settings.LoadPinjaman(tx)
+    if err != nil { return nil, err }
+    unmet := []SyaratPinjaman{}
+
+    if cfg.Analisis.Wajib {
+        var n int64
+        if err := tx.Model(&models.AnalisisKredit{}).Where("pinjaman_id = ?", p.ID).Count(&n).Error; err != nil { return nil, err }
+        if n == 0 { unmet = append(unmet, SyaratPinjaman{Kode: "analisis", Pesan: "analisis kredit belum dibuat"}) }
+    }
+
+    if len(cfg.DokumenWajib) > 0 {
+        var docs []models.AnggotaDocument
+        if err := dokumenBerlaku(tx).Where("pinjaman_id = ? AND status_verifikasi <> ?", p.ID, "ditolak").Find(&docs).Error; err != nil { return nil, err }
+        for _, jenis := range cfg.DokumenWajib {
+            if !slices.ContainsFunc(docs, func(d models.AnggotaDocument) bool { return d.Jenis == jenis }) {
+                unmet = append(unmet, SyaratPinjaman{Kode: "dokumen_" + jenis, Pesan: "dokumen " + jenis + " belum diunggah"})
+            }
+        }
+    }
+
+    var penjamin []models.Penjamin
+    if err := tx.Where("pinjaman_id = ? AND status = ?", p.ID, "aktif").Find(&penjamin).Error; err != nil { return nil, err }
+    if cfg.WajibPenjaminMulai > 0 && p.Nominal >= cfg.WajibPenjaminMulai && len(penjamin) == 0 {
+        unmet = append(unmet, SyaratPinjaman{Kode: "penjamin", Pesan: fmt.Sprintf("pinjaman mulai %.0f wajib memiliki penjamin", cfg.WajibPenjaminMulai)})
+    }
+    // batas eksposur diperiksa ulang karena penjamin bisa saja menjamin pinjaman lain setelah didaftarkan
+    for i := range penjamin {
+        if err := cekEksposurPenjamin(tx, &penjamin[i], cfg); err != nil {
+            if !errors.Is(err, errEksposurPenjamin) { return nil, err }
+            unmet = append(unmet, SyaratPinjaman{Kode: "eksposur_penjamin", Pesan: err.Error()})
+        }
+    }
+
+    if cfg.WajibAgunanMulai > 0 && p.Nominal >= cfg.WajibAgunanMulai {
+        var agunan []models.Agunan
+        if err := tx.Where("pinjaman_id = ? AND status_penyimpanan <> ?", p.ID, "dikembalikan").Find(&agunan).Error; err != nil { return nil, err }
+        r := ringkasJaminan(p, nil, agunan, cfg)
+        switch {
+        case len(agunan) == 0:
+            unmet = append(unmet, SyaratPinjaman{Kode: "agunan", Pesan: fmt.Sprintf("pinjaman mulai %.0f wajib memiliki agunan", cfg.WajibAgunanMulai)})
+        case p.Nominal > r.MaksPinjamanAgunan:
+            unmet = append(unmet, SyaratPinjaman{Kode: "ltv", Pesan: fmt.Sprintf("LTV %.1f%% melebihi batas; nilai agunan hanya cukup untuk pinjaman %.0f", r.LTVPersen, r.MaksPinjamanAgunan)})
+        }
+    }
+    return unmet, nil
+}
+
+// POST /api/pinjaman/:id/documents
+// multipart: files[], jenis, user_id — sama seperti dokumen anggota; dokumen tercatat atas nama peminjam.
+// Ganti, hapus, verifikasi dan unduh memakai endpoint /api/anggota/:anggota_id/documents/:docId/...
+func (h *PinjamanController) UploadDokumen(c *gin.Context) {
+    var p models.Pinjaman
+    if err := h.DB.First(&p, c.Param("id")).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "pinjaman tidak ditemukan"})
+        return
+    }
+    unggahDokumen(c, h.DB, h.Storage, p.AnggotaID, &p.ID)
+}
+
+// GET /api/pinjaman/:id/documents?user_id=...&riwayat=true
+func (h *PinjamanController) ListDokumen(c *gin.Context) {
+    daftarDokumen(c, h.DB, h.DB.Where("pinjaman_id = ?", c.Param("id")))
+}
+
+// GET /api/pinjaman/:id/jaminan
+// Penjamin, agunan dan ringkasan LTV satu pinjaman
+func (h *PinjamanController) Jaminan(c *gin.Context) {
+    var p models.Pinjaman
+    if err := h.DB.First(&p, c.Param("id")).Error; err != nil {
+        h.jaminanError(c, err)
+        return
+    }
+    cfg, err := settings.LoadPinjaman(h.DB)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    var penjamin []models.Penjamin
+    var agunan []models.Agunan
+    if err := h.DB.Where("pinjaman_id = ?", p.ID).Order("id").Find(&penjamin).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    if err := h.DB.Where("pinjaman_id = ?", p.ID).Order("id").Find(&agunan).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"penjamin": penjamin, "agunan": agunan, "ringkasan": ringkasJaminan(&p, penjamin, agunan, cfg)})
+}
+
+// POST /api/pinjaman/:id/penjamin
+// { anggota_id? | nama, nik, hubungan, alamat, telp, penghasilan, nilai_penjaminan?, user_id }
+// Penjamin anggota cukup anggota_id; data diri disalin dari data anggota. nilai_penjaminan bawaan = nominal pinjaman
+type PenjaminInput struct {
+    AnggotaID       *uint   `json:"anggota_id"`
+    Nama            string  `json:"nama"`
+    NIK             string  `json:"nik"`
+    Hubungan        string  `json:"hubungan"`
+    Alamat          string  `json:"alamat"`
+    Telp            string  `json:"telp"`
+    Penghasilan     float64 `json:"penghasilan"`
+    NilaiPenjaminan float64 `json:"nilai_penjaminan"`
+    UserID          uint    `json:"user_id" binding:"required"`
+}
+
+func (h *PinjamanController) TambahPenjamin(c *gin.Context) {
+    var in PenjaminInput
+    if err := c.ShouldBindJSON(&in); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    pj := models.Penjamin{
+        AnggotaID:       in.AnggotaID,
+        Nama:            strings.TrimSpace(in.Nama),
+        NIK:             strings.TrimSpace(in.NIK),
+        Hubungan:        strings.TrimSpace(in.Hubungan),
+        Alamat:          strings.TrimSpace(in.Alamat),
+        Telp:            strings.TrimSpace(in.Telp),
+        Penghasilan:     in.Penghasilan,
+        NilaiPenjaminan: in.NilaiPenjaminan,
+        Status:          "aktif",
+    }
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        u, err := cariPengguna(tx, in.UserID, rolePengelolaPinjaman...)
+        if err != nil { return err }
+        p, err := cariPinjamanPengajuan(tx, c.Param("id"))
+        if err != nil { return err }
+        pj.PinjamanID = p.ID
+        if pj.AnggotaID != nil {
+            var a models.Anggota
+            if err := tx.First(&a, *pj.AnggotaID).Error; err != nil { return fmt.Errorf("%w: anggota penjamin tidak ditemukan", errInputJaminan) }
+            if a.ID == p.AnggotaID { return errPenjaminDiriSendiri }
+            if err := cekAnggotaAktif(&a); err != nil { return fmt.Errorf("%w: penjamin %s", err, a.Nama) }
+            pj.Nama, pj.NIK = a.Nama, a.NIK
+            if pj.Alamat == "" { pj.Alamat = a.Alamat }
+            if pj.Telp == "" { pj.Telp = a.Telp }
+        } else {
+            if pj.Nama == "" { return fmt.Errorf("%w: nama penjamin wajib diisi", errInputJaminan) }
+            if _, err := validasiNIK(pj.NIK); err != nil { return fmt.Errorf("%w: %v", errInputJaminan, err) }
+            var peminjam models.Anggota
+            if err := tx.First(&peminjam, p.AnggotaID).Error; err != nil { return err }
+            if peminjam.NIK == pj.NIK { return errPenjaminDiriSendiri }
+        }
+        if pj.NilaiPenjaminan == 0 { pj.NilaiPenjaminan = p.Nominal }
+        if pj.NilaiPenjaminan < 0 || pj.NilaiPenjaminan > p.Nominal {
+            return fmt.Errorf("%w: nilai_penjaminan harus antara 0 dan nominal pinjaman", errInputJaminan)
+        }
+
+        var ada int64
+        if err := tx.Model(&models.Penjamin{}).Where("pinjaman_id = ? AND status = ? AND nik = ?", p.ID, "aktif", pj.NIK).Count(&ada).Error; err != nil { return err }
+        if ada > 0 { return errPenjaminGanda }
+        cfg, err := settings.LoadPinjaman(tx)
+        if err != nil { return err }
+        if err := cekEksposurPenjamin(tx, &pj, cfg); err != nil { return err }
+
+        if err := tx.Create(&pj).Error; err != nil { return err }
+        return catatAudit(tx, u.ID, "penjamin_ditambah", "pinjaman", p.ID, fmt.Sprintf("%s (%s) %.0f", pj.Nama, pj.NIK, pj.NilaiPenjaminan))
+    })
+    if err != nil {
+        h.jaminanError(c, err)
+        return
+    }
+    c.JSON(http.StatusCreated, pj)
+}
+
+// DELETE /api/pinjaman/:id/penjamin/:penjaminId { user_id, alasan }
+// Melepas penjamin selama pinjaman masih pengajuan; datanya tetap disimpan
+func (h *PinjamanController) LepasPenjamin(c *gin.Context) {
+    var in struct {
+        UserID uint   `json:"user_id" binding:"required"`
+        Alasan string `json:"alasan"`
+    }
+    if err := c.ShouldBindJSON(&in); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        u, err := cariPengguna(tx, in.UserID, rolePengelolaPinjaman...)
+        if err != nil { return err }
+        p, err := cariPinjamanPengajuan(tx, c.Param("id"))
+        if err != nil { return err }
+        var pj models.Penjamin
+        if err := tx.Where("id = ? AND pinjaman_id = ? AND status = ?", c.Param("penjaminId"), p.ID, "aktif").First(&pj).Error; err != nil { return err }
+        now := time.Now()
+        if err := tx.Model(&pj).Updates(map[string]interface{}{"status": "dilepas", "dilepas_at": now}).Error; err != nil { return err }
+        return catatAudit(tx, u.ID, "penjamin_dilepas", "pinjaman", p.ID, fmt.Sprintf("%s: %s", pj.Nama, in.Alasan))
+    })
+    if err != nil {
+        h.jaminanError(c, err)
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+// POST /api/pinjaman/:id/agunan
+// { jenis, deskripsi, nomor_bukti, atas_nama, nilai_taksiran, tanggal_taksiran?, user_id }
+type AgunanInput struct {
+    Jenis           string     `json:"jenis" binding:"required"`
+    Deskripsi       string     `json:"deskripsi"`
+    NomorBukti      string     `json:"nomor_bukti"`
+    AtasNama        string     `json:"atas_nama"`
+    NilaiTaksiran   float64    `json:"nilai_taksiran"`
+    TanggalTaksiran *time.Time `json:"tanggal_taksiran"`
+    UserID          uint       `json:"user_id" binding:"required"`
+}
+
+func (h *PinjamanController) TambahAgunan(c *gin.Context) {
+    var in AgunanInput
+    if err := c.ShouldBindJSON(&in); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    ag := models.Agunan{
+        Jenis:             strings.ToLower(strings.TrimSpace(in.Jenis)),
+        Deskripsi:         strings.TrimSpace(in.Deskripsi),
+        NomorBukti:        strings.TrimSpace(in.NomorBukti),
+        AtasNama:          strings.TrimSpace(in.AtasNama),
+        NilaiTaksiran:     in.NilaiTaksiran,
+        TanggalTaksiran:   time.Now(),
+        StatusPenyimpanan: "diajukan",
+    }
+    if in.TanggalTaksiran != nil { ag.TanggalTaksiran = *in.TanggalTaksiran }
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        if !slices.Contains(models.JenisAgunan, ag.Jenis) { return fmt.Errorf("%w: %s", errJenisAgunan, ag.Jenis) }
+        if ag.NilaiTaksiran <= 0 { return fmt.Errorf("%w: nilai_taksiran harus lebih dari 0", errInputJaminan) }
+        u, err := cariPengguna(tx, in.UserID, rolePengelolaPinjaman...)
+        if err != nil { return err }
+        p, err := cariPinjamanPengajuan(tx, c.Param("id"))
+        if err != nil { return err }
+        ag.PinjamanID = p.ID
+        if err := tx.Create(&ag).Error; err != nil { return err }
+        return catatAudit(tx, u.ID, "agunan_ditambah", "pinjaman", p.ID, fmt.Sprintf("%s %s taksiran %.0f", ag.Jenis, ag.NomorBukti, ag.NilaiTaksiran))
+    })
+    if err != nil {
+        h.jaminanError(c, err)
+        return
+    }
+    c.JSON(http.StatusCreated, ag)
+}
+
+// DELETE /api/pinjaman/:id/agunan/:agunanId { user_id, alasan }
+// Hanya agunan yang belum diserahkan (diajukan) pada pinjaman pengajuan
+func (h *PinjamanController) HapusAgunan(c *gin.Context) {
+    var in struct {
+        UserID uint   `json:"user_id" binding:"required"`
+        Alasan string `json:"alasan"`
+    }
+    if err := c.ShouldBindJSON(&in); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        u, err := cariPengguna(tx, in.UserID, rolePengelolaPinjaman...)
+        if err != nil { return err }
+        p, err := cariPinjamanPengajuan(tx, c.Param("id"))
+        if err != nil { return err }
+        var ag models.Agunan
+        if err := tx.Where("id = ? AND pinjaman_id = ?", c.Param("agunanId"), p.ID).First(&ag).Error; err != nil { return err }
+        if ag.StatusPenyimpanan != "diajukan" { return fmt.Errorf("%w: agunan sudah %s, kembalikan dulu", errStatusAgunan, ag.StatusPenyimpanan) }
+        if err := tx.Delete(&ag).Error; err != nil { return err }
+        return catatAudit(tx, u.ID, "agunan_dihapus", "pinjaman", p.ID, fmt.Sprintf("%s %s: %s", ag.Jenis, ag.NomorBukti, in.Alasan))
+    })
+    if err != nil {
+        h.jaminanError(c, err)
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+// POST /api/pinjaman/:id/agunan/:agunanId/status { status: disimpan|dikembalikan, lokasi, catatan, user_id }
+// disimpan: fisik/dokumen asli diterima koperasi (lokasi wajib). dikembalikan: hanya setelah pinjaman lunas
+// atau ditolak, atau selama pinjaman masih pengajuan (pengajuan batal/agunan diganti)
+func (h *PinjamanController) StatusAgunan(c *gin.Context) {
+    var in struct {
+        Status  string `json:"status" binding:"required"`
+        Lokasi  string `json:"lokasi"`
+        Catatan string `json:"catatan"`
+        UserID  uint   `json:"user_id" binding:"required"`
+    }
+    if err := c.ShouldBindJSON(&in); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    in.Status = strings.ToLower(strings.TrimSpace(in.Status))
+    var ag models.Agunan
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        u, err := cariPengguna(tx, in.UserID, rolePengelolaPinjaman...)
+        if err != nil { return err }
+        var p models.Pinjaman
+        if err := tx.First(&p, c.Param("id")).Error; err != nil { return err }
+        if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ? AND pinjaman_id = ?", c.Param("agunanId"), p.ID).First(&ag).Error; err != nil { return err }
+        if !slices.Contains(transisiAgunan[ag.StatusPenyimpanan], in.Status) {
+            return fmt.Errorf("%w: %s ke %s", errStatusAgunan, ag.StatusPenyimpanan, in.Status)
+        }
+        now := time.Now()
+        switch in.Status {
+        case "disimpan":
+            if strings.TrimSpace(in.Lokasi) == "" { return fmt.Errorf("%w: lokasi penyimpanan wajib diisi", errInputJaminan) }
+            ag.LokasiPenyimpanan = strings.TrimSpace(in.Lokasi)
+            ag.DiterimaOleh, ag.DiterimaAt = &u.ID, &now
+        case "dikembalikan":
+            if p.Status != "lunas" && p.Status != "pengajuan" && p.Status != "ditolak" { return errAgunanMasihDijaminkan }
+            ag.DikembalikanOleh, ag.DikembalikanAt = &u.ID, &now
+        }
+        ag.StatusPenyimpanan = in.Status
+        if err := tx.Save(&ag).Error; err != nil { return err }
+        note := fmt.Sprintf("%s %s", ag.Jenis, ag.NomorBukti)
+        if in.Catatan != "" { note += ": " + in.Catatan }
+        return catatAudit(tx, u.ID, "agunan_"+in.Status, "pinjaman", p.ID, note)
+    })
+    if err != nil {
+        h.jaminanError(c, err)
+        return
+    }
+    c.JSON(http.StatusOK, ag)
+}
+
+func (h *PinjamanController) jaminanError(c *gin.Context, err error) {
+    switch {
+    case errors.Is(err, gorm.ErrRecordNotFound):
+        c.JSON(http.StatusNotFound, gin.H{"error": "data tidak ditemukan"})
+    case errors.Is(err, errAksesDitolak):
+        c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+    case errors.Is(err, errInputJaminan), errors.Is(err, errJenisAgunan), errors.Is(err, errPenjaminDiriSendiri):
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    case errors.Is(err, errEksposurPenjamin):
+        c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+    case errors.Is(err, errPinjamanBukanPengajuan), errors.Is(err, errPinjamanDalamPersetujuan), errors.Is(err, errPenjaminGanda), errors.Is(err, errStatusAgunan),
+        errors.Is(err, errAgunanMasihDijaminkan), errors.Is(err, errAnggotaTidakAktif):
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+    default:
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+    }
+}
@@ -0,0 +1,84 @@
+package controllers
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+    "time"
+
+    "gorm.io/gorm"
+
+    "koperasi-desa/service/internal/models"
+)
+
+var errInputPencairan = errors.New("input pencairan tidak valid")
+
+// POST /api/pinjaman/pencairan
+// { pinjaman_id, user_id?, metode: tunai|sukarela|transfer, bank?, nomor_rekening?, atas_nama?, referensi? }
+// Metode kosong berarti tunai. Transfer wajib menyebut bank, nomor rekening dan referensi transfer.
+type PencairanInput struct {
+    PinjamanID    uint   `json:"pinjaman_id"`
+    UserID        uint   `json:"user_id"`
+    Metode        string `json:"metode"`
+    Bank          string `json:"bank"`
+    NomorRekening string `json:"nomor_rekening"`
+    AtasNama      string `json:"atas_nama"`
+    Referensi     string `json:"referensi"`
+}
+
+// terapkanMetodePencairan memvalidasi metode pencairan dan menyalinnya ke pinjaman
+func terapkanMetodePencairan(p *models.Pinjaman, in *PencairanInput) error {
+    metode := strings.ToLower(strings.TrimSpace(in.Metode))
+    if metode == "" { metode = "tunai" }
+    p.MetodePencairan = metode
+    switch metode {
+    case "tunai", "sukarela":
+        return nil
+    case "transfer":
+        p.BankTujuan = strings.TrimSpace(in.Bank)
+        p.RekeningTujuan = strings.TrimSpace(in.NomorRekening)
+        p.AtasNamaRekening = strings.TrimSpace(in.AtasNama)
+        p.ReferensiTransfer = strings.TrimSpace(in.Referensi)
+        if p.BankTujuan == "" || p.RekeningTujuan == "" || p.ReferensiTransfer == "" {
+            return fmt.Errorf("%w: transfer wajib mengisi bank, nomor_rekening dan referensi", errInputPencairan)
+        }
+        return nil
+    }
+    return fmt.Errorf("%w: metode harus tunai/sukarela/transfer", errInputPencairan)
+}
+
+// salurkanPencairan memposting pencairan sesuai metodenya. Piutang didebit sebesar nominal + biaya
+// diangsur dan setiap biaya dikreditkan ke akunnya. Dana bersih mengkredit Kas (tunai) atau Bank
+// (transfer); untuk metode sukarela dana bersih dicatat sebagai setoran simpanan sukarela yang
+// jurnalnya (Piutang Pinjaman / Simpanan Sukarela) dibuat oleh catatSimpanan.
+func salurkanPencairan(tx *gorm.DB, p *models.Pinjaman, biaya []models.BiayaPinjaman, tanggal time.Time) error {
+    piutang := p.Nominal + p.BiayaDiangsur
+    var dana []barisJurnal
+    switch p.MetodePencairan {
+    case "sukarela":
+        var a models.Anggota
+        if err := tx.First(&a, p.AnggotaID).Error; err != nil { return err }
+        if err := cekBolehSetor(&a, "sukarela"); err != nil { return err }
+        setor := models.Simpanan{
+            AnggotaID:  p.AnggotaID,
+            Jenis:      "sukarela",
+            Tipe:       "setoran",
+            Tanggal:    tanggal,
+            Jumlah:     p.PencairanBersih,
+            Keterangan: "Pencairan pinjaman " + p.NomorPinjaman,
+            NomorBukti: p.NomorBuktiPencairan,
+            Sumber:     "pencairan",
+        }
+        if err := catatSimpanan(tx, &setor, models.AkunPiutangPinjaman); err != nil { return err }
+        piutang -= p.PencairanBersih
+    case "transfer":
+        dana = append(dana, kredit(models.AkunBank, p.PencairanBersih))
+    default:
+        dana = append(dana, kredit(models.AkunKas, p.PencairanBersih))
+    }
+
+    lines := append([]barisJurnal{debit(models.AkunPiutangPinjaman, piutang)}, dana...)
+    for _, b := range biaya { lines = append(lines, kredit(b.AkunKode, b.Jumlah)) }
+    _, err := postJurnal(tx, tanggal, "pinjaman", p.ID, "Pencairan pinjaman "+p.NomorPinjaman+" ("+p.MetodePencairan+")", lines...)
+    return err
+}
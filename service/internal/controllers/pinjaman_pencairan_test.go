@@ -0,0 +1,68 @@
+package controllers
+
+import (
+    "math"
+    "testing"
+    "time"
+
+    "gorm.io/gorm"
+
+    "koperasi-desa/service/internal/models"
+)
+
+func TestSalurkanPencairanJurnal(t *testing.T) {
+    tanggal := time.Date(2026, 10, 5, 10, 0, 0, 0, time.Local)
+    cases := []struct {
+        metode string
+        dana   string // akun yang dikredit sebesar pencairan bersih
+    }{
+        {"tunai", models.AkunKas},
+        {"transfer", models.AkunBank},
+        {"sukarela", models.AkunSimpananSukarela},
+    }
+    for _, c := range cases {
+        t.Run(c.metode, func(t *testing.T) {
+            db := dbUji(t)
+            a := models.Anggota{NomorAnggota: "AG-1", Nama: "Uji", NIK: "3201010107900001", Status: "active", TanggalGabung: tanggal.AddDate(-1, 0, 0)}
+            if err := db.Create(&a).Error; err != nil { t.Fatal(err) }
+            p := models.Pinjaman{AnggotaID: a.ID, NomorPinjaman: "PJ-1", Nominal: 5_000_000, TenorBulan: 10, Status: "disetujui", MetodePencairan: c.metode, NomorBuktiPencairan: "KW-1"}
+            if err := db.Create(&p).Error; err != nil { t.Fatal(err) }
+            biaya := []models.BiayaPinjaman{
+                {Jenis: "administrasi", Jumlah: 50_000, Pembebanan: "potong", AkunKode: models.AkunPendapatanAdmin},
+                {Jenis: "asuransi", Jumlah: 30_000, Pembebanan: "angsuran", AkunKode: models.AkunUtangPremi},
+            }
+            if err := terapkanBiaya(&p, biaya); err != nil { t.Fatal(err) }
+            if err := db.Transaction(func(tx *gorm.DB) error { return salurkanPencairan(tx, &p, biaya, tanggal) }); err != nil { t.Fatal(err) }
+
+            var jurnal []models.Jurnal
+            if err := db.Preload("Details").Find(&jurnal).Error; err != nil { t.Fatal(err) }
+            saldo := map[string]float64{}
+            for _, j := range jurnal {
+                var d, k float64
+                for _, l := range j.Details {
+                    d += l.Debit
+                    k += l.Kredit
+                    saldo[l.AkunKode] += l.Debit - l.Kredit
+                }
+                if math.Abs(d-k) > 0.005 { t.Errorf("jurnal %s #%d tidak seimbang: debit %v kredit %v", j.Sumber, j.RefID, d, k) }
+            }
+            ingin := map[string]float64{
+                models.AkunPiutangPinjaman: 5_030_000,
+                c.dana:                     -4_950_000,
+                models.AkunPendapatanAdmin: -50_000,
+                models.AkunUtangPremi:      -30_000,
+            }
+            for akun, v := range ingin {
+                if math.Abs(saldo[akun]-v) > 0.005 { t.Errorf("saldo %s = %v, ingin %v", akun, saldo[akun], v) }
+            }
+            for akun, v := range saldo {
+                if _, ok := ingin[akun]; !ok && math.Abs(v) > 0.005 { t.Errorf("akun %s tidak diharapkan bersaldo %v", akun, v) }
+            }
+            if c.metode == "sukarela" {
+                s, err := saldoPada(db, a.ID, "sukarela", tanggal)
+                if err != nil { t.Fatal(err) }
+                if s != 4_950_000 { t.Errorf("saldo sukarela = %v, ingin 4950000", s) }
+            }
+        })
+    }
+}
@@ -0,0 +1,274 @@
+package controllers
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "slices"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+
+    "koperasi-desa/service/internal/models"
+    "koperasi-desa/service/internal/settings"
+)
+
+var (
+    errInputPersetujuan      = errors.New("input persetujuan tidak valid")
+    errPinjamanTidakMenunggu = errors.New("pinjaman tidak sedang menunggu persetujuan")
+    errPenyetujuPengaju      = errors.New("penyetuju harus berbeda dengan pengaju pinjaman")
+    errPenyetujuGanda        = errors.New("pengguna sudah memberi keputusan pada pinjaman ini")
+)
+
+// rantaiPersetujuan memuat tahap persetujuan pinjaman berurutan. Pinjaman yang belum memiliki tahap
+// (diajukan sebelum fitur ini ada) dibuatkan rantai dari settings.pinjaman saat ini.
+func rantaiPersetujuan(tx *gorm.DB, p *models.Pinjaman) ([]models.TahapPersetujuan, error) {
+    var tahap []models.TahapPersetujuan
+    if err := tx.Preload("Keputusan", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+        Where("pinjaman_id = ?", p.ID).Order("urutan ASC").Find(&tahap).Error; err != nil {
+        return nil, err
+    }
+    if len(tahap) > 0 || p.Status != "pengajuan" { return tahap, nil }
+    cfg, err := settings.LoadPinjaman(tx)
+    if err != nil { return nil, err }
+    for i, a := range cfg.RantaiPersetujuan(p.Nominal, p.Kategori) {
+        tahap = append(tahap, models.TahapPersetujuan{
+            PinjamanID:       p.ID,
+            Urutan:           i + 1,
+            Nama:             a.Nama,
+            Roles:            strings.Join(a.Roles, ","),
+            MinimalPenyetuju: a.MinimalPenyetuju,
+            Status:           "menunggu",
+        })
+    }
+    if len(tahap) == 0 { return nil, nil }
+    if err := tx.Create(&tahap).Error; err != nil { return nil, err }
+    return tahap, nil
+}
+
+// tahapAktif mengembalikan tahap pertama yang masih menunggu; nil jika tidak ada
+func tahapAktif(tahap []models.TahapPersetujuan) *models.TahapPersetujuan {
+    for i := range tahap {
+        if tahap[i].Status == "menunggu" { return &tahap[i] }
+    }
+    return nil
+}
+
+func bolehMemutus(t *models.TahapPersetujuan, role string) bool {
+    return slices.Contains(strings.Split(t.Roles, ","), strings.ToLower(strings.TrimSpace(role)))
+}
+
+// putuskanPinjaman mencatat keputusan user pada tahap aktif pinjaman. Setiap tahap dan setiap pengguna
+// hanya memberi satu keputusan per pinjaman, dan pengaju tidak boleh menyetujui pinjamannya sendiri
+// (maker-checker). Syarat verifikasi diperiksa ulang setiap kali ada persetujuan; unmet berisi syarat
+// yang belum terpenuhi dan keputusan tidak dicatat. Pinjaman menjadi disetujui setelah tahap terakhir
+// selesai, atau langsung jika rantai persetujuan kosong. Satu penolakan menolak pinjaman.
+func putuskanPinjaman(tx *gorm.DB, pinjamanID uint, userID uint, setuju bool, catatan string) (*models.Pinjaman, []SyaratPinjaman, error) {
+    catatan = strings.TrimSpace(catatan)
+    if !setuju && catatan == "" { return nil, nil, fmt.Errorf("%w: catatan penolakan wajib diisi", errInputPersetujuan) }
+    u, err := cariPengguna(tx, userID)
+    if err != nil { return nil, nil, err }
+
+    var p models.Pinjaman
+    if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&p, pinjamanID).Error; err != nil { return nil, nil, err }
+    if p.Status != "pengajuan" { return nil, nil, fmt.Errorf("%w: status %s", errPinjamanTidakMenunggu, p.Status) }
+    if p.DiajukanOleh != nil && *p.DiajukanOleh == u.ID { return nil, nil, errPenyetujuPengaju }
+
+    tahap, err := rantaiPersetujuan(tx, &p)
+    if err != nil { return nil, nil, err }
+    aktif := tahapAktif(tahap)
+    if len(tahap) > 0 && aktif == nil { return nil, nil, errPinjamanTidakMenunggu }
+    if aktif != nil && !bolehMemutus(aktif, u.Role) {
+        return nil, nil, fmt.Errorf("%w: tahap %s memerlukan role %s", errAksesDitolak, aktif.Nama, strings.ReplaceAll(aktif.Roles, ",", "/"))
+    }
+    if len(tahap) == 0 && !slices.Contains(rolePengelolaPinjaman, strings.ToLower(strings.TrimSpace(u.Role))) {
+        return nil, nil, errAksesDitolak
+    }
+    var sudah int64
+    if err := tx.Model(&models.KeputusanPersetujuan{}).Where("pinjaman_id = ? AND user_id = ?", p.ID, u.ID).Count(&sudah).Error; err != nil {
+        return nil, nil, err
+    }
+    if sudah > 0 { return nil, nil, errPenyetujuGanda }
+
+    if setuju {
+        unmet, err := cekSyaratVerifikasiPinjaman(tx, &p)
+        if err != nil || len(unmet) > 0 { return nil, unmet, err }
+    }
+
+    now := time.Now()
+    namaTahap := "verifikasi"
+    if aktif != nil {
+        namaTahap = aktif.Nama
+        k := models.KeputusanPersetujuan{PinjamanID: p.ID, TahapID: aktif.ID, UserID: u.ID, Role: u.Role, Keputusan: "setuju", Catatan: catatan}
+        if !setuju { k.Keputusan = "tolak" }
+        if err := tx.Create(&k).Error; err != nil { return nil, nil, err }
+        aktif.Keputusan = append(aktif.Keputusan, k)
+
+        setujuTahap := 0
+        for _, kp := range aktif.Keputusan {
+            if kp.Keputusan == "setuju" { setujuTahap++ }
+        }
+        switch {
+        case !setuju:
+            aktif.Status, aktif.SelesaiAt = "ditolak", &now
+        case setujuTahap >= aktif.MinimalPenyetuju:
+            aktif.Status, aktif.SelesaiAt = "disetujui", &now
+        }
+        if err := tx.Model(aktif).Updates(map[string]any{"status": aktif.Status, "selesai_at": aktif.SelesaiAt}).Error; err != nil { return nil, nil, err }
+    }
+
+    switch {
+    case !setuju:
+        if err := tx.Model(&models.TahapPersetujuan{}).Where("pinjaman_id = ? AND status = ?", p.ID, "menunggu").
+            Updates(map[string]any{"status": "dibatalkan", "selesai_at": now}).Error; err != nil {
+            return nil, nil, err
+        }
+        p.Status, p.TanggalDitolak = "ditolak", &now
+        if err := tx.Save(&p).Error; err != nil { return nil, nil, err }
+    case tahapAktif(tahap) == nil:
+        p.Status, p.TanggalDisetujui = "disetujui", &now
+        if err := tx.Save(&p).Error; err != nil { return nil, nil, err }
+    }
+
+    aksi := "persetujuan_setuju"
+    if !setuju { aksi = "persetujuan_tolak" }
+    note := "tahap " + namaTahap
+    if catatan != "" { note += ": " + catatan }
+    if err := catatAudit(tx, u.ID, aksi, "pinjaman", p.ID, note); err != nil { return nil, nil, err }
+    p.Persetujuan = tahap
+    var a models.AnalisisKredit
+    if err := tx.Where("pinjaman_id = ?", p.ID).Limit(1).Find(&a).Error; err != nil { return nil, nil, err }
+    if a.ID != 0 { p.Analisis = &a }
+    return &p, nil, nil
+}
+
+// POST /api/pinjaman/verifikasi { pinjaman_id, user_id, catatan }
+// POST /api/pinjaman/:id/setujui { user_id, catatan }
+// POST /api/pinjaman/:id/tolak { user_id, catatan }
+// Setujui/verifikasi memberi persetujuan pada tahap aktif; ditolak (422, daftar unmet) bila syarat
+// settings.pinjaman belum terpenuhi: dokumen wajib, penjamin, eksposur penjamin, agunan dan loan-to-value
+type PinjamanActionInput struct {
+    PinjamanID uint   `json:"pinjaman_id"`
+    UserID     uint   `json:"user_id"`
+    Catatan    string `json:"catatan"`
+}
+
+func (h *PinjamanController) Verifikasi(c *gin.Context) {
+    var in PinjamanActionInput
+    if err := c.ShouldBindJSON(&in); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    h.putuskan(c, in, true)
+}
+
+func (h *PinjamanController) Setujui(c *gin.Context) { h.putuskanParam(c, true) }
+func (h *PinjamanController) Tolak(c *gin.Context) { h.putuskanParam(c, false) }
+
+func (h *PinjamanController) putuskanParam(c *gin.Context, setuju bool) {
+    var in PinjamanActionInput
+    if err := c.ShouldBindJSON(&in); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "id tidak valid"})
+        return
+    }
+    in.PinjamanID = uint(id)
+    h.putuskan(c, in, setuju)
+}
+
+func (h *PinjamanController) putuskan(c *gin.Context, in PinjamanActionInput, setuju bool) {
+    var p *models.Pinjaman
+    var unmet []SyaratPinjaman
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        var err error
+        p, unmet, err = putuskanPinjaman(tx, in.PinjamanID, in.UserID, setuju, in.Catatan)
+        return err
+    })
+    switch {
+    case err != nil:
+        h.persetujuanError(c, err)
+    case len(unmet) > 0:
+        c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "syarat verifikasi pinjaman belum terpenuhi", "unmet": unmet})
+    default:
+        c.JSON(http.StatusOK, gin.H{"pinjaman": p, "tahap_aktif": tahapAktif(p.Persetujuan)})
+    }
+}
+
+// GET /api/pinjaman/:id/persetujuan → rantai tahap beserta keputusan tiap penyetuju dan analisis kredit
+func (h *PinjamanController) Persetujuan(c *gin.Context) {
+    var p models.Pinjaman
+    var tahap []models.TahapPersetujuan
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Preload("Analisis").First(&p, c.Param("id")).Error; err != nil { return err }
+        var err error
+        tahap, err = rantaiPersetujuan(tx, &p)
+        return err
+    })
+    if err != nil {
+        h.persetujuanError(c, err)
+        return
+    }
+    if tahap == nil { tahap = []models.TahapPersetujuan{} }
+    c.JSON(http.StatusOK, gin.H{"pinjaman_id": p.ID, "status": p.Status, "tahap": tahap, "tahap_aktif": tahapAktif(tahap), "analisis": p.Analisis})
+}
+
+// GET /api/pinjaman/persetujuan/antrian?user_id=...
+// Pinjaman pengajuan yang tahap aktifnya dapat diputus oleh user (sesuai role, bukan pengaju,
+// dan belum pernah memberi keputusan pada pinjaman tersebut)
+func (h *PinjamanController) AntrianPersetujuan(c *gin.Context) {
+    userID, _ := strconv.ParseUint(c.Query("user_id"), 10, 64)
+    u, err := cariPengguna(h.DB, uint(userID))
+    if err != nil {
+        h.persetujuanError(c, err)
+        return
+    }
+    var list []models.Pinjaman
+    if err := h.DB.Preload("Analisis").Where("status = ?", "pengajuan").Order("tanggal_pengajuan ASC, id ASC").Find(&list).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    var sudah []uint
+    if err := h.DB.Model(&models.KeputusanPersetujuan{}).Where("user_id = ?", u.ID).Distinct().Pluck("pinjaman_id", &sudah).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    data := []gin.H{}
+    for i := range list {
+        p := &list[i]
+        if slices.Contains(sudah, p.ID) || (p.DiajukanOleh != nil && *p.DiajukanOleh == u.ID) { continue }
+        var tahap []models.TahapPersetujuan
+        if err := h.DB.Preload("Keputusan").Where("pinjaman_id = ?", p.ID).Order("urutan ASC").Find(&tahap).Error; err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+            return
+        }
+        // pinjaman lama tanpa rantai tahap muncul untuk pengelola pinjaman; rantainya dibuat saat diputus
+        aktif := tahapAktif(tahap)
+        if (aktif == nil && len(tahap) > 0) || (aktif != nil && !bolehMemutus(aktif, u.Role)) { continue }
+        if aktif == nil && !slices.Contains(rolePengelolaPinjaman, strings.ToLower(strings.TrimSpace(u.Role))) { continue }
+        data = append(data, gin.H{"pinjaman": p, "tahap_aktif": aktif})
+    }
+    c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+func (h *PinjamanController) persetujuanError(c *gin.Context, err error) {
+    switch {
+    case errors.Is(err, gorm.ErrRecordNotFound):
+        c.JSON(http.StatusNotFound, gin.H{"error": "pinjaman tidak ditemukan"})
+    case errors.Is(err, errAksesDitolak):
+        c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+    case errors.Is(err, errInputPersetujuan):
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    case errors.Is(err, errPinjamanTidakMenunggu), errors.Is(err, errPenyetujuPengaju), errors.Is(err, errPenyetujuGanda):
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+    default:
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+    }
+}
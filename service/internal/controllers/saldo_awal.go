@@ -0,0 +1,94 @@
+package controllers
+
+import (
+    "fmt"
+    "math"
+    "sort"
+    "time"
+
+    "gorm.io/gorm"
+
+    "koperasi-desa/service/internal/models"
+)
+
+// PostingSaldoAwal memposting jurnal saldo awal untuk transaksi yang tercatat sebelum buku besar dipasang
+// (simpanan, pencairan pinjaman dan pembayaran angsuran tanpa jurnal), agar rekonsiliasi buku pembantu
+// saat tutup buku sesuai. Satu jurnal per bulan bertanggal akhir bulan dengan lawan Ekuitas Saldo Awal.
+// Yang diposting hanya selisih terhadap jurnal saldo awal bulan itu yang sudah ada, sehingga aman
+// dijalankan ulang; tahun yang sudah ditutup dilewati.
+func PostingSaldoAwal(db *gorm.DB) error {
+    perBulan := map[int]map[string]float64{} // YYYYMM -> akun -> debit - kredit
+    tambah := func(t time.Time, akun string, v float64) {
+        kunci := t.Year()*100 + int(t.Month())
+        if perBulan[kunci] == nil { perBulan[kunci] = map[string]float64{} }
+        perBulan[kunci][akun] += v
+    }
+    tanpaJurnal := func(sumber, ref string) *gorm.DB {
+        return db.Model(&models.Jurnal{}).Select("1").Where("jurnals.sumber = ? AND jurnals.ref_id = "+ref, sumber)
+    }
+
+    // entri pembalik koreksi tidak berjurnal sendiri; pengaruhnya ikut jurnal transaksi asalnya
+    var simpanan []models.Simpanan
+    if err := db.Where("NOT EXISTS (?)", tanpaJurnal("simpanan", "COALESCE(simpanans.koreksi_dari_id, simpanans.id)")).Find(&simpanan).Error; err != nil { return err }
+    for _, r := range simpanan { tambah(r.Tanggal, akunSimpanan(r.Jenis), -saldoDelta(r.Tipe, r.Jumlah)) }
+
+    var pinjaman []models.Pinjaman
+    if err := db.Where("tanggal_pencairan IS NOT NULL").Find(&pinjaman).Error; err != nil { return err }
+    byID := map[uint]*models.Pinjaman{}
+    for i := range pinjaman { byID[pinjaman[i].ID] = &pinjaman[i] }
+    var lama []models.Pinjaman
+    if err := db.Where("tanggal_pencairan IS NOT NULL AND NOT EXISTS (?)", tanpaJurnal("pinjaman", "pinjamen.id")).Find(&lama).Error; err != nil { return err }
+    for _, p := range lama { tambah(*p.TanggalPencairan, models.AkunPiutangPinjaman, p.Nominal+p.BiayaDiangsur) }
+
+    var angsuran []models.Angsuran
+    if err := db.Scopes(angsuranBerlaku).Where("tanggal_bayar IS NOT NULL AND NOT EXISTS (?)", tanpaJurnal("angsuran", "angsurans.id")).Find(&angsuran).Error; err != nil { return err }
+    for i := range angsuran {
+        p, ok := byID[angsuran[i].PinjamanID]
+        if !ok { continue }
+        tambah(*angsuran[i].TanggalBayar, models.AkunPiutangPinjaman, -pokokAngsuran(p, &angsuran[i]))
+    }
+
+    bulan := make([]int, 0, len(perBulan))
+    for k := range perBulan { bulan = append(bulan, k) }
+    sort.Ints(bulan)
+    for _, kunci := range bulan {
+        tahun, bln := kunci/100, time.Month(kunci%100)
+        ditutup, err := tahunDitutup(db, tahun)
+        if err != nil { return err }
+        if ditutup { continue }
+        err = db.Transaction(func(tx *gorm.DB) error {
+            type baris struct {
+                AkunKode string
+                Saldo    float64
+            }
+            var ada []baris
+            if err := tx.Model(&models.JurnalDetail{}).Select("jurnal_details.akun_kode, SUM(jurnal_details.debit - jurnal_details.kredit) AS saldo").
+                Joins("JOIN jurnals ON jurnals.id = jurnal_details.jurnal_id").
+                Where("jurnals.sumber = ? AND jurnals.ref_id = ?", "saldo_awal", kunci).Group("jurnal_details.akun_kode").Scan(&ada).Error; err != nil {
+                return err
+            }
+            perlu := perBulan[kunci]
+            for _, b := range ada {
+                if b.AkunKode != models.AkunSaldoAwal { perlu[b.AkunKode] -= b.Saldo }
+            }
+            akun := make([]string, 0, len(perlu))
+            for a := range perlu { akun = append(akun, a) }
+            sort.Strings(akun)
+            var lines []barisJurnal
+            total := 0.0
+            for _, a := range akun {
+                v := math.Round(perlu[a]*100) / 100
+                if v > 0 { lines = append(lines, debit(a, v)) } else if v < 0 { lines = append(lines, kredit(a, -v)) }
+                total += v
+            }
+            if len(lines) == 0 { return nil }
+            if total > 0 { lines = append(lines, kredit(models.AkunSaldoAwal, total)) } else if total < 0 { lines = append(lines, debit(models.AkunSaldoAwal, -total)) }
+            awal := time.Date(tahun, bln, 1, 0, 0, 0, 0, time.Local)
+            _, err := simpanJurnal(tx, awal.AddDate(0, 1, 0).Add(-time.Second), "saldo_awal", uint(kunci),
+                fmt.Sprintf("Saldo awal transaksi sebelum buku besar %04d-%02d", tahun, bln), lines...)
+            return err
+        })
+        if err != nil { return err }
+    }
+    return nil
+}
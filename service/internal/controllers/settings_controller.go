@@ -1,64 +1,246 @@
 package controllers
 
 import (
+    "encoding/json"
+    "errors"
+    "fmt"
     "net/http"
+    "strings"
+    "time"
 
     "github.com/gin-gonic/gin"
     "gorm.io/gorm"
+    "gorm.io/gorm/clause"
 
     "koperasi-desa/service/internal/models"
+    "koperasi-desa/service/internal/settings"
 )
 
-// SettingsController mengelola konfigurasi sistem berbasis key-value
-// Nilai value disepakati bertipe string; untuk data kompleks gunakan JSON string.
+var errVersiSettingTidakAda = errors.New("versi setting tidak ditemukan")
+
+// rolePengelolaPengaturan adalah role yang boleh mengubah dan me-rollback pengaturan
+var rolePengelolaPengaturan = []string{"admin", "bendahara"}
+
+// SettingsController mengelola konfigurasi sistem berbasis key-value.
+// Hanya key yang terdaftar di package settings yang dapat disimpan; value disimpan sebagai JSON string
+// yang sudah divalidasi dan dilengkapi nilai bawaan. Setiap perubahan dicatat di SettingHistory.
 type SettingsController struct { DB *gorm.DB }
 func NewSettingsController(db *gorm.DB) *SettingsController { return &SettingsController{DB: db} }
 
+// SettingResponse adalah isi satu setting; Default bernilai true jika key belum pernah disimpan
+type SettingResponse struct {
+    Key        string     `json:"key"`
+    Value      string     `json:"value"`
+    Keterangan string     `json:"keterangan,omitempty"`
+    Default    bool       `json:"default"`
+    Versi      int        `json:"versi"`
+    UpdatedAt  *time.Time `json:"updated_at"`
+}
+
+// settingResponse menyusun respons dari baris tersimpan, atau nilai bawaan jika s nil
+func settingResponse(d settings.Definisi, s *models.Setting) (SettingResponse, error) {
+    r := SettingResponse{Key: d.Key, Keterangan: d.Keterangan}
+    if s != nil {
+        r.Value = s.Value
+        r.Versi = s.Versi
+        r.UpdatedAt = &s.UpdatedAt
+        return r, nil
+    }
+    v, err := settings.Bawaan(d.Key)
+    r.Value = v
+    r.Default = true
+    return r, err
+}
+
 // GET /api/settings
-// Mengembalikan daftar semua settings
+// Mengembalikan semua key terdaftar; key yang belum disimpan berisi nilai bawaan
 func (h *SettingsController) ListSettings(c *gin.Context) {
     var list []models.Setting
     if err := h.DB.Order("`key` ASC").Find(&list).Error; err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
     }
-    c.JSON(http.StatusOK, gin.H{"data": list})
+    tersimpan := map[string]*models.Setting{}
+    for i := range list { tersimpan[list[i].Key] = &list[i] }
+
+    out := []SettingResponse{}
+    for _, d := range settings.Daftar() {
+        r, err := settingResponse(d, tersimpan[d.Key])
+        if err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+            return
+        }
+        out = append(out, r)
+    }
+    c.JSON(http.StatusOK, gin.H{"data": out})
 }
 
 // GET /api/settings/:key
 func (h *SettingsController) GetSetting(c *gin.Context) {
-    key := c.Param("key")
+    d, ok := settings.Cari(c.Param("key"))
+    if !ok {
+        c.JSON(http.StatusNotFound, gin.H{"error": settings.ErrKeyTidakDikenal.Error()})
+        return
+    }
     var s models.Setting
-    if err := h.DB.First(&s, "`key` = ?", key).Error; err != nil {
-        if err == gorm.ErrRecordNotFound {
-            c.JSON(http.StatusNotFound, gin.H{"error": "setting tidak ditemukan"})
-        } else {
-            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-        }
+    var ada *models.Setting
+    if err := h.DB.First(&s, "`key` = ?", d.Key).Error; err == nil {
+        ada = &s
+    } else if err != gorm.ErrRecordNotFound {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
     }
-    c.JSON(http.StatusOK, s)
+    r, err := settingResponse(d, ada)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    c.JSON(http.StatusOK, r)
 }
 
 // PUT /api/settings/:key
-// Body: { value: string }
-// Upsert nilai setting berdasarkan key
+// Body: { value: <JSON string atau objek>, user_id, alasan? }
+// Nilai divalidasi terhadap definisi key; field yang tidak dikenal ditolak (400)
 func (h *SettingsController) PutSetting(c *gin.Context) {
     key := c.Param("key")
-    var body struct { Value string `json:"value"` }
+    var body struct {
+        Value  json.RawMessage `json:"value"`
+        UserID uint            `json:"user_id" binding:"required"`
+        Alasan string          `json:"alasan"`
+    }
     if err := c.ShouldBindJSON(&body); err != nil {
         c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
         return
     }
-    if key == "" {
-        c.JSON(http.StatusBadRequest, gin.H{"error": "key wajib diisi"})
+    raw := strings.TrimSpace(string(body.Value))
+    if raw == "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "value wajib diisi"})
+        return
+    }
+    // value lama berupa JSON string, mis. "{\"name\":\"...\"}"
+    if strings.HasPrefix(raw, `"`) {
+        var s string
+        if err := json.Unmarshal([]byte(raw), &s); err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+            return
+        }
+        raw = s
+    }
+    value, err := settings.Validasi(key, raw)
+    if err != nil {
+        if err == settings.ErrKeyTidakDikenal {
+            c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        }
+        return
+    }
+    var riwayat models.SettingHistory
+    err = h.DB.Transaction(func(tx *gorm.DB) error {
+        u, err := cariPengguna(tx, body.UserID, rolePengelolaPengaturan...)
+        if err != nil { return err }
+        riwayat, err = simpanSetting(tx, key, value, u.ID, "ubah", body.Alasan, nil)
+        return err
+    })
+    if err != nil {
+        settingError(c, err)
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"ok": true, "versi": riwayat.Versi, "value": value})
+}
+
+// GET /api/settings/:key/history
+// Riwayat perubahan dari versi terbaru
+func (h *SettingsController) HistorySetting(c *gin.Context) {
+    d, ok := settings.Cari(c.Param("key"))
+    if !ok {
+        c.JSON(http.StatusNotFound, gin.H{"error": settings.ErrKeyTidakDikenal.Error()})
         return
     }
-    s := models.Setting{Key: key, Value: body.Value}
-    // upsert berdasarkan primary key (Name)
-    if err := h.DB.Save(&s).Error; err != nil {
+    var list []models.SettingHistory
+    if err := h.DB.Where("`key` = ?", d.Key).Order("versi DESC").Find(&list).Error; err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
     }
-    c.JSON(http.StatusOK, gin.H{"ok": true})
-}
\ No newline at end of file
+    c.JSON(http.StatusOK, gin.H{"data": list})
+}
+
+// POST /api/settings/:key/rollback
+// Body: { versi, user_id, alasan? }
+// Memulihkan nilai dari versi sebelumnya sebagai versi baru; riwayat tidak dihapus.
+func (h *SettingsController) RollbackSetting(c *gin.Context) {
+    d, ok := settings.Cari(c.Param("key"))
+    if !ok {
+        c.JSON(http.StatusNotFound, gin.H{"error": settings.ErrKeyTidakDikenal.Error()})
+        return
+    }
+    var in struct {
+        Versi  int    `json:"versi" binding:"required,min=1"`
+        UserID uint   `json:"user_id" binding:"required"`
+        Alasan string `json:"alasan"`
+    }
+    if err := c.ShouldBindJSON(&in); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    var asal models.SettingHistory
+    if err := h.DB.Where("`key` = ? AND versi = ?", d.Key, in.Versi).First(&asal).Error; err != nil {
+        settingError(c, err)
+        return
+    }
+    // nilai lama diperiksa ulang karena aturan validasi bisa berubah sejak versi itu disimpan
+    value, err := settings.Validasi(d.Key, asal.NilaiBaru)
+    if err != nil {
+        c.JSON(http.StatusUnprocessableEntity, gin.H{"error": fmt.Sprintf("versi %d tidak dapat dipulihkan: %s", in.Versi, err.Error())})
+        return
+    }
+    var riwayat models.SettingHistory
+    err = h.DB.Transaction(func(tx *gorm.DB) error {
+        u, err := cariPengguna(tx, in.UserID, rolePengelolaPengaturan...)
+        if err != nil { return err }
+        riwayat, err = simpanSetting(tx, d.Key, value, u.ID, "rollback", in.Alasan, &in.Versi)
+        return err
+    })
+    if err != nil {
+        settingError(c, err)
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"ok": true, "versi": riwayat.Versi, "dari_versi": in.Versi, "value": value})
+}
+
+// simpanSetting menyimpan nilai baru dengan versi berikutnya dan mencatat riwayat beserta nilai lamanya.
+// Baris setting dikunci agar dua perubahan bersamaan tidak mendapat nomor versi yang sama.
+func simpanSetting(tx *gorm.DB, key, value string, userID uint, aksi, alasan string, dariVersi *int) (models.SettingHistory, error) {
+    var s models.Setting
+    if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&s, "`key` = ?", key).Error; err != nil && err != gorm.ErrRecordNotFound {
+        return models.SettingHistory{}, err
+    }
+    r := models.SettingHistory{
+        Key:       key,
+        Versi:     s.Versi + 1,
+        NilaiLama: s.Value,
+        NilaiBaru: value,
+        Aksi:      aksi,
+        DariVersi: dariVersi,
+        Alasan:    alasan,
+        UserID:    userID,
+    }
+    if err := tx.Create(&r).Error; err != nil { return r, err }
+    s.Key = key
+    s.Value = value
+    s.Versi = r.Versi
+    // upsert berdasarkan primary key (key)
+    if err := tx.Save(&s).Error; err != nil { return r, err }
+    return r, catatAudit(tx, userID, "setting_"+aksi, "setting", r.ID, fmt.Sprintf("%s versi %d", key, r.Versi))
+}
+
+func settingError(c *gin.Context, err error) {
+    switch {
+    case errors.Is(err, gorm.ErrRecordNotFound):
+        c.JSON(http.StatusNotFound, gin.H{"error": errVersiSettingTidakAda.Error()})
+    case errors.Is(err, errAksesDitolak):
+        c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+    default:
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+    }
+}
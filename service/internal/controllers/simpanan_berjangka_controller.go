@@ -0,0 +1,355 @@
+package controllers
+
+import (
+    "errors"
+    "fmt"
+    "math"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+
+    "koperasi-desa/service/internal/models"
+    "koperasi-desa/service/internal/settings"
+)
+
+var errTenorTidakTersedia = errors.New("tenor belum diatur di settings.financial.simpanan_berjangka")
+
+type SimpananBerjangkaController struct { DB *gorm.DB }
+func NewSimpananBerjangkaController(db *gorm.DB) *SimpananBerjangkaController { return &SimpananBerjangkaController{DB: db} }
+
+// GET /api/simpanan-berjangka?anggota_id=...&status=...&page=...&limit=...
+func (h *SimpananBerjangkaController) ListSimpananBerjangka(c *gin.Context) {
+    var list []models.SimpananBerjangka
+    page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+    limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+    if page < 1 { page = 1 }
+    if limit < 1 || limit > 100 { limit = 10 }
+    offset := (page - 1) * limit
+
+    anggotaID := strings.TrimSpace(c.Query("anggota_id"))
+    status := strings.TrimSpace(c.Query("status"))
+
+    tx := h.DB.Model(&models.SimpananBerjangka{})
+    if anggotaID != "" { tx = tx.Where("anggota_id = ?", anggotaID) }
+    if status != "" { tx = tx.Where("status = ?", strings.ToLower(status)) }
+
+    if err := tx.Order("tanggal_penempatan DESC, id DESC").Limit(limit).Offset(offset).Find(&list).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": list, "page": page, "limit": limit})
+}
+
+// GET /api/simpanan-berjangka/:id
+func (h *SimpananBerjangkaController) GetSimpananBerjangka(c *gin.Context) {
+    var sb models.SimpananBerjangka
+    if err := h.DB.First(&sb, c.Param("id")).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            c.JSON(http.StatusNotFound, gin.H{"error": "simpanan berjangka tidak ditemukan"})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+    c.JSON(http.StatusOK, sb)
+}
+
+// POST /api/simpanan-berjangka
+// { anggota_id, nominal, tenor_bulan, instruksi, sumber: tunai|sukarela, tanggal_penempatan?, user_id? }
+type PenempatanInput struct {
+    AnggotaID  uint       `json:"anggota_id" binding:"required"`
+    Nominal    float64    `json:"nominal" binding:"required,gt=0"`
+    TenorBulan int        `json:"tenor_bulan" binding:"required,gt=0"`
+    Instruksi  string     `json:"instruksi"`
+    Sumber     string     `json:"sumber"`
+    Tanggal    *time.Time `json:"tanggal_penempatan"`
+    UserID     uint       `json:"user_id"` // teller; penempatan tunai masuk ke laci sesi kasirnya
+}
+
+func (h *SimpananBerjangkaController) Penempatan(c *gin.Context) {
+    var in PenempatanInput
+    if err := c.ShouldBindJSON(&in); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    instruksi := strings.ToLower(strings.TrimSpace(in.Instruksi))
+    if instruksi == "" { instruksi = "cair_sukarela" }
+    if instruksi != "perpanjang_pokok" && instruksi != "perpanjang_pokok_bunga" && instruksi != "cair_sukarela" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "instruksi harus perpanjang_pokok/perpanjang_pokok_bunga/cair_sukarela"})
+        return
+    }
+    sumber := strings.ToLower(strings.TrimSpace(in.Sumber))
+    if sumber == "" { sumber = "tunai" }
+    if sumber != "tunai" && sumber != "sukarela" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "sumber harus tunai/sukarela"})
+        return
+    }
+
+    var a models.Anggota
+    if err := h.DB.First(&a, in.AnggotaID).Error; err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "anggota tidak ditemukan"})
+        return
+    }
+    if err := cekAnggotaAktif(&a); err != nil {
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+        return
+    }
+    fs, err := settings.LoadFinancial(h.DB)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    rate, ok := fs.SimpananBerjangka.BungaPerTenor[strconv.Itoa(in.TenorBulan)]
+    if !ok {
+        c.JSON(http.StatusBadRequest, gin.H{"error": errTenorTidakTersedia.Error()})
+        return
+    }
+    if in.Nominal < fs.SimpananBerjangka.MinimalNominal {
+        c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("nominal minimal %.0f", fs.SimpananBerjangka.MinimalNominal)})
+        return
+    }
+
+    tanggal := time.Now()
+    if in.Tanggal != nil { tanggal = *in.Tanggal }
+    if err := cekTanggalMasaDepan(tanggal); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    sb := models.SimpananBerjangka{
+        AnggotaID:         in.AnggotaID,
+        TanggalPenempatan: tanggal,
+        TenorBulan:        in.TenorBulan,
+        BungaPersen:       rate,
+        Nominal:           in.Nominal,
+        TanggalJatuhTempo: tanggal.AddDate(0, in.TenorBulan, 0),
+        Instruksi:         instruksi,
+        Status:            "aktif",
+    }
+    err = h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := cekPeriodeTerbuka(tx, tanggal); err != nil { return err }
+        var err error
+        if sb.NomorBilyet, err = nomorBerikutnya(tx, "simpanan_berjangka", tanggal); err != nil { return err }
+        if err := tx.Create(&sb).Error; err != nil { return err }
+        if sumber == "sukarela" {
+            tarik := models.Simpanan{
+                AnggotaID:  sb.AnggotaID,
+                Jenis:      "sukarela",
+                Tipe:       "penarikan",
+                Tanggal:    tanggal,
+                Jumlah:     sb.Nominal,
+                Keterangan: "Penempatan simpanan berjangka " + sb.NomorBilyet,
+                Sumber:     "berjangka",
+            }
+            return catatSimpanan(tx, &tarik, models.AkunSimpananBerjangka)
+        }
+        if _, err := postJurnal(tx, tanggal, "simpanan_berjangka", sb.ID, "Penempatan simpanan berjangka "+sb.NomorBilyet,
+            debit(models.AkunKas, sb.Nominal), kredit(models.AkunSimpananBerjangka, sb.Nominal)); err != nil {
+            return err
+        }
+        return catatTransaksiKasir(tx, in.UserID, &models.TransaksiKasir{
+            Jenis: "penempatan", Sumber: "berjangka", RefID: sb.ID, Arah: "masuk", Jumlah: sb.Nominal, NomorBukti: sb.NomorBilyet,
+            Keterangan: "Penempatan simpanan berjangka " + sb.NomorBilyet,
+        })
+    })
+    if err != nil {
+        if err == gorm.ErrInvalidTransaction {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "saldo sukarela tidak cukup"})
+        } else if err == errPeriodeTertutup || errors.Is(err, errSesiKasirTidakAda) {
+            c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+    c.JSON(http.StatusCreated, sb)
+}
+
+// POST /api/simpanan-berjangka/:id/pencairan-awal { ke: sukarela|tunai, tanggal?, user_id? }
+// Pencairan sebelum jatuh tempo: bunga hangus dan pokok dipotong penalti.
+type PencairanAwalInput struct {
+    Ke      string     `json:"ke"`
+    Tanggal *time.Time `json:"tanggal"`
+    UserID  uint       `json:"user_id"` // teller; pencairan tunai keluar dari laci sesi kasirnya
+}
+
+func (h *SimpananBerjangkaController) PencairanAwal(c *gin.Context) {
+    var in PencairanAwalInput
+    if err := c.ShouldBindJSON(&in); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    ke := strings.ToLower(strings.TrimSpace(in.Ke))
+    if ke == "" { ke = "sukarela" }
+    if ke != "sukarela" && ke != "tunai" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "ke harus sukarela/tunai"})
+        return
+    }
+    tanggal := time.Now()
+    if in.Tanggal != nil { tanggal = *in.Tanggal }
+    if err := cekTanggalMasaDepan(tanggal); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    fs, err := settings.LoadFinancial(h.DB)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    var sb models.SimpananBerjangka
+    err = h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.First(&sb, c.Param("id")).Error; err != nil { return err }
+        if sb.Status != "aktif" || !tanggal.Before(sb.TanggalJatuhTempo) { return gorm.ErrInvalidTransaction }
+        if err := cekPeriodeTerbuka(tx, tanggal); err != nil { return err }
+
+        sb.Penalti = math.Floor(sb.Nominal * fs.SimpananBerjangka.PenaltiPersen / 100)
+        sb.Status = "dipecah"
+        sb.TanggalPencairan = &tanggal
+        if err := tx.Save(&sb).Error; err != nil { return err }
+        if _, err := postJurnal(tx, tanggal, "simpanan_berjangka", sb.ID, "Penalti pencairan awal "+sb.NomorBilyet,
+            debit(models.AkunSimpananBerjangka, sb.Penalti), kredit(models.AkunPendapatanPenalti, sb.Penalti)); err != nil {
+            return err
+        }
+        if ke == "sukarela" {
+            setor := models.Simpanan{
+                AnggotaID:  sb.AnggotaID,
+                Jenis:      "sukarela",
+                Tipe:       "setoran",
+                Tanggal:    tanggal,
+                Jumlah:     sb.Nominal - sb.Penalti,
+                Keterangan: "Pencairan awal simpanan berjangka " + sb.NomorBilyet,
+                Sumber:     "berjangka",
+            }
+            return catatSimpanan(tx, &setor, models.AkunSimpananBerjangka)
+        }
+        if _, err := postJurnal(tx, tanggal, "simpanan_berjangka", sb.ID, "Pencairan awal simpanan berjangka "+sb.NomorBilyet,
+            debit(models.AkunSimpananBerjangka, sb.Nominal-sb.Penalti), kredit(models.AkunKas, sb.Nominal-sb.Penalti)); err != nil {
+            return err
+        }
+        return catatTransaksiKasir(tx, in.UserID, &models.TransaksiKasir{
+            Jenis: "pencairan_awal", Sumber: "berjangka", RefID: sb.ID, Arah: "keluar", Jumlah: sb.Nominal - sb.Penalti, NomorBukti: sb.NomorBilyet,
+            Keterangan: "Pencairan awal simpanan berjangka " + sb.NomorBilyet,
+        })
+    })
+    if err != nil {
+        if err == gorm.ErrRecordNotFound {
+            c.JSON(http.StatusNotFound, gin.H{"error": "simpanan berjangka tidak ditemukan"})
+        } else if err == gorm.ErrInvalidTransaction {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "simpanan berjangka tidak aktif atau sudah jatuh tempo"})
+        } else if err == errPeriodeTertutup || errors.Is(err, errSesiKasirTidakAda) || errors.Is(err, errKasLaciKurang) {
+            c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": sb, "dibayarkan": sb.Nominal - sb.Penalti})
+}
+
+// POST /api/simpanan-berjangka/proses-jatuh-tempo
+func (h *SimpananBerjangkaController) JatuhTempo(c *gin.Context) {
+    hasil, err := h.ProsesJatuhTempo(time.Now())
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": hasil})
+}
+
+// ProsesJatuhTempo memproses semua simpanan berjangka aktif yang sudah jatuh tempo sesuai instruksinya.
+// Perpanjangan yang ikut jatuh tempo (karena proses terlambat) diproses pada putaran berikutnya.
+func (h *SimpananBerjangkaController) ProsesJatuhTempo(now time.Time) ([]models.SimpananBerjangka, error) {
+    fs, err := settings.LoadFinancial(h.DB)
+    if err != nil { return nil, err }
+
+    hasil := []models.SimpananBerjangka{}
+    for {
+        var due []models.SimpananBerjangka
+        if err := h.DB.Where("status = ? AND tanggal_jatuh_tempo <= ?", "aktif", now).Order("tanggal_jatuh_tempo ASC, id ASC").Find(&due).Error; err != nil {
+            return hasil, err
+        }
+        if len(due) == 0 { return hasil, nil }
+        for i := range due {
+            sb := due[i]
+            if err := h.DB.Transaction(func(tx *gorm.DB) error { return prosesSatuJatuhTempo(tx, &sb, fs) }); err != nil {
+                return hasil, err
+            }
+            hasil = append(hasil, sb)
+        }
+    }
+}
+
+func prosesSatuJatuhTempo(tx *gorm.DB, sb *models.SimpananBerjangka, fs settings.Financial) error {
+    // Jika periode jatuh tempo sudah ditutup (proses terlambat), posting dilakukan pada tanggal proses
+    tanggal := sb.TanggalJatuhTempo
+    if err := cekPeriodeTerbuka(tx, tanggal); err == errPeriodeTertutup {
+        tanggal = time.Now()
+    } else if err != nil {
+        return err
+    }
+    sb.Bunga = math.Floor(sb.Nominal * sb.BungaPersen / 100 * float64(sb.TenorBulan) / 12)
+    if fs.PajakBunga.TarifPersen > 0 && sb.Bunga > fs.PajakBunga.BatasBebas {
+        sb.Pajak = math.Floor(sb.Bunga * fs.PajakBunga.TarifPersen / 100)
+    }
+    bersih := sb.Bunga - sb.Pajak
+    sb.TanggalPencairan = &tanggal
+
+    keSukarela := 0.0
+    pokokBaru := 0.0
+    switch sb.Instruksi {
+    case "perpanjang_pokok":
+        sb.Status = "diperpanjang"
+        pokokBaru = sb.Nominal
+        keSukarela = bersih
+    case "perpanjang_pokok_bunga":
+        sb.Status = "diperpanjang"
+        pokokBaru = sb.Nominal + bersih
+    default:
+        sb.Status = "dicairkan"
+        keSukarela = sb.Nominal + bersih
+    }
+    if err := tx.Save(sb).Error; err != nil { return err }
+    // Bunga dibebankan saat jatuh tempo; bunga bersih menambah kewajiban simpanan berjangka
+    if _, err := postJurnal(tx, tanggal, "simpanan_berjangka", sb.ID, "Bunga simpanan berjangka "+sb.NomorBilyet,
+        debit(models.AkunBebanBungaSimpan, sb.Bunga), kredit(models.AkunUtangPajakBunga, sb.Pajak), kredit(models.AkunSimpananBerjangka, bersih)); err != nil {
+        return err
+    }
+
+    if keSukarela > 0 {
+        setor := models.Simpanan{
+            AnggotaID:  sb.AnggotaID,
+            Jenis:      "sukarela",
+            Tipe:       "setoran",
+            Tanggal:    tanggal,
+            Jumlah:     keSukarela,
+            Keterangan: "Jatuh tempo simpanan berjangka " + sb.NomorBilyet,
+            Sumber:     "berjangka",
+        }
+        if err := catatSimpanan(tx, &setor, models.AkunSimpananBerjangka); err != nil { return err }
+    }
+    if pokokBaru > 0 {
+        rate, ok := fs.SimpananBerjangka.BungaPerTenor[strconv.Itoa(sb.TenorBulan)]
+        if !ok { rate = sb.BungaPersen }
+        dari := sb.ID
+        baru := models.SimpananBerjangka{
+            AnggotaID:          sb.AnggotaID,
+            TanggalPenempatan:  tanggal,
+            TenorBulan:         sb.TenorBulan,
+            BungaPersen:        rate,
+            Nominal:            pokokBaru,
+            TanggalJatuhTempo:  tanggal.AddDate(0, sb.TenorBulan, 0),
+            Instruksi:          sb.Instruksi,
+            Status:             "aktif",
+            PerpanjanganDariID: &dari,
+        }
+        nomor, err := nomorBerikutnya(tx, "simpanan_berjangka", tanggal)
+        if err != nil { return err }
+        baru.NomorBilyet = nomor
+        if err := tx.Create(&baru).Error; err != nil { return err }
+    }
+    return nil
+}
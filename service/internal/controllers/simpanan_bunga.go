@@ -0,0 +1,230 @@
+package controllers
+
+import (
+    "errors"
+    "fmt"
+    "math"
+    "net/http"
+    "sort"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+
+    "koperasi-desa/service/internal/models"
+    "koperasi-desa/service/internal/settings"
+)
+
+var (
+    ErrBungaBelumDiatur     = errors.New("bunga simpanan belum diatur di settings.financial")
+    errPeriodeBelumBerakhir = errors.New("periode belum berakhir, gunakan dry_run untuk simulasi")
+)
+
+// POST /api/simpanan/bunga
+// { periode: "YYYY-MM", jenis?: "sukarela", dry_run?: true }
+type KreditBungaInput struct {
+    Periode string `json:"periode" binding:"required"`
+    Jenis   string `json:"jenis"`
+    DryRun  bool   `json:"dry_run"`
+}
+
+// BungaAnggota adalah rincian perhitungan bunga satu anggota untuk satu jenis simpanan
+type BungaAnggota struct {
+    AnggotaID  uint    `json:"anggota_id"`
+    Jenis      string  `json:"jenis"`
+    Metode     string  `json:"metode"`
+    SaldoDasar float64 `json:"saldo_dasar"`
+    Hari       int     `json:"hari"`
+    Bunga      float64 `json:"bunga"`
+    Pajak      float64 `json:"pajak"`
+    Bersih     float64 `json:"bersih"`
+}
+
+// KreditBungaResult adalah hasil (atau simulasi) kredit bunga satu periode
+type KreditBungaResult struct {
+    Periode       string         `json:"periode"`
+    DryRun        bool           `json:"dry_run"`
+    Data          []BungaAnggota `json:"data"`
+    SudahDikredit []uint         `json:"sudah_dikredit"`
+    TotalBunga    float64        `json:"total_bunga"`
+    TotalPajak    float64        `json:"total_pajak"`
+}
+
+func (h *SimpananController) KreditBunga(c *gin.Context) {
+    var in KreditBungaInput
+    if err := c.ShouldBindJSON(&in); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    periode, err := time.ParseInLocation("2006-01", strings.TrimSpace(in.Periode), time.Local)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "periode harus berformat YYYY-MM"})
+        return
+    }
+    res, err := h.ProsesBunga(periode, strings.ToLower(strings.TrimSpace(in.Jenis)), in.DryRun)
+    if err != nil {
+        if errors.Is(err, ErrBungaBelumDiatur) || errors.Is(err, errPeriodeBelumBerakhir) {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        } else if errors.Is(err, errPeriodeTertutup) {
+            c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+    c.JSON(http.StatusOK, res)
+}
+
+// ProsesBunga menghitung bunga bulan periode untuk jenis tertentu (kosong = semua jenis yang diatur)
+// dan, jika bukan dry run, mengkreditkan bunga serta pajaknya pada akhir bulan.
+// Setiap anggota diproses dalam transaksinya sendiri; anggota yang sudah menerima bunga pada periode
+// tersebut dilewati sehingga proses aman diulang, termasuk setelah gagal di tengah jalan.
+func (h *SimpananController) ProsesBunga(periode time.Time, jenis string, dryRun bool) (*KreditBungaResult, error) {
+    fs, err := settings.LoadFinancial(h.DB)
+    if err != nil { return nil, err }
+
+    var daftarJenis []string
+    if jenis != "" {
+        if _, ok := fs.BungaSimpanan[jenis]; !ok {
+            return nil, fmt.Errorf("%w: jenis %s", ErrBungaBelumDiatur, jenis)
+        }
+        daftarJenis = []string{jenis}
+    } else {
+        for j := range fs.BungaSimpanan { daftarJenis = append(daftarJenis, j) }
+        sort.Strings(daftarJenis)
+    }
+    if len(daftarJenis) == 0 { return nil, ErrBungaBelumDiatur }
+
+    start := time.Date(periode.Year(), periode.Month(), 1, 0, 0, 0, 0, time.Local)
+    end := start.AddDate(0, 1, 0)
+    if !dryRun && end.After(time.Now()) { return nil, errPeriodeBelumBerakhir }
+    if !dryRun {
+        if err := cekPeriodeTerbuka(h.DB, start); err != nil { return nil, err }
+    }
+
+    res := &KreditBungaResult{Periode: start.Format("2006-01"), DryRun: dryRun, Data: []BungaAnggota{}, SudahDikredit: []uint{}}
+    for _, j := range daftarJenis {
+        cfg := fs.BungaSimpanan[j]
+        var ids []uint
+        if err := h.DB.Model(&models.Simpanan{}).Where("jenis = ? AND tanggal < ?", j, end).Distinct().Order("anggota_id").Pluck("anggota_id", &ids).Error; err != nil {
+            return nil, err
+        }
+        for _, id := range ids {
+            var b BungaAnggota
+            var sudah bool
+            if dryRun {
+                b, sudah, err = kreditBungaAnggota(h.DB, id, j, cfg, fs.PajakBunga, start, end, false)
+            } else {
+                err = h.DB.Transaction(func(tx *gorm.DB) error {
+                    var err error
+                    b, sudah, err = kreditBungaAnggota(tx, id, j, cfg, fs.PajakBunga, start, end, true)
+                    return err
+                })
+            }
+            if err != nil { return nil, err }
+            if sudah {
+                res.SudahDikredit = append(res.SudahDikredit, id)
+                continue
+            }
+            if b.Bunga <= 0 { continue }
+            res.Data = append(res.Data, b)
+            res.TotalBunga += b.Bunga
+            res.TotalPajak += b.Pajak
+        }
+    }
+    return res, nil
+}
+
+// kreditBungaAnggota memeriksa apakah anggota sudah menerima bunga jenis ini pada [start, end), lalu
+// menghitung bunganya dan, jika catat, mengkreditkan bunga serta pajak pada akhir periode. Saat mencatat,
+// baris anggota dikunci lebih dulu (kunci yang sama dengan catatSimpanan) agar pemeriksaan dan perhitungan
+// tidak berjalan bersamaan dengan proses bunga lain atau transaksi simpanan anggota tersebut.
+func kreditBungaAnggota(tx *gorm.DB, anggotaID uint, jenis string, cfg settings.BungaSimpanan, pajak settings.PajakBunga, start, end time.Time, catat bool) (BungaAnggota, bool, error) {
+    if catat {
+        if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.Anggota{}, anggotaID).Error; err != nil { return BungaAnggota{}, false, err }
+    }
+    var credited int64
+    if err := tx.Model(&models.Simpanan{}).Where("anggota_id = ? AND jenis = ? AND tipe = ? AND tanggal >= ? AND tanggal < ?", anggotaID, jenis, "bunga", start, end).Count(&credited).Error; err != nil {
+        return BungaAnggota{}, false, err
+    }
+    if credited > 0 { return BungaAnggota{}, true, nil }
+    b, err := hitungBunga(tx, anggotaID, jenis, cfg, pajak, start, end)
+    if err != nil || !catat || b.Bunga <= 0 { return b, false, err }
+
+    tanggal := end.Add(-time.Second)
+    periode := start.Format("2006-01")
+    bunga := models.Simpanan{
+        AnggotaID:  anggotaID,
+        Jenis:      jenis,
+        Tipe:       "bunga",
+        Tanggal:    tanggal,
+        Jumlah:     b.Bunga,
+        Keterangan: fmt.Sprintf("Bunga simpanan %s periode %s", jenis, periode),
+        Sumber:     "bunga",
+    }
+    if err := catatSimpanan(tx, &bunga, ""); err != nil { return b, false, err }
+    if b.Pajak > 0 {
+        pajakBunga := models.Simpanan{
+            AnggotaID:  anggotaID,
+            Jenis:      jenis,
+            Tipe:       "pajak_bunga",
+            Tanggal:    tanggal,
+            Jumlah:     b.Pajak,
+            Keterangan: fmt.Sprintf("Pajak bunga simpanan %s periode %s", jenis, periode),
+            Sumber:     "bunga",
+        }
+        if err := catatSimpanan(tx, &pajakBunga, ""); err != nil { return b, false, err }
+    }
+    return b, false, nil
+}
+
+// hitungBunga menghitung saldo dasar dari saldo akhir harian dalam [start, end)
+// lalu bunga = saldo dasar * rate tahunan * jumlah hari / 365, dibulatkan ke bawah.
+func hitungBunga(db *gorm.DB, anggotaID uint, jenis string, cfg settings.BungaSimpanan, pajak settings.PajakBunga, start, end time.Time) (BungaAnggota, error) {
+    b := BungaAnggota{AnggotaID: anggotaID, Jenis: jenis, Metode: cfg.Metode}
+    if b.Metode == "" { b.Metode = "saldo_rata_rata" }
+
+    saldo := 0.0
+    var opening models.Simpanan
+    err := db.Where("anggota_id = ? AND jenis = ? AND tanggal < ?", anggotaID, jenis, start).Order("tanggal DESC, id DESC").First(&opening).Error
+    if err == nil {
+        saldo = opening.SaldoAkhir
+    } else if err != gorm.ErrRecordNotFound {
+        return b, err
+    }
+    var rows []models.Simpanan
+    if err := db.Where("anggota_id = ? AND jenis = ? AND tanggal >= ? AND tanggal < ?", anggotaID, jenis, start, end).Order("tanggal ASC, id ASC").Find(&rows).Error; err != nil {
+        return b, err
+    }
+
+    terendah := math.Inf(1)
+    total := 0.0
+    i := 0
+    for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+        akhirHari := d.AddDate(0, 0, 1)
+        for i < len(rows) && rows[i].Tanggal.Before(akhirHari) {
+            saldo = rows[i].SaldoAkhir
+            i++
+        }
+        if saldo < terendah { terendah = saldo }
+        total += saldo
+        b.Hari++
+    }
+    if b.Hari == 0 { return b, nil }
+
+    if b.Metode == "saldo_terendah" {
+        b.SaldoDasar = terendah
+    } else {
+        b.SaldoDasar = math.Round(total / float64(b.Hari))
+    }
+    if b.SaldoDasar <= 0 { return b, nil }
+
+    b.Bunga = math.Floor(b.SaldoDasar * cfg.RatePersenTahun / 100 * float64(b.Hari) / 365)
+    if pajak.TarifPersen > 0 && b.Bunga > pajak.BatasBebas {
+        b.Pajak = math.Floor(b.Bunga * pajak.TarifPersen / 100)
+    }
+    b.Bersih = b.Bunga - b.Pajak
+    return b, nil
+}
@@ -0,0 +1,52 @@
+package controllers
+
+import (
+    "testing"
+    "time"
+
+    "koperasi-desa/service/internal/models"
+    "koperasi-desa/service/internal/settings"
+)
+
+func TestHitungBunga(t *testing.T) {
+    start := time.Date(2026, 9, 1, 0, 0, 0, 0, time.Local)
+    end := start.AddDate(0, 1, 0)
+    tgl := func(bulan time.Month, hari int) time.Time { return time.Date(2026, bulan, hari, 10, 0, 0, 0, time.Local) }
+    type mutasi struct {
+        tanggal time.Time
+        saldo   float64
+    }
+    cases := []struct {
+        nama   string
+        mutasi []mutasi
+        metode string
+        pajak  settings.PajakBunga
+        dasar  float64
+        bunga  float64
+        potong float64
+    }{
+        {"tanpa transaksi", nil, "", settings.PajakBunga{}, 0, 0, 0},
+        {"saldo tetap sebulan", []mutasi{{tgl(8, 5), 1_000_000}}, "", settings.PajakBunga{TarifPersen: 10}, 1_000_000, 9863, 986},
+        {"setoran di tengah bulan, rata-rata", []mutasi{{tgl(9, 16), 1_000_000}}, "saldo_rata_rata", settings.PajakBunga{}, 500_000, 4931, 0},
+        {"setoran di tengah bulan, saldo terendah", []mutasi{{tgl(9, 16), 1_000_000}}, "saldo_terendah", settings.PajakBunga{}, 0, 0, 0},
+        {"penarikan, rata-rata", []mutasi{{tgl(8, 5), 2_000_000}, {tgl(9, 11), 500_000}}, "saldo_rata_rata", settings.PajakBunga{}, 1_000_000, 9863, 0},
+        {"penarikan, saldo terendah", []mutasi{{tgl(8, 5), 2_000_000}, {tgl(9, 11), 500_000}}, "saldo_terendah", settings.PajakBunga{}, 500_000, 4931, 0},
+        {"bunga di bawah batas bebas pajak", []mutasi{{tgl(8, 5), 1_000_000}}, "", settings.PajakBunga{TarifPersen: 10, BatasBebas: 10_000}, 1_000_000, 9863, 0},
+        {"transaksi sesudah periode diabaikan", []mutasi{{tgl(8, 5), 1_000_000}, {tgl(10, 2), 5_000_000}}, "", settings.PajakBunga{}, 1_000_000, 9863, 0},
+    }
+    for _, c := range cases {
+        t.Run(c.nama, func(t *testing.T) {
+            db := dbUji(t)
+            for _, m := range c.mutasi {
+                r := models.Simpanan{AnggotaID: 1, Jenis: "sukarela", Tipe: "setoran", Tanggal: m.tanggal, Jumlah: m.saldo, SaldoAkhir: m.saldo}
+                if err := db.Create(&r).Error; err != nil { t.Fatal(err) }
+            }
+            b, err := hitungBunga(db, 1, "sukarela", settings.BungaSimpanan{RatePersenTahun: 12, Metode: c.metode}, c.pajak, start, end)
+            if err != nil { t.Fatal(err) }
+            if b.Hari != 30 { t.Errorf("hari = %d, ingin 30", b.Hari) }
+            if b.SaldoDasar != c.dasar || b.Bunga != c.bunga || b.Pajak != c.potong || b.Bersih != c.bunga-c.potong {
+                t.Errorf("saldo dasar/bunga/pajak/bersih = %v/%v/%v/%v, ingin %v/%v/%v/%v", b.SaldoDasar, b.Bunga, b.Pajak, b.Bersih, c.dasar, c.bunga, c.potong, c.bunga-c.potong)
+            }
+        })
+    }
+}
@@ -1,6 +1,9 @@
 package controllers
 
 import (
+    "errors"
+    "fmt"
+    "math"
     "net/http"
     "strconv"
     "strings"
@@ -8,10 +11,13 @@ import (
 
     "github.com/gin-gonic/gin"
     "gorm.io/gorm"
+    "gorm.io/gorm/clause"
 
     "koperasi-desa/service/internal/models"
 )
 
+var errTanggalMasaDepan = errors.New("tanggal transaksi tidak boleh di masa depan")
+
 type SimpananController struct {
     DB *gorm.DB
 }
@@ -21,9 +27,10 @@ func NewSimpananController(db *gorm.DB) *SimpananController { return &SimpananCo
 // Input payloads
 type SetoranInput struct {
     AnggotaID uint     `json:"anggota_id" binding:"required"`
-    Jenis     string   `json:"jenis" binding:"required"`   // wajib | sukarela | khusus
+    Jenis     string   `json:"jenis" binding:"required"`   // pokok | wajib | sukarela | khusus
     Jumlah    float64  `json:"jumlah" binding:"required,gt=0"`
     Tanggal   *time.Time `json:"tanggal"`
+    UserID    uint     `json:"user_id"` // teller; uang masuk ke laci sesi kasirnya
 }
 
 type PenarikanInput struct {
@@ -31,6 +38,7 @@ type PenarikanInput struct {
     Jenis     string   `json:"jenis" binding:"required"`
     Jumlah    float64  `json:"jumlah" binding:"required,gt=0"`
     Tanggal   *time.Time `json:"tanggal"`
+    UserID    uint     `json:"user_id"` // teller; uang keluar dari laci sesi kasirnya
 }
 
 // Helper to get latest saldo for anggota+jenis
@@ -46,6 +54,97 @@ func (h *SimpananController) latestSaldo(anggotaID uint, jenis string) (float64,
     return last.SaldoAkhir, nil
 }
 
+// saldoPada mengembalikan saldo anggota+jenis per waktu t (transaksi terakhir dengan tanggal <= t)
+func saldoPada(tx *gorm.DB, anggotaID uint, jenis string, t time.Time) (float64, error) {
+    var last models.Simpanan
+    err := tx.Where("anggota_id = ? AND jenis = ? AND tanggal <= ?", anggotaID, strings.ToLower(jenis), t).Order("tanggal DESC, id DESC").First(&last).Error
+    if err != nil {
+        if err == gorm.ErrRecordNotFound {
+            return 0, nil
+        }
+        return 0, err
+    }
+    return last.SaldoAkhir, nil
+}
+
+// saldoDelta mengembalikan pengaruh satu transaksi terhadap saldo
+func saldoDelta(tipe string, jumlah float64) float64 {
+    switch tipe {
+    case "penarikan", "pajak_bunga", "koreksi_debit":
+        return -jumlah
+    default:
+        return jumlah
+    }
+}
+
+// catatSimpanan menyimpan transaksi simpanan lalu menghitung ulang saldo_akhir mulai tanggal transaksi,
+// sehingga transaksi bertanggal mundur tidak merusak rantai saldo sesudahnya.
+// Baris anggota dikunci agar posting untuk anggota yang sama berjalan berurutan.
+// Setoran/penarikan tunai (akunLawan kosong) diberi nomor kuitansi.
+// Transaksi selain koreksi ikut diposting ke jurnal dengan akunLawan (kosong = default menurut tipe);
+// jurnal koreksi dibuat lewat balikJurnal oleh pemanggil.
+// Mengembalikan errPeriodeTertutup jika tanggal berada di periode yang sudah ditutup
+// dan gorm.ErrInvalidTransaction jika ada saldo yang menjadi negatif.
+func catatSimpanan(tx *gorm.DB, rec *models.Simpanan, akunLawan string) error {
+    if err := cekPeriodeTerbuka(tx, rec.Tanggal); err != nil { return err }
+    if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.Anggota{}, rec.AnggotaID).Error; err != nil { return err }
+    saldo, err := saldoPada(tx, rec.AnggotaID, rec.Jenis, rec.Tanggal)
+    if err != nil { return err }
+    rec.SaldoAkhir = saldo + saldoDelta(rec.Tipe, rec.Jumlah)
+    if rec.SaldoAkhir < 0 { return gorm.ErrInvalidTransaction }
+    if akunLawan == "" && (rec.Tipe == "setoran" || rec.Tipe == "penarikan") && rec.NomorBukti == "" {
+        if rec.NomorBukti, err = nomorBerikutnya(tx, "kuitansi", rec.Tanggal); err != nil { return err }
+    }
+    if err := tx.Create(rec).Error; err != nil { return err }
+    if err := hitungUlangSaldo(tx, rec.AnggotaID, rec.Jenis, rec.Tanggal); err != nil { return err }
+    if !strings.HasPrefix(rec.Tipe, "koreksi_") {
+        if err := jurnalSimpanan(tx, rec, akunLawan); err != nil { return err }
+    }
+    return tx.Select("saldo_akhir").First(rec, rec.ID).Error
+}
+
+// cekTanggalMasaDepan menolak tanggal transaksi yang diisi petugas melewati waktu sekarang.
+// Tanggal mundur diperbolehkan selama periodenya belum ditutup (dicek di catatSimpanan).
+func cekTanggalMasaDepan(t time.Time) error {
+    if t.After(time.Now()) { return errTanggalMasaDepan }
+    return nil
+}
+
+// hitungUlangSaldo menghitung ulang saldo_akhir transaksi anggota+jenis mulai tanggal dari.
+// Mengembalikan gorm.ErrInvalidTransaction jika ada saldo yang menjadi negatif
+// dan errPeriodeTertutup jika saldo transaksi di periode yang sudah ditutup ikut berubah.
+func hitungUlangSaldo(tx *gorm.DB, anggotaID uint, jenis string, dari time.Time) error {
+    saldo := 0.0
+    var opening models.Simpanan
+    err := tx.Where("anggota_id = ? AND jenis = ? AND tanggal < ?", anggotaID, jenis, dari).Order("tanggal DESC, id DESC").First(&opening).Error
+    if err == nil {
+        saldo = opening.SaldoAkhir
+    } else if err != gorm.ErrRecordNotFound {
+        return err
+    }
+    var rows []models.Simpanan
+    if err := tx.Where("anggota_id = ? AND jenis = ? AND tanggal >= ?", anggotaID, jenis, dari).Order("tanggal ASC, id ASC").Find(&rows).Error; err != nil {
+        return err
+    }
+    tertutup := map[string]bool{}
+    for _, r := range rows {
+        saldo += saldoDelta(r.Tipe, r.Jumlah)
+        if saldo < 0 { return gorm.ErrInvalidTransaction }
+        if math.Abs(r.SaldoAkhir-saldo) > 0.005 {
+            // saldo transaksi di periode tertutup tidak boleh berubah
+            bulan := r.Tanggal.Format("2006-01")
+            if _, ok := tertutup[bulan]; !ok {
+                err := cekPeriodeTerbuka(tx, r.Tanggal)
+                if err != nil && err != errPeriodeTertutup { return err }
+                tertutup[bulan] = err != nil
+            }
+            if tertutup[bulan] { return errPeriodeTertutup }
+            if err := tx.Model(&models.Simpanan{}).Where("id = ?", r.ID).Update("saldo_akhir", saldo).Error; err != nil { return err }
+        }
+    }
+    return nil
+}
+
 // GET /api/simpanan?anggota_id=...&jenis=...&page=...&limit=...
 func (h *SimpananController) ListSimpanan(c *gin.Context) {
     var list []models.Simpanan
@@ -74,7 +173,9 @@ func (h *SimpananController) ListSimpanan(c *gin.Context) {
         wajib, _ := h.latestSaldo(uint(id64), "wajib")
         sukarela, _ := h.latestSaldo(uint(id64), "sukarela")
         khusus, _ := h.latestSaldo(uint(id64), "khusus")
-        saldo = gin.H{"wajib": wajib, "sukarela": sukarela, "khusus": khusus}
+        var berjangka float64
+        _ = h.DB.Model(&models.SimpananBerjangka{}).Where("anggota_id = ? AND status = ?", id64, "aktif").Select("COALESCE(SUM(nominal), 0)").Scan(&berjangka).Error
+        saldo = gin.H{"wajib": wajib, "sukarela": sukarela, "khusus": khusus, "berjangka": berjangka}
     }
 
     c.JSON(http.StatusOK, gin.H{"data": list, "page": page, "limit": limit, "saldo": saldo})
@@ -88,8 +189,8 @@ func (h *SimpananController) Setoran(c *gin.Context) {
         return
     }
     jenis := strings.ToLower(input.Jenis)
-    if jenis != "wajib" && jenis != "sukarela" && jenis != "khusus" {
-        c.JSON(http.StatusBadRequest, gin.H{"error": "jenis harus wajib/sukarela/khusus"})
+    if jenis != "pokok" && jenis != "wajib" && jenis != "sukarela" && jenis != "khusus" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "jenis harus pokok/wajib/sukarela/khusus"})
         return
     }
 
@@ -99,28 +200,43 @@ func (h *SimpananController) Setoran(c *gin.Context) {
         c.JSON(http.StatusBadRequest, gin.H{"error": "anggota tidak ditemukan"})
         return
     }
+    if err := cekBolehSetor(&a, jenis); err != nil {
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+        return
+    }
 
-    // Transactional insert
+    tanggal := time.Now()
+    if input.Tanggal != nil { tanggal = *input.Tanggal }
+    if err := cekTanggalMasaDepan(tanggal); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    // Transactional insert; saldo transaksi sesudah tanggal ini ikut dihitung ulang
+    rec := models.Simpanan{
+        AnggotaID: input.AnggotaID,
+        Jenis:     jenis,
+        Tipe:      "setoran",
+        Tanggal:   tanggal,
+        Jumlah:    input.Jumlah,
+        Sumber:    "loket",
+    }
     err := h.DB.Transaction(func(tx *gorm.DB) error {
-        latest, err := h.latestSaldo(input.AnggotaID, jenis)
-        if err != nil { return err }
-        tanggal := time.Now()
-        if input.Tanggal != nil { tanggal = *input.Tanggal }
-        rec := models.Simpanan{
-            AnggotaID:  input.AnggotaID,
-            Jenis:      jenis,
-            Tipe:       "setoran",
-            Tanggal:    tanggal,
-            Jumlah:     input.Jumlah,
-            SaldoAkhir: latest + input.Jumlah,
-        }
-        return tx.Create(&rec).Error
+        if err := catatSimpanan(tx, &rec, ""); err != nil { return err }
+        return catatTransaksiKasir(tx, input.UserID, &models.TransaksiKasir{
+            Jenis: "setoran", Sumber: "simpanan", RefID: rec.ID, Arah: "masuk", Jumlah: rec.Jumlah, NomorBukti: rec.NomorBukti,
+            Keterangan: fmt.Sprintf("Setoran simpanan %s anggota #%d", rec.Jenis, rec.AnggotaID),
+        })
     })
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        if err == errPeriodeTertutup || errors.Is(err, errSesiKasirTidakAda) {
+            c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
         return
     }
-    c.JSON(http.StatusCreated, gin.H{"ok": true})
+    c.JSON(http.StatusCreated, gin.H{"ok": true, "nomor_bukti": rec.NomorBukti})
 }
 
 // POST /api/simpanan/penarikan
@@ -131,6 +247,7 @@ func (h *SimpananController) Penarikan(c *gin.Context) {
         return
     }
     jenis := strings.ToLower(input.Jenis)
+    // Simpanan pokok hanya dikembalikan lewat penyelesaian keluar anggota
     if jenis != "wajib" && jenis != "sukarela" && jenis != "khusus" {
         c.JSON(http.StatusBadRequest, gin.H{"error": "jenis harus wajib/sukarela/khusus"})
         return
@@ -141,32 +258,38 @@ func (h *SimpananController) Penarikan(c *gin.Context) {
         return
     }
 
-    // Transactional insert with saldo check
+    tanggal := time.Now()
+    if input.Tanggal != nil { tanggal = *input.Tanggal }
+    if err := cekTanggalMasaDepan(tanggal); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    // Transactional insert with saldo check; penarikan mundur ditolak jika ada saldo historis yang menjadi negatif
+    rec := models.Simpanan{
+        AnggotaID: input.AnggotaID,
+        Jenis:     jenis,
+        Tipe:      "penarikan",
+        Tanggal:   tanggal,
+        Jumlah:    input.Jumlah,
+        Sumber:    "loket",
+    }
     err := h.DB.Transaction(func(tx *gorm.DB) error {
-        latest, err := h.latestSaldo(input.AnggotaID, jenis)
-        if err != nil { return err }
-        if latest < input.Jumlah {
-            return gorm.ErrInvalidTransaction
-        }
-        tanggal := time.Now()
-        if input.Tanggal != nil { tanggal = *input.Tanggal }
-        rec := models.Simpanan{
-            AnggotaID:  input.AnggotaID,
-            Jenis:      jenis,
-            Tipe:       "penarikan",
-            Tanggal:    tanggal,
-            Jumlah:     input.Jumlah,
-            SaldoAkhir: latest - input.Jumlah,
-        }
-        return tx.Create(&rec).Error
+        if err := catatSimpanan(tx, &rec, ""); err != nil { return err }
+        return catatTransaksiKasir(tx, input.UserID, &models.TransaksiKasir{
+            Jenis: "penarikan", Sumber: "simpanan", RefID: rec.ID, Arah: "keluar", Jumlah: rec.Jumlah, NomorBukti: rec.NomorBukti,
+            Keterangan: fmt.Sprintf("Penarikan simpanan %s anggota #%d", rec.Jenis, rec.AnggotaID),
+        })
     })
     if err != nil {
         if err == gorm.ErrInvalidTransaction {
             c.JSON(http.StatusBadRequest, gin.H{"error": "saldo tidak cukup"})
+        } else if err == errPeriodeTertutup || errors.Is(err, errSesiKasirTidakAda) || errors.Is(err, errKasLaciKurang) {
+            c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
         } else {
             c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         }
         return
     }
-    c.JSON(http.StatusCreated, gin.H{"ok": true})
+    c.JSON(http.StatusCreated, gin.H{"ok": true, "nomor_bukti": rec.NomorBukti})
 }
\ No newline at end of file
@@ -0,0 +1,267 @@
+package controllers
+
+import (
+    "errors"
+    "fmt"
+    "log"
+    "math"
+    "net/http"
+    "strconv"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+
+    "koperasi-desa/service/internal/models"
+    "koperasi-desa/service/internal/settings"
+)
+
+var errSkorKreditKurang = errors.New("skor kredit anggota di bawah minimal pengajuan")
+
+// statusDinilaiSkor adalah status anggota yang skornya dihitung ulang setiap malam
+var statusDinilaiSkor = []string{"active", "nonaktif"}
+
+// nilaiKolektibilitas adalah nilai komponen kolektibilitas (indeks 1-5)
+var nilaiKolektibilitas = []float64{0, 100, 70, 40, 20, 0}
+
+// kolektibilitas menggolongkan hari tunggakan terlama: 0 lancar, 1-90 dalam perhatian khusus,
+// 91-120 kurang lancar, 121-180 diragukan, lebih dari 180 macet
+func kolektibilitas(hari int) int {
+    switch {
+    case hari <= 0:
+        return 1
+    case hari <= 90:
+        return 2
+    case hari <= 120:
+        return 3
+    case hari <= 180:
+        return 4
+    }
+    return 5
+}
+
+func gradeSkor(skor int) string {
+    switch {
+    case skor >= 80:
+        return "A"
+    case skor >= 65:
+        return "B"
+    case skor >= 50:
+        return "C"
+    case skor >= 35:
+        return "D"
+    }
+    return "E"
+}
+
+func awalBulan(t time.Time) time.Time { return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location()) }
+
+// hitungSkorKredit menghitung skor kredit anggota per tanggal dari data yang sudah ada:
+//   - ketepatan: porsi angsuran tepat waktu pada pinjaman berjalan/lunas
+//   - denda: denda yang sudah dibayar dibanding batas_denda
+//   - kolektibilitas: tunggakan terlama pada pinjaman berjalan
+//   - simpanan: porsi bulan (periode_simpanan_bulan terakhir, tidak termasuk bulan berjalan) yang memiliki
+//     setoran simpanan wajib yang tidak dikoreksi
+//   - masa_keanggotaan: lama bergabung dibanding masa_penuh_bulan
+// Komponen tanpa data (belum pernah meminjam, baru bergabung bulan ini) memakai nilai_tanpa_riwayat.
+func hitungSkorKredit(tx *gorm.DB, a *models.Anggota, cfg settings.SkorKredit, now time.Time) (models.SkorKredit, error) {
+    s := models.SkorKredit{AnggotaID: a.ID, Tanggal: tanggalSaja(now)}
+    hariIni := tanggalSaja(now)
+
+    var pinjaman []models.Pinjaman
+    if err := tx.Where("anggota_id = ? AND status IN ?", a.ID, []string{"berjalan", "lunas"}).Find(&pinjaman).Error; err != nil { return s, err }
+    dibayar := 0
+    for i := range pinjaman {
+        var list []models.Angsuran
+        if err := tx.Scopes(angsuranBerlaku).Where("pinjaman_id = ?", pinjaman[i].ID).Find(&list).Error; err != nil { return s, err }
+        for j := range list {
+            ag := &list[j]
+            switch ketepatanBayar(ag, hariIni) {
+            case "tepat":
+                s.AngsuranTepatWaktu++
+            case "terlambat":
+                s.AngsuranTerlambat++
+            }
+            if ag.TanggalBayar != nil {
+                dibayar++
+                s.TotalDenda += ag.Denda
+            } else if pinjaman[i].Status == "berjalan" && tanggalSaja(ag.TanggalJatuhTempo) < hariIni {
+                s.HariTunggakan = max(s.HariTunggakan, int(now.Sub(ag.TanggalJatuhTempo).Hours()/24))
+            }
+        }
+    }
+    s.Kolektibilitas = kolektibilitas(s.HariTunggakan)
+
+    s.SkorKetepatan = cfg.NilaiTanpaRiwayat
+    if n := s.AngsuranTepatWaktu + s.AngsuranTerlambat; n > 0 { s.SkorKetepatan = float64(s.AngsuranTepatWaktu) / float64(n) * 100 }
+    s.SkorDenda = cfg.NilaiTanpaRiwayat
+    if dibayar > 0 { s.SkorDenda = math.Max(0, 100*(1-s.TotalDenda/cfg.BatasDenda)) }
+    s.SkorKolektibilitas = nilaiKolektibilitas[s.Kolektibilitas]
+
+    gabung := a.TanggalGabung
+    if gabung.IsZero() { gabung = a.CreatedAt }
+    bulanIni := awalBulan(now)
+    mulai := awalBulan(gabung)
+    if batas := bulanIni.AddDate(0, -cfg.PeriodeSimpananBulan, 0); mulai.Before(batas) { mulai = batas }
+    for m := mulai; m.Before(bulanIni); m = m.AddDate(0, 1, 0) { s.BulanWajib++ }
+    s.SkorSimpanan = cfg.NilaiTanpaRiwayat
+    if s.BulanWajib > 0 {
+        var tanggal []time.Time
+        if err := tx.Model(&models.Simpanan{}).
+            Where("anggota_id = ? AND jenis = ? AND tipe = ? AND dikoreksi_oleh_id IS NULL AND tanggal >= ? AND tanggal < ?", a.ID, "wajib", "setoran", mulai, bulanIni).
+            Pluck("tanggal", &tanggal).Error; err != nil {
+            return s, err
+        }
+        bulan := map[string]bool{}
+        for _, t := range tanggal { bulan[t.Format("2006-01")] = true }
+        s.BulanWajibDisetor = len(bulan)
+        s.SkorSimpanan = float64(s.BulanWajibDisetor) / float64(s.BulanWajib) * 100
+    }
+
+    s.MasaKeanggotaanBulan = (now.Year()-gabung.Year())*12 + int(now.Month()-gabung.Month())
+    if now.Day() < gabung.Day() { s.MasaKeanggotaanBulan-- }
+    s.MasaKeanggotaanBulan = max(s.MasaKeanggotaanBulan, 0)
+    s.SkorMasaKeanggotaan = math.Min(float64(s.MasaKeanggotaanBulan)/float64(cfg.MasaPenuhBulan), 1) * 100
+
+    komponen := map[string]float64{
+        "ketepatan": s.SkorKetepatan, "denda": s.SkorDenda, "kolektibilitas": s.SkorKolektibilitas,
+        "simpanan": s.SkorSimpanan, "masa_keanggotaan": s.SkorMasaKeanggotaan,
+    }
+    var total, bobot float64
+    for _, k := range settings.KomponenSkorKredit {
+        total += cfg.Bobot[k] * komponen[k]
+        bobot += cfg.Bobot[k]
+    }
+    s.Skor = int(math.Round(total / bobot))
+    s.Grade = gradeSkor(s.Skor)
+    s.SkorKetepatan, s.SkorDenda, s.SkorSimpanan, s.SkorMasaKeanggotaan =
+        pembulatan(s.SkorKetepatan), pembulatan(s.SkorDenda), pembulatan(s.SkorSimpanan), pembulatan(s.SkorMasaKeanggotaan)
+    return s, nil
+}
+
+// simpanSkorKredit menyimpan skor ke riwayat; skor tanggal yang sama diperbarui
+func simpanSkorKredit(tx *gorm.DB, s *models.SkorKredit, sumber string) error {
+    var lama models.SkorKredit
+    err := tx.Where("anggota_id = ? AND tanggal = ?", s.AnggotaID, s.Tanggal).First(&lama).Error
+    switch {
+    case err == nil:
+        s.ID, s.CreatedAt = lama.ID, lama.CreatedAt
+    case !errors.Is(err, gorm.ErrRecordNotFound):
+        return err
+    }
+    s.Sumber = sumber
+    return tx.Save(s).Error
+}
+
+// skorKreditTerkini mengembalikan skor tersimpan terakhir, atau menghitungnya (tanpa menyimpan)
+// bila anggota belum pernah dinilai
+func skorKreditTerkini(tx *gorm.DB, a *models.Anggota) (*models.SkorKredit, error) {
+    var s models.SkorKredit
+    err := tx.Where("anggota_id = ?", a.ID).Order("tanggal DESC").First(&s).Error
+    if err == nil { return &s, nil }
+    if !errors.Is(err, gorm.ErrRecordNotFound) { return nil, err }
+    cfg, err := settings.LoadSkorKredit(tx)
+    if err != nil { return nil, err }
+    s, err = hitungSkorKredit(tx, a, cfg, time.Now())
+    return &s, err
+}
+
+// cekSkorPengajuan menghitung dan menyimpan skor anggota saat pengajuan pinjaman. Mengembalikan
+// errSkorKreditKurang bila skor di bawah settings.skor_kredit.minimal_pengajuan (skor tidak disimpan).
+func cekSkorPengajuan(tx *gorm.DB, a *models.Anggota) (*models.SkorKredit, error) {
+    cfg, err := settings.LoadSkorKredit(tx)
+    if err != nil { return nil, err }
+    if cfg.MinimalPengajuan == 0 { return nil, nil }
+    s, err := hitungSkorKredit(tx, a, cfg, time.Now())
+    if err != nil { return nil, err }
+    if s.Skor < cfg.MinimalPengajuan {
+        return &s, fmt.Errorf("%w: skor %d, minimal %d", errSkorKreditKurang, s.Skor, cfg.MinimalPengajuan)
+    }
+    return &s, simpanSkorKredit(tx, &s, "pengajuan")
+}
+
+// HasilSkorKredit merangkum satu kali proses skor kredit harian
+type HasilSkorKredit struct {
+    Diproses int `json:"diproses"`
+    Gagal    int `json:"gagal"`
+}
+
+// ProsesSkorKredit menghitung ulang dan menyimpan skor seluruh anggota aktif/nonaktif per tanggal now.
+// Aman diulang pada hari yang sama karena riwayat disimpan satu baris per anggota per tanggal.
+// Anggota yang gagal dihitung dicatat ke log dan dilewati agar anggota lain tetap dinilai.
+func (h *AnggotaController) ProsesSkorKredit(now time.Time) (HasilSkorKredit, error) {
+    var hasil HasilSkorKredit
+    cfg, err := settings.LoadSkorKredit(h.DB)
+    if err != nil { return hasil, err }
+    var list []models.Anggota
+    if err := h.DB.Where("status IN ?", statusDinilaiSkor).Order("id ASC").Find(&list).Error; err != nil { return hasil, err }
+    for i := range list {
+        err := h.DB.Transaction(func(tx *gorm.DB) error {
+            s, err := hitungSkorKredit(tx, &list[i], cfg, now)
+            if err != nil { return err }
+            return simpanSkorKredit(tx, &s, "harian")
+        })
+        if err != nil {
+            log.Printf("skor kredit: anggota %d gagal dihitung: %v", list[i].ID, err)
+            hasil.Gagal++
+            continue
+        }
+        hasil.Diproses++
+    }
+    return hasil, nil
+}
+
+// POST /api/anggota/skor-kredit/proses → sama dengan job harian, untuk dijalankan manual
+func (h *AnggotaController) ProsesSkor(c *gin.Context) {
+    hasil, err := h.ProsesSkorKredit(time.Now())
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "diproses": hasil.Diproses, "gagal": hasil.Gagal})
+        return
+    }
+    c.JSON(http.StatusOK, hasil)
+}
+
+// GET /api/anggota/:id/skor-kredit?limit=...
+// Skor terkini beserta riwayat skor terbaru lebih dulu
+func (h *AnggotaController) SkorKredit(c *gin.Context) {
+    limit, _ := strconv.Atoi(c.DefaultQuery("limit", "30"))
+    if limit < 1 || limit > 366 { limit = 30 }
+    var a models.Anggota
+    if err := h.DB.First(&a, c.Param("id")).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "anggota not found"})
+        return
+    }
+    terkini, err := skorKreditTerkini(h.DB, &a)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    var riwayat []models.SkorKredit
+    if err := h.DB.Where("anggota_id = ?", a.ID).Order("tanggal DESC").Limit(limit).Find(&riwayat).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"terkini": terkini, "riwayat": riwayat})
+}
+
+// POST /api/anggota/:id/skor-kredit → hitung ulang sekarang dan simpan ke riwayat
+func (h *AnggotaController) HitungSkorKredit(c *gin.Context) {
+    var s models.SkorKredit
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        var a models.Anggota
+        if err := tx.First(&a, c.Param("id")).Error; err != nil { return err }
+        cfg, err := settings.LoadSkorKredit(tx)
+        if err != nil { return err }
+        if s, err = hitungSkorKredit(tx, &a, cfg, time.Now()); err != nil { return err }
+        return simpanSkorKredit(tx, &s, "manual")
+    })
+    if err != nil {
+        if errors.Is(err, gorm.ErrRecordNotFound) {
+            c.JSON(http.StatusNotFound, gin.H{"error": "anggota not found"})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+    c.JSON(http.StatusOK, s)
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+    "fmt"
+    "testing"
+    "time"
+
+    "gorm.io/gorm"
+
+    "koperasi-desa/service/internal/models"
+    "koperasi-desa/service/internal/settings"
+)
+
+func TestKolektibilitas(t *testing.T) {
+    cases := []struct {
+        hari  int
+        ingin int
+    }{
+        {-3, 1}, {0, 1}, {1, 2}, {90, 2}, {91, 3}, {120, 3}, {121, 4}, {180, 4}, {181, 5}, {720, 5},
+    }
+    for _, c := range cases {
+        if got := kolektibilitas(c.hari); got != c.ingin { t.Errorf("kolektibilitas(%d) = %d, ingin %d", c.hari, got, c.ingin) }
+    }
+}
+
+func TestHitungSkorKredit(t *testing.T) {
+    cfg := settings.SkorKredit{
+        Bobot:                map[string]float64{"ketepatan": 35, "denda": 10, "kolektibilitas": 25, "simpanan": 20, "masa_keanggotaan": 10},
+        NilaiTanpaRiwayat:    60,
+        BatasDenda:           500_000,
+        PeriodeSimpananBulan: 12,
+        MasaPenuhBulan:       60,
+    }
+    now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local)
+    tgl := func(th int, bl time.Month, hr int) time.Time { return time.Date(th, bl, hr, 0, 0, 0, 0, time.Local) }
+    ptr := func(t time.Time) *time.Time { return &t }
+    uintPtr := func(v uint) *uint { return &v }
+
+    angsuran := func(t *testing.T, db *gorm.DB, status string, list ...models.Angsuran) {
+        var n int64
+        db.Model(&models.Pinjaman{}).Count(&n)
+        p := models.Pinjaman{AnggotaID: 1, NomorPinjaman: fmt.Sprintf("PJ-%d", n+1), Nominal: 1_200_000, TenorBulan: 12, Status: status}
+        if err := db.Create(&p).Error; err != nil { t.Fatal(err) }
+        for i := range list {
+            list[i].PinjamanID = p.ID
+            list[i].Ke = i + 1
+            list[i].Jumlah = 110_000
+            if err := db.Create(&list[i]).Error; err != nil { t.Fatal(err) }
+        }
+    }
+    setoranWajib := func(t *testing.T, db *gorm.DB, tanggal time.Time, dikoreksi *uint) {
+        r := models.Simpanan{AnggotaID: 1, Jenis: "wajib", Tipe: "setoran", Tanggal: tanggal, Jumlah: 10_000, DikoreksiOlehID: dikoreksi}
+        if err := db.Create(&r).Error; err != nil { t.Fatal(err) }
+    }
+
+    type ingin struct {
+        ketepatan, denda, kolek, simpanan, masa float64
+        hariTunggakan, kolektibilitas          int
+        skor                                   int
+        grade                                  string
+    }
+    cases := []struct {
+        nama   string
+        gabung time.Time
+        isi    func(t *testing.T, db *gorm.DB)
+        ingin  ingin
+    }{
+        {"anggota baru tanpa riwayat", tgl(2026, 10, 1), func(t *testing.T, db *gorm.DB) {},
+            ingin{60, 60, 100, 60, 0, 0, 1, 64, "C"}},
+        {"lancar penuh", tgl(2020, 1, 15), func(t *testing.T, db *gorm.DB) {
+            angsuran(t, db, "berjalan",
+                models.Angsuran{TanggalJatuhTempo: tgl(2026, 7, 10), TanggalBayar: ptr(tgl(2026, 7, 10))},
+                models.Angsuran{TanggalJatuhTempo: tgl(2026, 8, 10), TanggalBayar: ptr(tgl(2026, 8, 9))},
+                models.Angsuran{TanggalJatuhTempo: tgl(2026, 9, 10), TanggalBayar: ptr(tgl(2026, 9, 10))},
+                models.Angsuran{TanggalJatuhTempo: tgl(2026, 10, 25)})
+            for m := 0; m < 12; m++ { setoranWajib(t, db, tgl(2025, 10, 5).AddDate(0, m, 0), nil) }
+        }, ingin{100, 100, 100, 100, 100, 0, 1, 100, "A"}},
+        {"menunggak dan simpanan bolong", tgl(2024, 10, 19), func(t *testing.T, db *gorm.DB) {
+            angsuran(t, db, "berjalan",
+                models.Angsuran{TanggalJatuhTempo: tgl(2026, 5, 10), TanggalBayar: ptr(tgl(2026, 5, 20)), Denda: 10_000},
+                models.Angsuran{TanggalJatuhTempo: tgl(2026, 6, 10)})
+            // pinjaman yang belum dicairkan tidak dinilai
+            angsuran(t, db, "disetujui", models.Angsuran{TanggalJatuhTempo: tgl(2026, 1, 10)})
+            for m := 0; m < 6; m++ { setoranWajib(t, db, tgl(2026, 4, 5).AddDate(0, m, 0), nil) }
+            setoranWajib(t, db, tgl(2025, 12, 5), uintPtr(99)) // dikoreksi, tidak dihitung
+            setoranWajib(t, db, tgl(2026, 10, 5), nil)         // bulan berjalan, tidak dihitung
+        }, ingin{0, 98, 20, 50, 40, 131, 4, 29, "E"}},
+    }
+    for _, c := range cases {
+        t.Run(c.nama, func(t *testing.T) {
+            db := dbUji(t)
+            c.isi(t, db)
+            a := models.Anggota{ID: 1, TanggalGabung: c.gabung}
+            s, err := hitungSkorKredit(db, &a, cfg, now)
+            if err != nil { t.Fatal(err) }
+            got := ingin{s.SkorKetepatan, s.SkorDenda, s.SkorKolektibilitas, s.SkorSimpanan, s.SkorMasaKeanggotaan, s.HariTunggakan, s.Kolektibilitas, s.Skor, s.Grade}
+            if got != c.ingin { t.Errorf("skor = %+v, ingin %+v", got, c.ingin) }
+        })
+    }
+}
@@ -0,0 +1,265 @@
+package controllers
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "math"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+
+    "koperasi-desa/service/internal/models"
+)
+
+var (
+    errTahunSudahDitutup = errors.New("tahun buku sudah ditutup")
+    errPraTutupBuku      = errors.New("pemeriksaan tutup buku belum terpenuhi")
+)
+
+type TutupBukuController struct { DB *gorm.DB }
+func NewTutupBukuController(db *gorm.DB) *TutupBukuController { return &TutupBukuController{DB: db} }
+
+// PemeriksaanTutupBuku adalah hasil satu pemeriksaan sebelum tutup buku
+type PemeriksaanTutupBuku struct {
+    Kode  string `json:"kode"`
+    Lolos bool   `json:"lolos"`
+    Pesan string `json:"pesan"`
+}
+
+// LaporanTutupBuku adalah snapshot laporan yang dibekukan saat tutup buku
+type LaporanTutupBuku struct {
+    Tahun           int         `json:"tahun"`
+    Pendapatan      []SaldoAkun `json:"pendapatan"`
+    Beban           []SaldoAkun `json:"beban"`
+    TotalPendapatan float64     `json:"total_pendapatan"`
+    TotalBeban      float64     `json:"total_beban"`
+    SHU             float64     `json:"shu"`
+    NeracaSaldo     []SaldoAkun `json:"neraca_saldo"`
+}
+
+func batasTahun(tahun int) (time.Time, time.Time) {
+    awal := time.Date(tahun, 1, 1, 0, 0, 0, 0, time.Local)
+    return awal, awal.AddDate(1, 0, 0)
+}
+
+// tahunDitutup mengembalikan true jika tahun buku sudah ditutup
+func tahunDitutup(tx *gorm.DB, tahun int) (bool, error) {
+    var n int64
+    if err := tx.Model(&models.TutupBuku{}).Where("tahun = ?", tahun).Count(&n).Error; err != nil { return false, err }
+    return n > 0, nil
+}
+
+// periksaTutupBuku menjalankan seluruh pemeriksaan sebelum tutup buku
+func periksaTutupBuku(tx *gorm.DB, tahun int) ([]PemeriksaanTutupBuku, error) {
+    awal, akhir := batasTahun(tahun)
+    hasil := []PemeriksaanTutupBuku{}
+
+    // Tahun sebelumnya harus sudah ditutup jika memiliki transaksi
+    var lama int64
+    if err := tx.Model(&models.Jurnal{}).Where("tanggal < ?", awal).Count(&lama).Error; err != nil { return nil, err }
+    sebelum, err := tahunDitutup(tx, tahun-1)
+    if err != nil { return nil, err }
+    p := PemeriksaanTutupBuku{Kode: "tahun_sebelumnya", Lolos: lama == 0 || sebelum, Pesan: "tahun buku sebelumnya sudah ditutup"}
+    if !p.Lolos { p.Pesan = fmt.Sprintf("tahun buku %d belum ditutup", tahun-1) }
+    hasil = append(hasil, p)
+
+    // Kas dianggap sudah direkonsiliasi jika seluruh periode bulanan sudah ditutup
+    var bulanTutup int64
+    if err := tx.Model(&models.PeriodeAkuntansi{}).Where("tahun = ? AND status = ?", tahun, "ditutup").Count(&bulanTutup).Error; err != nil { return nil, err }
+    p = PemeriksaanTutupBuku{Kode: "rekonsiliasi_kas", Lolos: bulanTutup == 12, Pesan: "seluruh periode bulanan sudah direkonsiliasi dan ditutup"}
+    if !p.Lolos { p.Pesan = fmt.Sprintf("baru %d dari 12 periode bulanan yang ditutup", bulanTutup) }
+    hasil = append(hasil, p)
+
+    // Koreksi yang masih menunggu adalah transaksi yang belum terposting final
+    var koreksi int64
+    if err := tx.Model(&models.Koreksi{}).Where("status = ?", "menunggu").Count(&koreksi).Error; err != nil { return nil, err }
+    p = PemeriksaanTutupBuku{Kode: "koreksi_menunggu", Lolos: koreksi == 0, Pesan: "tidak ada koreksi yang menunggu keputusan"}
+    if !p.Lolos { p.Pesan = fmt.Sprintf("%d koreksi masih menunggu keputusan", koreksi) }
+    hasil = append(hasil, p)
+
+    // Simpanan berjangka jatuh tempo dalam tahun ini yang belum diproses (penarikan tertunda)
+    var berjangka int64
+    if err := tx.Model(&models.SimpananBerjangka{}).Where("status = ? AND tanggal_jatuh_tempo < ?", "aktif", akhir).Count(&berjangka).Error; err != nil { return nil, err }
+    p = PemeriksaanTutupBuku{Kode: "penarikan_tertunda", Lolos: berjangka == 0, Pesan: "tidak ada simpanan berjangka jatuh tempo yang belum diproses"}
+    if !p.Lolos { p.Pesan = fmt.Sprintf("%d simpanan berjangka jatuh tempo belum diproses", berjangka) }
+    hasil = append(hasil, p)
+
+    // Saldo akun simpanan dan piutang pinjaman di buku besar harus sama dengan buku pembantunya
+    selisih, err := rekonsiliasiBukuPembantu(tx, akhir)
+    if err != nil { return nil, err }
+    p = PemeriksaanTutupBuku{Kode: "rekonsiliasi_buku_pembantu", Lolos: len(selisih) == 0, Pesan: "saldo simpanan dan piutang pinjaman di buku besar sesuai buku pembantu"}
+    if !p.Lolos { p.Pesan = "tidak sesuai buku pembantu: " + strings.Join(selisih, "; ") }
+    hasil = append(hasil, p)
+    return hasil, nil
+}
+
+// rekonsiliasiBukuPembantu membandingkan saldo buku besar per akhir dengan buku pembantu: akun simpanan per
+// jenis terhadap total saldo simpanan anggota, dan piutang pinjaman terhadap pokok angsuran yang belum
+// dibayar dari pinjaman yang sudah dicairkan. Mengembalikan keterangan akun yang berselisih.
+func rekonsiliasiBukuPembantu(tx *gorm.DB, akhir time.Time) ([]string, error) {
+    saldoAkun, err := hitungSaldoAkun(tx, time.Time{}, akhir)
+    if err != nil { return nil, err }
+    besar := map[string]float64{}
+    for _, a := range saldoAkun { besar[a.Kode] = a.Saldo }
+    pembantu := map[string]float64{}
+
+    var simpanan []models.Simpanan
+    if err := tx.Select("jenis", "tipe", "jumlah").Where("tanggal < ?", akhir).Find(&simpanan).Error; err != nil { return nil, err }
+    for _, r := range simpanan { pembantu[akunSimpanan(r.Jenis)] += saldoDelta(r.Tipe, r.Jumlah) }
+
+    var pinjaman []models.Pinjaman
+    if err := tx.Where("tanggal_pencairan < ?", akhir).Find(&pinjaman).Error; err != nil { return nil, err }
+    for i := range pinjaman {
+        var dibayar []models.Angsuran
+        if err := tx.Scopes(angsuranBerlaku).Where("pinjaman_id = ? AND tanggal_bayar < ?", pinjaman[i].ID, akhir).Find(&dibayar).Error; err != nil { return nil, err }
+        sisa := pinjaman[i].Nominal + pinjaman[i].BiayaDiangsur
+        for j := range dibayar { sisa -= pokokAngsuran(&pinjaman[i], &dibayar[j]) }
+        pembantu[models.AkunPiutangPinjaman] += sisa
+    }
+
+    var selisih []string
+    for _, kode := range []string{models.AkunSimpananPokok, models.AkunSimpananWajib, models.AkunSimpananSukarela, models.AkunSimpananKhusus, models.AkunPiutangPinjaman} {
+        if math.Abs(besar[kode]-pembantu[kode]) >= 1 {
+            selisih = append(selisih, fmt.Sprintf("akun %s buku besar %.2f, buku pembantu %.2f", kode, besar[kode], pembantu[kode]))
+        }
+    }
+    return selisih, nil
+}
+
+// GET /api/tutup-buku
+func (h *TutupBukuController) ListTutupBuku(c *gin.Context) {
+    var list []models.TutupBuku
+    if err := h.DB.Order("tahun DESC").Find(&list).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": list})
+}
+
+// GET /api/tutup-buku/:tahun/cek
+func (h *TutupBukuController) CekTutupBuku(c *gin.Context) {
+    tahun, err := strconv.Atoi(c.Param("tahun"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "tahun tidak valid"})
+        return
+    }
+    hasil, err := periksaTutupBuku(h.DB, tahun)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    siap := true
+    for _, p := range hasil { siap = siap && p.Lolos }
+    ditutup, err := tahunDitutup(h.DB, tahun)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"tahun": tahun, "ditutup": ditutup, "siap": siap && !ditutup, "pemeriksaan": hasil})
+}
+
+// GET /api/tutup-buku/:tahun
+// Laporan tahun tertutup diambil dari snapshot sehingga angkanya tetap seperti saat ditutup.
+func (h *TutupBukuController) GetTutupBuku(c *gin.Context) {
+    var tb models.TutupBuku
+    if err := h.DB.Where("tahun = ?", c.Param("tahun")).First(&tb).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "tahun buku belum ditutup"})
+        return
+    }
+    var lap LaporanTutupBuku
+    if err := json.Unmarshal([]byte(tb.Laporan), &lap); err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": tb, "laporan": lap})
+}
+
+// POST /api/tutup-buku { tahun, user_id }
+type TutupBukuInput struct {
+    Tahun  int  `json:"tahun" binding:"required"`
+    UserID uint `json:"user_id" binding:"required"`
+}
+
+// TutupBuku menutup akun pendapatan dan beban ke SHU tahun berjalan per 31 Desember,
+// memindahkan SHU ke SHU belum dibagi per 1 Januari tahun berikutnya, lalu mengunci tahun tersebut.
+func (h *TutupBukuController) TutupBuku(c *gin.Context) {
+    var in TutupBukuInput
+    if err := c.ShouldBindJSON(&in); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    var tb models.TutupBuku
+    var lap LaporanTutupBuku
+    var gagal []PemeriksaanTutupBuku
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        u, err := cariPengguna(tx, in.UserID, rolePengelolaPeriode...)
+        if err != nil { return err }
+        ditutup, err := tahunDitutup(tx, in.Tahun)
+        if err != nil { return err }
+        if ditutup { return errTahunSudahDitutup }
+        if err := PostingSaldoAwal(tx); err != nil { return err }
+        hasil, err := periksaTutupBuku(tx, in.Tahun)
+        if err != nil { return err }
+        for _, p := range hasil {
+            if !p.Lolos { gagal = append(gagal, p) }
+        }
+        if len(gagal) > 0 { return errPraTutupBuku }
+
+        awal, akhir := batasTahun(in.Tahun)
+        saldo, err := hitungSaldoAkun(tx, awal, akhir)
+        if err != nil { return err }
+        lap = LaporanTutupBuku{Tahun: in.Tahun, Pendapatan: []SaldoAkun{}, Beban: []SaldoAkun{}}
+        var lines []barisJurnal
+        for _, s := range saldo {
+            switch s.Tipe {
+            case "pendapatan":
+                lap.Pendapatan = append(lap.Pendapatan, s)
+                lap.TotalPendapatan += s.Saldo
+                lines = append(lines, barisJurnal{Akun: s.Kode, Debit: math.Max(s.Saldo, 0), Kredit: math.Max(-s.Saldo, 0)})
+            case "beban":
+                lap.Beban = append(lap.Beban, s)
+                lap.TotalBeban += s.Saldo
+                lines = append(lines, barisJurnal{Akun: s.Kode, Debit: math.Max(-s.Saldo, 0), Kredit: math.Max(s.Saldo, 0)})
+            }
+        }
+        lap.SHU = lap.TotalPendapatan - lap.TotalBeban
+        lines = append(lines, barisJurnal{Akun: models.AkunSHUTahunBerjalan, Debit: math.Max(-lap.SHU, 0), Kredit: math.Max(lap.SHU, 0)})
+
+        // Jurnal penutup dan pemindahan saldo tidak melalui cek periode karena bulan-bulannya sudah ditutup
+        penutup, err := simpanJurnal(tx, akhir.Add(-time.Second), "tutup_buku", uint(in.Tahun), fmt.Sprintf("Jurnal penutup tahun buku %d", in.Tahun), lines...)
+        if err != nil { return err }
+        if _, err := simpanJurnal(tx, akhir, "tutup_buku", uint(in.Tahun), fmt.Sprintf("Pemindahan SHU tahun buku %d", in.Tahun),
+            barisJurnal{Akun: models.AkunSHUTahunBerjalan, Debit: math.Max(lap.SHU, 0), Kredit: math.Max(-lap.SHU, 0)},
+            barisJurnal{Akun: models.AkunSHUBelumDibagi, Debit: math.Max(-lap.SHU, 0), Kredit: math.Max(lap.SHU, 0)}); err != nil {
+            return err
+        }
+
+        lap.NeracaSaldo, err = hitungSaldoAkun(tx, time.Time{}, akhir)
+        if err != nil { return err }
+        b, err := json.Marshal(lap)
+        if err != nil { return err }
+        tb = models.TutupBuku{Tahun: in.Tahun, SHU: lap.SHU, JurnalID: penutup.ID, DitutupOleh: u.ID, DitutupAt: time.Now(), Laporan: string(b)}
+        if err := tx.Create(&tb).Error; err != nil { return err }
+        return catatAudit(tx, u.ID, "tutup_buku", "tutup_buku", tb.ID, fmt.Sprintf("tahun %d, SHU %.2f", in.Tahun, lap.SHU))
+    })
+    if err != nil {
+        switch {
+        case errors.Is(err, errAksesDitolak):
+            c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+        case errors.Is(err, errTahunSudahDitutup):
+            c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+        case errors.Is(err, errPraTutupBuku):
+            c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "pemeriksaan": gagal})
+        default:
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+    c.JSON(http.StatusCreated, gin.H{"data": tb, "laporan": lap})
+}
@@ -4,6 +4,8 @@ import (
     "fmt"
     "log"
     "os"
+    "regexp"
+    "strconv"
 
     "gorm.io/driver/mysql"
     "gorm.io/driver/sqlite"
@@ -37,18 +39,128 @@ func InitDB() *gorm.DB {
         &models.User{},
         &models.Anggota{},
         &models.AnggotaDocument{},
+        &models.DokumenAkses{},
         &models.AnggotaActivity{},
         &models.Simpanan{},
+        &models.SimpananBerjangka{},
         &models.Pinjaman{},
         &models.Angsuran{},
+        &models.Penjamin{},
+        &models.Agunan{},
+        &models.TahapPersetujuan{},
+        &models.KeputusanPersetujuan{},
+        &models.AnalisisKredit{},
+        &models.BiayaPinjaman{},
+        &models.MandatAutoDebit{},
+        &models.AutoDebitLog{},
+        &models.SesiKasir{},
+        &models.TransaksiKasir{},
+        &models.HitunganKasir{},
+        &models.SkorKredit{},
         &models.Setting{},
+        &models.SettingHistory{},
+        &models.AuditLog{},
+        &models.Koreksi{},
+        &models.PeriodeAkuntansi{},
+        &models.Akun{},
+        &models.Jurnal{},
+        &models.JurnalDetail{},
+        &models.Kas{},
+        &models.TutupBuku{},
+        &models.PenyelesaianKeluar{},
+        &models.AhliWaris{},
+        &models.PembagianWaris{},
+        &models.NomorUrut{},
     ); err != nil {
         log.Fatalf("failed to migrate: %v", err)
     }
 
+    // Isi nik_aktif untuk anggota lama yang belum keluar; NIK ganda dibiarkan NULL untuk diperiksa manual
+    if err := db.Exec("UPDATE anggota SET nik_aktif = nik WHERE nik_aktif IS NULL AND nik <> '' AND status NOT IN ('ditolak','keluar','meninggal') AND nik IN (SELECT nik FROM (SELECT nik FROM anggota GROUP BY nik HAVING COUNT(*) = 1) t)").Error; err != nil {
+        log.Printf("failed to backfill nik_aktif: %v", err)
+    }
+
+    // Dokumen lama tersimpan di uploads/<path> dengan url /uploads/<path>; pakai path itu sebagai key storage lokal
+    if db.Migrator().HasColumn(&models.AnggotaDocument{}, "url") {
+        if err := db.Exec("UPDATE anggota_documents SET storage_key = SUBSTR(url, 10) WHERE (storage_key IS NULL OR storage_key = '') AND url LIKE '/uploads/%'").Error; err != nil {
+            log.Printf("failed to backfill storage_key: %v", err)
+        }
+    }
+
+    // Jenis dokumen lama "foto" sekarang bernama pas_foto
+    if err := db.Model(&models.AnggotaDocument{}).Where("jenis = ?", "foto").Update("jenis", "pas_foto").Error; err != nil {
+        log.Printf("failed to rename jenis dokumen foto: %v", err)
+    }
+
+    // Pinjaman yang cair sebelum ada biaya pinjaman diterima utuh
+    if err := db.Model(&models.Pinjaman{}).Where("tanggal_pencairan IS NOT NULL AND pencairan_bersih IS NULL").
+        Updates(map[string]any{"pencairan_bersih": gorm.Expr("nominal"), "biaya_potongan": 0, "biaya_diangsur": 0}).Error; err != nil {
+        log.Printf("failed to backfill pencairan_bersih: %v", err)
+    }
+
+    // Tandai sumber transaksi simpanan lama agar koreksi hanya berlaku untuk transaksi loket.
+    // Urutan penting: transaksi sistem dikenali lebih dulu, sisa setoran/penarikan dianggap transaksi loket.
+    tanpaSumber := func() *gorm.DB { return db.Model(&models.Simpanan{}).Where("sumber IS NULL OR sumber = ''") }
+    sumberLama := []struct {
+        sumber string
+        where  func(*gorm.DB) *gorm.DB
+    }{
+        {"bunga", func(q *gorm.DB) *gorm.DB { return q.Where("tipe IN ?", []string{"bunga", "pajak_bunga"}) }},
+        {"koreksi", func(q *gorm.DB) *gorm.DB { return q.Where("koreksi_dari_id IS NOT NULL") }},
+        {"auto_debit", func(q *gorm.DB) *gorm.DB {
+            return q.Where("nomor_bukti <> '' AND nomor_bukti IN (?)", db.Model(&models.AutoDebitLog{}).Select("nomor_bukti"))
+        }},
+        {"pencairan", func(q *gorm.DB) *gorm.DB {
+            return q.Where("nomor_bukti <> '' AND nomor_bukti IN (?)", db.Model(&models.Pinjaman{}).Select("nomor_bukti_pencairan"))
+        }},
+        {"berjangka", func(q *gorm.DB) *gorm.DB { return q.Where("keterangan LIKE ?", "%simpanan berjangka%") }},
+        {"penyelesaian", func(q *gorm.DB) *gorm.DB { return q.Where("keterangan LIKE ?", "Pengembalian simpanan %") }},
+        {"loket", func(q *gorm.DB) *gorm.DB { return q.Where("tipe IN ?", []string{"setoran", "penarikan"}) }},
+    }
+    for _, s := range sumberLama {
+        if err := s.where(tanpaSumber()).Update("sumber", s.sumber).Error; err != nil {
+            log.Printf("failed to backfill sumber simpanan %s: %v", s.sumber, err)
+        }
+    }
+
+    // Seed bagan akun default
+    for _, a := range models.BaganAkunDefault {
+        if err := db.Where(models.Akun{Kode: a.Kode}).Attrs(a).FirstOrCreate(&models.Akun{}).Error; err != nil {
+            log.Fatalf("failed to seed akun: %v", err)
+        }
+    }
+
+    // Lanjutkan nomor urut dari nomor lama berformat PJ-YYYY-NNNNNN / SB-YYYY-NNNNNN
+    seedNomorUrut(db, "pinjaman", &models.Pinjaman{}, "nomor_pinjaman")
+    seedNomorUrut(db, "simpanan_berjangka", &models.SimpananBerjangka{}, "nomor_bilyet")
+
     return db
 }
 
+var nomorLama = regexp.MustCompile(`^[A-Z]+-(\d{4})-(\d+)$`)
+
+// seedNomorUrut membuat baris NomorUrut tahunan dari nomor terbesar yang sudah ada,
+// agar nomor baru tidak bentrok dengan nomor yang dibuat sebelum penomoran otomatis.
+func seedNomorUrut(db *gorm.DB, jenis string, model interface{}, kolom string) {
+    var nomor []string
+    if err := db.Model(model).Pluck(kolom, &nomor).Error; err != nil {
+        log.Printf("failed to read %s: %v", kolom, err)
+        return
+    }
+    maks := map[string]int64{}
+    for _, n := range nomor {
+        m := nomorLama.FindStringSubmatch(n)
+        if m == nil { continue }
+        v, _ := strconv.ParseInt(m[2], 10, 64)
+        if v > maks[m[1]] { maks[m[1]] = v }
+    }
+    for tahun, v := range maks {
+        if err := db.Where(models.NomorUrut{Jenis: jenis, Periode: tahun}).Attrs(models.NomorUrut{Nilai: v}).FirstOrCreate(&models.NomorUrut{}).Error; err != nil {
+            log.Printf("failed to seed nomor urut %s: %v", jenis, err)
+        }
+    }
+}
+
 func getenv(key, def string) string {
     v := os.Getenv(key)
     if v == "" {
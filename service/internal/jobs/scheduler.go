@@ -0,0 +1,95 @@
+package jobs
+
+import (
+    "errors"
+    "log"
+    "os"
+    "strconv"
+    "time"
+
+    "gorm.io/gorm"
+
+    "koperasi-desa/service/internal/controllers"
+)
+
+// Job adalah tugas harian yang dijalankan scheduler.
+// Run harus aman diulang (idempotent) karena bisa dipanggil lagi setelah service restart.
+type Job struct {
+    Name string
+    Run  func(now time.Time) error
+}
+
+// Start menjalankan job harian di background setelah jam JOBS_HOUR (default 01).
+// Set JOBS_DISABLED=true untuk menonaktifkan, misalnya saat menjalankan beberapa instance.
+func Start(db *gorm.DB) {
+    if os.Getenv("JOBS_DISABLED") == "true" {
+        log.Printf("scheduler: dinonaktifkan lewat JOBS_DISABLED")
+        return
+    }
+    hour, err := strconv.Atoi(os.Getenv("JOBS_HOUR"))
+    if err != nil || hour < 0 || hour > 23 { hour = 1 }
+
+    sc := controllers.NewSimpananController(db)
+    sbc := controllers.NewSimpananBerjangkaController(db)
+    ac := controllers.NewAnggotaController(db, nil)
+    pc := controllers.NewPinjamanController(db, nil)
+    list := []Job{
+        {
+            // Kredit bunga bulan sebelumnya; proses melewati anggota yang sudah dikredit.
+            // Dihitung dari tanggal 1 agar tanggal 29-31 tidak bergeser ke bulan berjalan.
+            // Bulan yang sudah ditutup berarti tidak ada lagi yang perlu dikredit.
+            Name: "bunga-simpanan",
+            Run: func(now time.Time) error {
+                bulanLalu := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).AddDate(0, -1, 0)
+                _, err := sc.ProsesBunga(bulanLalu, "", false)
+                if errors.Is(err, controllers.ErrBungaBelumDiatur) || errors.Is(err, controllers.ErrPeriodeTertutup) { return nil }
+                return err
+            },
+        },
+        {
+            Name: "jatuh-tempo-simpanan-berjangka",
+            Run: func(now time.Time) error {
+                _, err := sbc.ProsesJatuhTempo(now)
+                return err
+            },
+        },
+        {
+            // Sebelum skor kredit agar angsuran yang terdebit tidak dihitung menunggak
+            Name: "auto-debit-angsuran",
+            Run: func(now time.Time) error {
+                _, err := pc.ProsesAutoDebit(now)
+                return err
+            },
+        },
+        {
+            Name: "skor-kredit",
+            Run: func(now time.Time) error {
+                _, err := ac.ProsesSkorKredit(now)
+                return err
+            },
+        },
+    }
+    go loop(list, hour)
+}
+
+func loop(list []Job, hour int) {
+    lastRun := map[string]string{}
+    ticker := time.NewTicker(15 * time.Minute)
+    defer ticker.Stop()
+    for {
+        now := time.Now()
+        today := now.Format("2006-01-02")
+        if now.Hour() >= hour {
+            for _, j := range list {
+                if lastRun[j.Name] == today { continue }
+                if err := j.Run(now); err != nil {
+                    log.Printf("scheduler: job %s gagal: %v", j.Name, err)
+                } else {
+                    log.Printf("scheduler: job %s selesai", j.Name)
+                }
+                lastRun[j.Name] = today
+            }
+        }
+        <-ticker.C
+    }
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// AhliWaris adalah penerima manfaat simpanan anggota jika anggota meninggal.
+// Total PersenBagian seluruh ahli waris satu anggota harus 100.
+type AhliWaris struct {
+    ID           uint      `gorm:"primaryKey" json:"id"`
+    AnggotaID    uint      `gorm:"index" json:"anggota_id"`
+    Nama         string    `gorm:"size:128" json:"nama"`
+    Hubungan     string    `gorm:"size:32" json:"hubungan"`
+    NIK          string    `gorm:"size:32" json:"nik"`
+    Telp         string    `gorm:"size:32" json:"telp"`
+    PersenBagian float64   `json:"persen_bagian"`
+    CreatedAt    time.Time `json:"created_at"`
+    UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// PembagianWaris adalah bagian satu ahli waris dari penyelesaian anggota meninggal.
+// Nama dan persen disalin agar tidak berubah jika data ahli waris diperbarui.
+type PembagianWaris struct {
+    ID             uint      `gorm:"primaryKey" json:"id"`
+    PenyelesaianID uint      `gorm:"index" json:"penyelesaian_id"`
+    AhliWarisID    uint      `json:"ahli_waris_id"`
+    Nama           string    `gorm:"size:128" json:"nama"`
+    NIK            string    `gorm:"size:32" json:"nik"`
+    Hubungan       string    `gorm:"size:32" json:"hubungan"`
+    PersenBagian   float64   `json:"persen_bagian"`
+    Jumlah         float64   `json:"jumlah"`
+    CreatedAt      time.Time `json:"created_at"`
+}
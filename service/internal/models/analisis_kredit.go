@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// AnalisisKredit adalah lembar analisis 5C satu pengajuan pinjaman. Data penghasilan dan skor diisi
+// analis; riwayat angsuran dan simpanan diambil otomatis saat analisis disimpan sehingga penyetuju
+// melihat kondisi pada saat analisis dibuat.
+// Rekomendasi: layak | layak_bersyarat | tidak_layak
+type AnalisisKredit struct {
+    ID                   uint       `gorm:"primaryKey" json:"id"`
+    PinjamanID           uint       `gorm:"uniqueIndex" json:"pinjaman_id"`
+    AnalisOleh           uint       `json:"analis_oleh"`
+
+    PenghasilanBulanan   float64    `json:"penghasilan_bulanan"`
+    PenghasilanLain      float64    `json:"penghasilan_lain"`
+    PengeluaranBulanan   float64    `json:"pengeluaran_bulanan"`
+    KewajibanLain        float64    `json:"kewajiban_lain"` // cicilan di luar koperasi per bulan
+
+    // dihitung
+    AngsuranDiajukan     float64    `json:"angsuran_diajukan"`
+    AngsuranBerjalan     float64    `json:"angsuran_berjalan"` // angsuran bulanan pinjaman lain di koperasi
+    SisaPenghasilan      float64    `json:"sisa_penghasilan"`  // penghasilan - pengeluaran - kewajiban lain - angsuran berjalan
+    RasioAngsuranPersen  float64    `json:"rasio_angsuran_persen"` // seluruh angsuran / penghasilan (DSR)
+    RasioKapasitasPersen float64    `json:"rasio_kapasitas_persen"` // angsuran diajukan / sisa penghasilan (RPC)
+
+    SkorCharacter        int        `json:"skor_character"` // 1-5
+    SkorCapacity         int        `json:"skor_capacity"`
+    SkorCapital          int        `json:"skor_capital"`
+    SkorCollateral       int        `json:"skor_collateral"`
+    SkorCondition        int        `json:"skor_condition"`
+    Catatan              string     `gorm:"type:text" json:"catatan"`
+
+    // riwayat otomatis
+    PinjamanSebelumnya   int        `json:"pinjaman_sebelumnya"`
+    AngsuranTepatWaktu   int        `json:"angsuran_tepat_waktu"`
+    AngsuranTerlambat    int        `json:"angsuran_terlambat"` // dibayar lewat jatuh tempo atau menunggak
+    RasioTepatWaktu      *float64   `json:"rasio_tepat_waktu"`  // persen; null jika belum ada riwayat
+    SaldoSimpanan        float64    `json:"saldo_simpanan"`     // pokok + wajib + sukarela + khusus
+    SimpananBerjangka    float64    `json:"simpanan_berjangka"` // nominal bilyet aktif
+
+    SkorTotal            float64    `json:"skor_total"` // rata-rata berbobot 1-5
+    Rekomendasi          string     `gorm:"size:32" json:"rekomendasi"`
+    AlasanRekomendasi    string     `gorm:"type:text" json:"alasan_rekomendasi"`
+    CreatedAt            time.Time  `json:"created_at"`
+    UpdatedAt            time.Time  `json:"updated_at"`
+}
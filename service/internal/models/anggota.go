@@ -3,27 +3,79 @@ package models
 import "time"
 
 // Anggota merepresentasikan member koperasi
+// Status: pending | verified | active | nonaktif | ditolak | keluar | meninggal
 type Anggota struct {
-    ID            uint      `gorm:"primaryKey" json:"id"`
-    NomorAnggota  string    `gorm:"size:64;uniqueIndex" json:"nomor_anggota"`
-    Nama          string    `gorm:"size:128" json:"nama"`
-    NIK           string    `gorm:"size:32" json:"nik"`
-    Alamat        string    `gorm:"size:255" json:"alamat"`
-    Telp          string    `gorm:"size:32" json:"telp"`
-    Status        string    `gorm:"size:32" json:"status"`
-    TanggalGabung time.Time `json:"tanggal_gabung"`
-    CreatedAt     time.Time `json:"created_at"`
-    UpdatedAt     time.Time `json:"updated_at"`
+    ID            uint       `gorm:"primaryKey" json:"id"`
+    NomorAnggota  string     `gorm:"size:64;uniqueIndex" json:"nomor_anggota"`
+    Nama          string     `gorm:"size:128" json:"nama"`
+    NIK           string     `gorm:"size:32;index" json:"nik"`
+    // NIKAktif berisi NIK selama anggota belum ditolak/keluar/meninggal (NULL setelahnya),
+    // sehingga unique index hanya berlaku untuk anggota yang masih tercatat.
+    NIKAktif      *string    `gorm:"size:32;uniqueIndex" json:"-"`
+    TanggalLahir  *time.Time `json:"tanggal_lahir"`
+    JenisKelamin  string     `gorm:"size:1" json:"jenis_kelamin"`
+    Alamat        string     `gorm:"size:255" json:"alamat"`
+    Telp          string     `gorm:"size:32" json:"telp"`
+    Status        string     `gorm:"size:32" json:"status"`
+    TanggalGabung time.Time  `json:"tanggal_gabung"`
+    TanggalKeluar *time.Time `json:"tanggal_keluar"`
+    CreatedAt     time.Time  `json:"created_at"`
+    UpdatedAt     time.Time  `json:"updated_at"`
     Documents     []AnggotaDocument `json:"documents"`
 }
 
-// AnggotaDocument menyimpan metadata dokumen anggota (KTP, KK, dll)
+// JenisDokumen adalah jenis dokumen anggota yang dapat diunggah, termasuk dokumen pengajuan pinjaman
+var JenisDokumen = []string{"ktp", "kk", "pas_foto", "slip_gaji", "surat_pernyataan",
+    "surat_permohonan", "ktp_penjamin", "surat_persetujuan_penjamin", "bukti_agunan", "foto_agunan"}
+
+// AnggotaDocument menyimpan metadata dokumen anggota (KTP, KK, dll).
+// Berkas disimpan di storage dengan nama acak (StorageKey); Filename hanya nama asli untuk ditampilkan.
+// Penggantian membuat baris baru dengan Versi berikutnya; baris lama diberi DigantiOlehID.
+// Penghapusan hanya menandai DihapusAt, berkas tetap disimpan. Dokumen yang berlaku adalah
+// yang belum diganti dan belum dihapus.
 type AnggotaDocument struct {
+    ID          uint      `gorm:"primaryKey" json:"id"`
+    AnggotaID   uint      `json:"anggota_id"`
+    PinjamanID  *uint     `gorm:"index" json:"pinjaman_id"` // diisi untuk dokumen pengajuan pinjaman
+    Jenis       string    `gorm:"size:32" json:"jenis"` // lihat JenisDokumen; dokumen lama bisa berisi lainnya
+    Filename    string    `gorm:"size:255" json:"filename"`
+    StorageKey  string    `gorm:"size:255" json:"-"`
+    Checksum    string    `gorm:"size:64" json:"checksum"` // SHA-256 isi berkas (hex)
+    Ukuran      int64     `json:"ukuran"`
+    UkuranAsli  int64     `json:"ukuran_asli"` // ukuran sebelum foto dikecilkan/dikompres
+    ContentType string    `gorm:"size:128" json:"content_type"` // hasil deteksi isi berkas, bukan header dari klien
+    Lebar       int       `json:"lebar,omitempty"`
+    Tinggi      int       `json:"tinggi,omitempty"`
+    URL         string    `gorm:"-" json:"url,omitempty"` // tautan bertanda tangan berumur pendek, diisi per permintaan
+    UploadedAt  time.Time `json:"uploaded_at"`
+    DiunggahOleh *uint    `json:"diunggah_oleh"` // kosong untuk dokumen sebelum pengunggah dicatat
+
+    ThumbnailKey string `gorm:"size:255" json:"-"` // kosong untuk PDF dan dokumen lama
+    ThumbnailURL string `gorm:"-" json:"thumbnail_url,omitempty"`
+
+    Versi          int   `gorm:"not null;default:1" json:"versi"`
+    MenggantikanID *uint `json:"menggantikan_id"`
+    DigantiOlehID  *uint `json:"diganti_oleh_id"`
+
+    StatusVerifikasi  string     `gorm:"size:16;not null;default:menunggu" json:"status_verifikasi"` // menunggu | valid | ditolak
+    CatatanVerifikasi string     `gorm:"size:255" json:"catatan_verifikasi"`
+    DiverifikasiOleh  *uint      `json:"diverifikasi_oleh"`
+    DiverifikasiAt    *time.Time `json:"diverifikasi_at"`
+
+    DihapusAt   *time.Time `gorm:"index" json:"dihapus_at"`
+    DihapusOleh *uint      `json:"dihapus_oleh"`
+    AlasanHapus string     `gorm:"size:255" json:"alasan_hapus"`
+}
+
+// DokumenAkses mencatat setiap penerbitan tautan dan unduhan dokumen anggota
+type DokumenAkses struct {
     ID        uint      `gorm:"primaryKey" json:"id"`
-    AnggotaID uint      `json:"anggota_id"`
-    Filename  string    `gorm:"size:255" json:"filename"`
-    URL       string    `gorm:"size:255" json:"url"`
-    UploadedAt time.Time `json:"uploaded_at"`
+    DokumenID uint      `gorm:"index" json:"dokumen_id"`
+    UserID    uint      `json:"user_id"`
+    Aksi      string    `gorm:"size:16" json:"aksi"` // tautan | unduh
+    IP        string    `gorm:"size:64" json:"ip"`
+    UserAgent string    `gorm:"size:255" json:"user_agent"`
+    CreatedAt time.Time `json:"created_at"`
 }
 
 // AnggotaActivity mencatat aktivitas penting (pendaftaran, verifikasi, aktivasi)
@@ -5,6 +5,8 @@ import "time"
 // Angsuran merepresentasikan jadwal dan pembayaran angsuran untuk pinjaman
 // Jika tanggal_bayar NULL maka angsuran belum dibayar
 // Denda opsional saat terjadi keterlambatan
+// Pembayaran yang dibatalkan lewat koreksi tidak diubah: DikoreksiOlehID menunjuk baris pengganti
+// (belum dibayar, KoreksiDariID menunjuk baris asal) yang menjadi tagihan berlaku untuk angsuran ke- itu
 type Angsuran struct {
     ID                 uint       `gorm:"primaryKey" json:"id"`
     PinjamanID         uint       `json:"pinjaman_id"`
@@ -13,5 +15,8 @@ type Angsuran struct {
     Jumlah             float64    `json:"jumlah"`
     TanggalBayar       *time.Time `json:"tanggal_bayar"`
     Denda              float64    `json:"denda"`
+    NomorBukti         string     `gorm:"size:64;index" json:"nomor_bukti"`
+    DikoreksiOlehID    *uint      `gorm:"index" json:"dikoreksi_oleh_id"`
+    KoreksiDariID      *uint      `json:"koreksi_dari_id"`
     CreatedAt          time.Time  `json:"created_at"`
 }
\ No newline at end of file
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// AuditLog mencatat aksi penting pengguna (persetujuan, pembatalan, perubahan data sensitif)
+type AuditLog struct {
+    ID        uint      `gorm:"primaryKey" json:"id"`
+    UserID    uint      `json:"user_id"`
+    Action    string    `gorm:"size:64" json:"action"`
+    Entity    string    `gorm:"size:64;index:idx_audit_entity" json:"entity"`
+    EntityID  uint      `gorm:"index:idx_audit_entity" json:"entity_id"`
+    Note      string    `gorm:"size:255" json:"note"`
+    Timestamp time.Time `json:"timestamp"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// MandatAutoDebit adalah persetujuan anggota agar angsuran satu pinjaman didebit otomatis dari simpanan
+// sukarelanya. Satu mandat per pinjaman; mandat yang dicabut tetap tersimpan dengan Aktif=false.
+type MandatAutoDebit struct {
+    ID          uint       `gorm:"primaryKey" json:"id"`
+    PinjamanID  uint       `gorm:"uniqueIndex" json:"pinjaman_id"`
+    AnggotaID   uint       `gorm:"index" json:"anggota_id"`
+    Aktif       bool       `json:"aktif"`
+    DibuatOleh  uint       `json:"dibuat_oleh"`
+    Catatan     string     `gorm:"size:255" json:"catatan"`
+    DicabutOleh *uint      `json:"dicabut_oleh"`
+    DicabutAt   *time.Time `json:"dicabut_at"`
+    CreatedAt   time.Time  `json:"created_at"`
+    UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// AutoDebitLog mencatat hasil auto-debit satu angsuran, paling banyak satu baris per angsuran per tanggal;
+// percobaan ulang pada hari yang sama memperbarui baris tersebut. Angsuran yang gagal didebit dicoba lagi
+// pada proses harian berikutnya selama belum dibayar.
+// Status: berhasil | gagal
+type AutoDebitLog struct {
+    ID            uint      `gorm:"primaryKey" json:"id"`
+    MandatID      uint      `gorm:"index" json:"mandat_id"`
+    PinjamanID    uint      `gorm:"index" json:"pinjaman_id"`
+    AngsuranID    uint      `gorm:"uniqueIndex:idx_auto_debit_angsuran_tanggal" json:"angsuran_id"`
+    Tanggal       string    `gorm:"size:10;uniqueIndex:idx_auto_debit_angsuran_tanggal;index" json:"tanggal"` // YYYY-MM-DD
+    Status        string    `gorm:"size:16;index" json:"status"`
+    Tagihan       float64   `json:"tagihan"` // angsuran + denda
+    SaldoSukarela float64   `json:"saldo_sukarela"` // saldo sebelum didebit
+    Alasan        string    `gorm:"size:255" json:"alasan"`
+    NomorBukti    string    `gorm:"size:64" json:"nomor_bukti"`
+    CreatedAt     time.Time `json:"created_at"`
+    UpdatedAt     time.Time `json:"updated_at"`
+}
@@ -0,0 +1,99 @@
+package models
+
+import "time"
+
+// Akun adalah bagan akun sederhana untuk jurnal koperasi.
+// Tipe: aset | kewajiban | ekuitas | pendapatan | beban
+type Akun struct {
+    Kode      string    `gorm:"primaryKey;size:16" json:"kode"`
+    Nama      string    `gorm:"size:128" json:"nama"`
+    Tipe      string    `gorm:"size:16" json:"tipe"`
+    CreatedAt time.Time `json:"created_at"`
+}
+
+// Kode akun yang dipakai posting otomatis
+const (
+    AkunKas               = "1-101"
+    AkunBank              = "1-102"
+    AkunPiutangPinjaman   = "1-201"
+    AkunSimpananSukarela  = "2-101"
+    AkunSimpananKhusus    = "2-102"
+    AkunSimpananBerjangka = "2-103"
+    AkunUtangPajakBunga   = "2-201"
+    AkunUtangPremi        = "2-202"
+    AkunSimpananPokok     = "3-101"
+    AkunSimpananWajib     = "3-102"
+    AkunSHUTahunBerjalan  = "3-201"
+    AkunSHUBelumDibagi    = "3-202"
+    AkunSaldoAwal         = "3-901"
+    AkunPendapatanBunga   = "4-101"
+    AkunPendapatanDenda   = "4-102"
+    AkunPendapatanPenalti = "4-103"
+    AkunPendapatanAdmin   = "4-104"
+    AkunPendapatanProvisi = "4-105"
+    AkunPendapatanLain    = "4-901"
+    AkunBebanBungaSimpan  = "5-101"
+    AkunBebanOperasional  = "5-201"
+    AkunBebanLain         = "5-901"
+)
+
+// BaganAkunDefault di-seed saat migrasi jika belum ada
+var BaganAkunDefault = []Akun{
+    {Kode: AkunKas, Nama: "Kas", Tipe: "aset"},
+    {Kode: AkunBank, Nama: "Bank", Tipe: "aset"},
+    {Kode: AkunPiutangPinjaman, Nama: "Piutang Pinjaman Anggota", Tipe: "aset"},
+    {Kode: AkunSimpananSukarela, Nama: "Simpanan Sukarela", Tipe: "kewajiban"},
+    {Kode: AkunSimpananKhusus, Nama: "Simpanan Khusus", Tipe: "kewajiban"},
+    {Kode: AkunSimpananBerjangka, Nama: "Simpanan Berjangka", Tipe: "kewajiban"},
+    {Kode: AkunUtangPajakBunga, Nama: "Utang Pajak Bunga Simpanan", Tipe: "kewajiban"},
+    {Kode: AkunUtangPremi, Nama: "Utang Premi Asuransi Jiwa Kredit", Tipe: "kewajiban"},
+    {Kode: AkunSimpananPokok, Nama: "Simpanan Pokok", Tipe: "ekuitas"},
+    {Kode: AkunSimpananWajib, Nama: "Simpanan Wajib", Tipe: "ekuitas"},
+    {Kode: AkunSHUTahunBerjalan, Nama: "SHU Tahun Berjalan", Tipe: "ekuitas"},
+    {Kode: AkunSHUBelumDibagi, Nama: "SHU Belum Dibagi", Tipe: "ekuitas"},
+    {Kode: AkunSaldoAwal, Nama: "Ekuitas Saldo Awal", Tipe: "ekuitas"},
+    {Kode: AkunPendapatanBunga, Nama: "Pendapatan Bunga Pinjaman", Tipe: "pendapatan"},
+    {Kode: AkunPendapatanDenda, Nama: "Pendapatan Denda", Tipe: "pendapatan"},
+    {Kode: AkunPendapatanPenalti, Nama: "Pendapatan Penalti Simpanan Berjangka", Tipe: "pendapatan"},
+    {Kode: AkunPendapatanAdmin, Nama: "Pendapatan Administrasi Pinjaman", Tipe: "pendapatan"},
+    {Kode: AkunPendapatanProvisi, Nama: "Pendapatan Provisi Pinjaman", Tipe: "pendapatan"},
+    {Kode: AkunPendapatanLain, Nama: "Pendapatan Lain-lain", Tipe: "pendapatan"},
+    {Kode: AkunBebanBungaSimpan, Nama: "Beban Bunga Simpanan", Tipe: "beban"},
+    {Kode: AkunBebanOperasional, Nama: "Beban Operasional", Tipe: "beban"},
+    {Kode: AkunBebanLain, Nama: "Beban Lain-lain", Tipe: "beban"},
+}
+
+// Jurnal adalah header jurnal umum. Sumber + RefID menunjuk transaksi asal
+// (simpanan, angsuran, pinjaman, simpanan_berjangka, kas, tutup_buku).
+type Jurnal struct {
+    ID         uint           `gorm:"primaryKey" json:"id"`
+    Tanggal    time.Time      `gorm:"index" json:"tanggal"`
+    Keterangan string         `gorm:"size:255" json:"keterangan"`
+    Sumber     string         `gorm:"size:32;index:idx_jurnal_ref" json:"sumber"`
+    RefID      uint           `gorm:"index:idx_jurnal_ref" json:"ref_id"`
+    Details    []JurnalDetail `json:"details"`
+    CreatedAt  time.Time      `json:"created_at"`
+}
+
+// JurnalDetail adalah baris debit/kredit satu jurnal
+type JurnalDetail struct {
+    ID       uint    `gorm:"primaryKey" json:"id"`
+    JurnalID uint    `gorm:"index" json:"jurnal_id"`
+    AkunKode string  `gorm:"size:16;index" json:"akun_kode"`
+    Debit    float64 `json:"debit"`
+    Kredit   float64 `json:"kredit"`
+}
+
+// Kas mencatat penerimaan/pengeluaran kas di luar transaksi simpan pinjam (mis. biaya operasional).
+// Jenis: masuk | keluar
+type Kas struct {
+    ID         uint      `gorm:"primaryKey" json:"id"`
+    Tanggal    time.Time `json:"tanggal"`
+    Jenis      string    `gorm:"size:16" json:"jenis"`
+    Keterangan string    `gorm:"size:255" json:"keterangan"`
+    Jumlah     float64   `json:"jumlah"`
+    AkunLawan  string    `gorm:"size:16" json:"akun_lawan"`
+    Ref        string    `gorm:"size:64" json:"ref"`
+    NomorBukti string    `gorm:"size:64;index" json:"nomor_bukti"`
+    CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// SesiKasir adalah satu sesi laci kas teller: dibuka dengan modal awal, setiap transaksi tunai di loket
+// terikat ke sesi, ditutup dengan hitungan uang per pecahan, lalu disetujui supervisor. Satu petugas
+// paling banyak memiliki satu sesi berstatus buka.
+// Status: buka | ditutup | disetujui
+type SesiKasir struct {
+    ID                uint             `gorm:"primaryKey" json:"id"`
+    UserID            uint             `gorm:"index" json:"user_id"`
+    Tanggal           string           `gorm:"size:10;index" json:"tanggal"` // YYYY-MM-DD saat dibuka
+    Status            string           `gorm:"size:16;index" json:"status"`
+    SaldoAwal         float64          `json:"saldo_awal"`
+    TotalMasuk        float64          `json:"total_masuk"`
+    TotalKeluar       float64          `json:"total_keluar"`
+    SaldoSeharusnya   float64          `json:"saldo_seharusnya"` // saldo awal + masuk - keluar
+    SaldoDihitung     float64          `json:"saldo_dihitung"`
+    Selisih           float64          `json:"selisih"` // dihitung - seharusnya; negatif berarti kas kurang
+    CatatanBuka       string           `gorm:"size:255" json:"catatan_buka"`
+    CatatanTutup      string           `gorm:"size:255" json:"catatan_tutup"`
+    DibukaAt          time.Time        `json:"dibuka_at"`
+    DitutupAt         *time.Time       `json:"ditutup_at"`
+    DisetujuiOleh     *uint            `json:"disetujui_oleh"`
+    DisetujuiAt       *time.Time       `json:"disetujui_at"`
+    CatatanSupervisor string           `gorm:"size:255" json:"catatan_supervisor"`
+    Transaksi         []TransaksiKasir `gorm:"foreignKey:SesiID" json:"transaksi,omitempty"`
+    Hitungan          []HitunganKasir  `gorm:"foreignKey:SesiID" json:"hitungan,omitempty"`
+    CreatedAt         time.Time        `json:"created_at"`
+    UpdatedAt         time.Time        `json:"updated_at"`
+}
+
+// TransaksiKasir mengikat satu transaksi tunai di loket ke sesi kasir
+// Jenis: setoran | penarikan | angsuran | pencairan | penempatan | pencairan_awal | kas_masuk | kas_keluar |
+//        penyelesaian | waris | koreksi
+// Sumber: simpanan | angsuran | pinjaman | berjangka | kas | penyelesaian | pembagian_waris | koreksi
+//         (RefID menunjuk baris sumber)
+// Arah: masuk | keluar
+type TransaksiKasir struct {
+    ID         uint      `gorm:"primaryKey" json:"id"`
+    SesiID     uint      `gorm:"index" json:"sesi_id"`
+    Jenis      string    `gorm:"size:16;index" json:"jenis"`
+    Sumber     string    `gorm:"size:16;index:idx_transaksi_kasir_ref" json:"sumber"`
+    RefID      uint      `gorm:"index:idx_transaksi_kasir_ref" json:"ref_id"`
+    Arah       string    `gorm:"size:8" json:"arah"`
+    Jumlah     float64   `json:"jumlah"`
+    NomorBukti string    `gorm:"size:64" json:"nomor_bukti"`
+    Keterangan string    `gorm:"size:255" json:"keterangan"`
+    CreatedAt  time.Time `json:"created_at"`
+}
+
+// HitunganKasir adalah jumlah lembar/keping satu pecahan uang saat tutup kas
+type HitunganKasir struct {
+    ID       uint    `gorm:"primaryKey" json:"id"`
+    SesiID   uint    `gorm:"index" json:"sesi_id"`
+    Pecahan  float64 `json:"pecahan"`
+    Lembar   int     `json:"lembar"`
+    Subtotal float64 `json:"subtotal"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Koreksi adalah permintaan pembatalan (reversal) transaksi simpanan atau pembayaran angsuran.
+// Transaksi asal tidak pernah dihapus; saat disetujui dibuat entri pembalik yang menunjuk ke transaksi asal.
+// Sumber: simpanan | angsuran
+// Status: menunggu | disetujui | ditolak
+type Koreksi struct {
+    ID               uint       `gorm:"primaryKey" json:"id"`
+    Sumber           string     `gorm:"size:32;index:idx_koreksi_ref" json:"sumber"`
+    RefID            uint       `gorm:"index:idx_koreksi_ref" json:"ref_id"`
+    Alasan           string     `gorm:"size:255" json:"alasan"`
+    Status           string     `gorm:"size:32" json:"status"`
+    DiajukanOleh     uint       `json:"diajukan_oleh"`
+    DisetujuiOleh    *uint      `json:"disetujui_oleh"`
+    Catatan          string     `gorm:"size:255" json:"catatan"`
+    DiputuskanAt     *time.Time `json:"diputuskan_at"`
+    // Salinan nilai transaksi asal saat koreksi dieksekusi
+    TanggalTransaksi *time.Time `json:"tanggal_transaksi"`
+    Jumlah           float64    `json:"jumlah"`
+    Denda            float64    `json:"denda"`
+    // ID entri pembalik: baris simpanan pembalik, atau baris angsuran pengganti untuk sumber angsuran
+    PembalikID       *uint      `json:"pembalik_id"`
+    CreatedAt        time.Time  `json:"created_at"`
+    UpdatedAt        time.Time  `json:"updated_at"`
+}
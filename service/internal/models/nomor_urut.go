@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// NomorUrut menyimpan nomor terakhir per jenis dokumen dan periode reset
+// (Periode: "2025" untuk reset tahunan, "2025-01" untuk bulanan, "-" jika tidak pernah reset).
+type NomorUrut struct {
+    Jenis     string    `gorm:"primaryKey;size:32" json:"jenis"`
+    Periode   string    `gorm:"primaryKey;size:16" json:"periode"`
+    Nilai     int64     `json:"nilai"`
+    UpdatedAt time.Time `json:"updated_at"`
+}
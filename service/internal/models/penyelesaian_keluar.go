@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// PenyelesaianKeluar mencatat perhitungan hak dan kewajiban anggota saat keluar/meninggal.
+// Selisih positif dibayarkan ke anggota, negatif ditagihkan ke anggota.
+type PenyelesaianKeluar struct {
+    ID               uint      `gorm:"primaryKey" json:"id"`
+    AnggotaID        uint      `gorm:"index" json:"anggota_id"`
+    Tanggal          time.Time `json:"tanggal"`
+    Status           string    `gorm:"size:16" json:"status"` // keluar | meninggal
+    Alasan           string    `gorm:"size:255" json:"alasan"`
+    SimpananPokok    float64   `json:"simpanan_pokok"`
+    SimpananWajib    float64   `json:"simpanan_wajib"`
+    SimpananSukarela float64   `json:"simpanan_sukarela"`
+    SimpananKhusus   float64   `json:"simpanan_khusus"`
+    TotalSimpanan    float64   `json:"total_simpanan"`
+    PokokPinjaman    float64   `json:"pokok_pinjaman"`
+    BungaPinjaman    float64   `json:"bunga_pinjaman"`
+    DendaPinjaman    float64   `json:"denda_pinjaman"`
+    TotalKewajiban   float64   `json:"total_kewajiban"`
+    Selisih          float64   `json:"selisih"`
+    DiprosesOleh     uint      `json:"diproses_oleh"`
+    CreatedAt        time.Time `json:"created_at"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// PeriodeAkuntansi menandai status buku bulanan.
+// Bulan yang belum memiliki baris dianggap masih terbuka.
+// Status: terbuka | ditutup
+type PeriodeAkuntansi struct {
+    ID          uint       `gorm:"primaryKey" json:"id"`
+    Tahun       int        `gorm:"uniqueIndex:idx_periode_bulan" json:"tahun"`
+    Bulan       int        `gorm:"uniqueIndex:idx_periode_bulan" json:"bulan"`
+    Status      string     `gorm:"size:16" json:"status"`
+    DitutupOleh *uint      `json:"ditutup_oleh"`
+    DitutupAt   *time.Time `json:"ditutup_at"`
+    DibukaOleh  *uint      `json:"dibuka_oleh"`
+    DibukaAt    *time.Time `json:"dibuka_at"`
+    Catatan     string     `gorm:"size:255" json:"catatan"`
+    CreatedAt   time.Time  `json:"created_at"`
+    UpdatedAt   time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// TahapPersetujuan adalah satu tingkat persetujuan pinjaman (mis. analisis petugas, ketua, komite pengurus).
+// Rantai tahap disalin dari settings.pinjaman.persetujuan saat pengajuan sehingga perubahan pengaturan
+// tidak mengubah pinjaman yang sedang diproses.
+// Status: menunggu | disetujui | ditolak | dibatalkan (tahap sisa setelah pinjaman ditolak)
+type TahapPersetujuan struct {
+    ID               uint                   `gorm:"primaryKey" json:"id"`
+    PinjamanID       uint                   `gorm:"index" json:"pinjaman_id"`
+    Urutan           int                    `json:"urutan"`
+    Nama             string                 `gorm:"size:64" json:"nama"`
+    Roles            string                 `gorm:"size:255" json:"roles"` // dipisah koma
+    MinimalPenyetuju int                    `json:"minimal_penyetuju"`
+    Status           string                 `gorm:"size:16;default:menunggu" json:"status"`
+    SelesaiAt        *time.Time             `json:"selesai_at"`
+    CreatedAt        time.Time              `json:"created_at"`
+    UpdatedAt        time.Time              `json:"updated_at"`
+
+    Keputusan        []KeputusanPersetujuan `gorm:"foreignKey:TahapID" json:"keputusan"`
+}
+
+// KeputusanPersetujuan mencatat keputusan satu pengguna pada satu tahap.
+// Keputusan: setuju | tolak
+type KeputusanPersetujuan struct {
+    ID         uint      `gorm:"primaryKey" json:"id"`
+    PinjamanID uint      `gorm:"index" json:"pinjaman_id"`
+    TahapID    uint      `gorm:"index" json:"tahap_id"`
+    UserID     uint      `json:"user_id"`
+    Role       string    `gorm:"size:64" json:"role"`
+    Keputusan  string    `gorm:"size:16" json:"keputusan"`
+    Catatan    string    `gorm:"type:text" json:"catatan"`
+    CreatedAt  time.Time `json:"created_at"`
+}
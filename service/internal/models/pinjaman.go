@@ -3,20 +3,40 @@ package models
 import "time"
 
 // Pinjaman merepresentasikan entitas pinjaman
-// Status: pengajuan | disetujui | berjalan | lunas
+// Status: pengajuan | disetujui | ditolak | berjalan | lunas. Pengajuan menjadi disetujui hanya setelah
+// seluruh tahap persetujuan selesai. Biaya pinjaman ditetapkan saat pencairan: BiayaPotongan dikurangkan
+// dari dana yang diterima anggota (PencairanBersih), BiayaDiangsur menambah piutang dan dibagi rata ke angsuran.
+// MetodePencairan: tunai (kas teller) | sukarela (disetor ke simpanan sukarela anggota) | transfer (rekening bank)
 type Pinjaman struct {
-    ID                uint       `gorm:"primaryKey" json:"id"`
-    AnggotaID         uint       `json:"anggota_id"`
-    NomorPinjaman     string     `gorm:"size:64;uniqueIndex" json:"nomor_pinjaman"`
-    TanggalPengajuan  time.Time  `json:"tanggal_pengajuan"`
-    TanggalDisetujui  *time.Time `json:"tanggal_disetujui"`
-    TanggalPencairan  *time.Time `json:"tanggal_pencairan"`
-    Nominal           float64    `json:"nominal"`
-    TenorBulan        int        `json:"tenor_bulan"`
-    BungaPersen       float64    `json:"bunga_persen"`
-    Status            string     `gorm:"size:32" json:"status"`
-    CreatedAt         time.Time  `json:"created_at"`
-    UpdatedAt         time.Time  `json:"updated_at"`
+    ID                  uint       `gorm:"primaryKey" json:"id"`
+    AnggotaID           uint       `json:"anggota_id"`
+    NomorPinjaman       string     `gorm:"size:64;uniqueIndex" json:"nomor_pinjaman"`
+    Kategori            string     `gorm:"size:64" json:"kategori"`
+    DiajukanOleh        *uint      `json:"diajukan_oleh"`
+    TanggalPengajuan    time.Time  `json:"tanggal_pengajuan"`
+    TanggalDisetujui    *time.Time `json:"tanggal_disetujui"`
+    TanggalPencairan    *time.Time `json:"tanggal_pencairan"`
+    Nominal             float64    `json:"nominal"`
+    TenorBulan          int        `json:"tenor_bulan"`
+    BungaPersen         float64    `json:"bunga_persen"`
+    Status              string     `gorm:"size:32" json:"status"`
+    TanggalDitolak      *time.Time `json:"tanggal_ditolak"`
+    BiayaPotongan       float64    `json:"biaya_potongan"`
+    BiayaDiangsur       float64    `json:"biaya_diangsur"`
+    PencairanBersih     float64    `json:"pencairan_bersih"`
+    NomorBuktiPencairan string     `gorm:"size:64" json:"nomor_bukti_pencairan"`
+    MetodePencairan     string     `gorm:"size:16" json:"metode_pencairan"`
+    BankTujuan          string     `gorm:"size:64" json:"bank_tujuan"`
+    RekeningTujuan      string     `gorm:"size:64" json:"rekening_tujuan"`
+    AtasNamaRekening    string     `gorm:"size:128" json:"atas_nama_rekening"`
+    ReferensiTransfer   string     `gorm:"size:64" json:"referensi_transfer"`
+    DicairkanOleh       *uint      `json:"dicairkan_oleh"`
+    CreatedAt           time.Time  `json:"created_at"`
+    UpdatedAt           time.Time  `json:"updated_at"`
 
-    Angsuran          []Angsuran `json:"-"`
+    Angsuran            []Angsuran         `json:"-"`
+    Persetujuan         []TahapPersetujuan `gorm:"foreignKey:PinjamanID" json:"persetujuan,omitempty"`
+    Analisis            *AnalisisKredit    `gorm:"foreignKey:PinjamanID" json:"analisis,omitempty"`
+    Biaya               []BiayaPinjaman    `gorm:"foreignKey:PinjamanID" json:"biaya,omitempty"`
+    AutoDebit           *MandatAutoDebit   `gorm:"foreignKey:PinjamanID" json:"auto_debit,omitempty"`
 }
\ No newline at end of file
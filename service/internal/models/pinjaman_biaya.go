@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// BiayaPinjaman adalah rincian satu komponen biaya yang dikenakan saat pencairan pinjaman, disalin dari
+// settings.financial.biaya_pinjaman agar perubahan pengaturan tidak mengubah pinjaman yang sudah cair.
+// Jenis: administrasi | provisi | asuransi
+// Pembebanan: potong (dikurangkan dari pencairan) | angsuran (ditambahkan rata ke setiap angsuran)
+type BiayaPinjaman struct {
+    ID         uint      `gorm:"primaryKey" json:"id"`
+    PinjamanID uint      `gorm:"index" json:"pinjaman_id"`
+    Jenis      string    `gorm:"size:32" json:"jenis"`
+    Nama       string    `gorm:"size:128" json:"nama"`
+    Persen     float64   `json:"persen"`
+    Jumlah     float64   `json:"jumlah"`
+    Pembebanan string    `gorm:"size:16" json:"pembebanan"`
+    AkunKode   string    `gorm:"size:16" json:"akun_kode"`
+    CreatedAt  time.Time `json:"created_at"`
+}
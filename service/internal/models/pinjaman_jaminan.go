@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// JenisAgunan adalah jenis barang jaminan yang diterima koperasi
+var JenisAgunan = []string{"bpkb_motor", "bpkb_mobil", "sertifikat_tanah", "emas", "simpanan_berjangka", "lainnya"}
+
+// Penjamin adalah penjamin satu pinjaman: anggota lain (AnggotaID diisi) atau orang luar.
+// NilaiPenjaminan adalah bagian pinjaman yang dijamin dan dihitung ke eksposur penjamin
+// selama statusnya aktif. Status: aktif | dilepas
+type Penjamin struct {
+    ID              uint       `gorm:"primaryKey" json:"id"`
+    PinjamanID      uint       `gorm:"index" json:"pinjaman_id"`
+    AnggotaID       *uint      `gorm:"index" json:"anggota_id"`
+    Nama            string     `gorm:"size:128" json:"nama"`
+    NIK             string     `gorm:"size:32;index" json:"nik"`
+    Hubungan        string     `gorm:"size:32" json:"hubungan"`
+    Alamat          string     `gorm:"size:255" json:"alamat"`
+    Telp            string     `gorm:"size:32" json:"telp"`
+    Penghasilan     float64    `json:"penghasilan"`
+    NilaiPenjaminan float64    `json:"nilai_penjaminan"`
+    Status          string     `gorm:"size:16;not null;default:aktif" json:"status"`
+    DilepasAt       *time.Time `json:"dilepas_at"`
+    CreatedAt       time.Time  `json:"created_at"`
+    UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// Agunan adalah barang jaminan satu pinjaman beserta nilai taksiran dan status penyimpanannya.
+// StatusPenyimpanan: diajukan (belum diserahkan) | disimpan (fisik/dokumen asli dipegang koperasi)
+// | dikembalikan (diserahkan kembali ke pemilik)
+type Agunan struct {
+    ID                uint       `gorm:"primaryKey" json:"id"`
+    PinjamanID        uint       `gorm:"index" json:"pinjaman_id"`
+    Jenis             string     `gorm:"size:32" json:"jenis"`
+    Deskripsi         string     `gorm:"size:255" json:"deskripsi"`
+    NomorBukti        string     `gorm:"size:64" json:"nomor_bukti"` // nomor BPKB/sertifikat/bilyet
+    AtasNama          string     `gorm:"size:128" json:"atas_nama"`
+    NilaiTaksiran     float64    `json:"nilai_taksiran"`
+    TanggalTaksiran   time.Time  `json:"tanggal_taksiran"`
+    StatusPenyimpanan string     `gorm:"size:16;not null;default:diajukan" json:"status_penyimpanan"`
+    LokasiPenyimpanan string     `gorm:"size:128" json:"lokasi_penyimpanan"`
+    DiterimaOleh      *uint      `json:"diterima_oleh"`
+    DiterimaAt        *time.Time `json:"diterima_at"`
+    DikembalikanOleh  *uint      `json:"dikembalikan_oleh"`
+    DikembalikanAt    *time.Time `json:"dikembalikan_at"`
+    CreatedAt         time.Time  `json:"created_at"`
+    UpdatedAt         time.Time  `json:"updated_at"`
+}
@@ -10,10 +10,27 @@ import "time"
 // - settings.categories
 // - settings.format
 // - settings.integrations
+// - settings.keanggotaan
 //
-// Lihat README bagian Pengaturan untuk spesifikasi.
+// Struktur, nilai bawaan dan validasi tiap key didefinisikan di package settings.
 type Setting struct {
     Key       string    `gorm:"primaryKey;size:128;column:key" json:"key"`
     Value     string    `gorm:"type:text" json:"value"`
+    Versi     int       `gorm:"not null;default:0" json:"versi"`
     UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SettingHistory mencatat setiap perubahan nilai setting. Versi berurutan per key dan
+// sama dengan Setting.Versi setelah perubahan tersimpan. Baris riwayat tidak pernah diubah.
+type SettingHistory struct {
+    ID        uint      `gorm:"primaryKey" json:"id"`
+    Key       string    `gorm:"size:128;not null;uniqueIndex:idx_setting_versi" json:"key"`
+    Versi     int       `gorm:"not null;uniqueIndex:idx_setting_versi" json:"versi"`
+    NilaiLama string    `gorm:"type:text" json:"nilai_lama"` // kosong jika sebelumnya memakai nilai bawaan
+    NilaiBaru string    `gorm:"type:text" json:"nilai_baru"`
+    Aksi      string    `gorm:"size:16;not null" json:"aksi"` // ubah | rollback
+    DariVersi *int      `json:"dari_versi,omitempty"`        // versi yang dipulihkan saat rollback
+    Alasan    string    `gorm:"size:255" json:"alasan"`
+    UserID    uint      `json:"user_id"`
+    CreatedAt time.Time `json:"created_at"`
 }
\ No newline at end of file
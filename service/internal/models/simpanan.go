@@ -4,13 +4,21 @@ import "time"
 
 // Simpanan merepresentasikan transaksi simpanan per anggota
 // Mendukung setoran dan penarikan dengan saldo_akhir per jenis.
+// Tipe bunga dan pajak_bunga dibuat oleh proses kredit bunga akhir bulan.
+// Tipe koreksi_debit/koreksi_kredit adalah entri pembalik yang menunjuk transaksi asal lewat koreksi_dari_id.
+// Sumber membedakan transaksi loket dari transaksi yang dibuat sistem; hanya transaksi loket yang boleh dikoreksi.
 type Simpanan struct {
-    ID         uint      `gorm:"primaryKey" json:"id"`
-    AnggotaID  uint      `json:"anggota_id"`
-    Jenis      string    `gorm:"size:32" json:"jenis"`      // wajib | sukarela | khusus
-    Tipe       string    `gorm:"size:16" json:"tipe"`       // setoran | penarikan
-    Tanggal    time.Time `json:"tanggal"`
-    Jumlah     float64   `json:"jumlah"`
-    SaldoAkhir float64   `json:"saldo_akhir"`
-    CreatedAt  time.Time `json:"created_at"`
-}
\ No newline at end of file
+    ID              uint      `gorm:"primaryKey" json:"id"`
+    AnggotaID       uint      `json:"anggota_id"`
+    Jenis           string    `gorm:"size:32" json:"jenis"`      // pokok | wajib | sukarela | khusus
+    Tipe            string    `gorm:"size:16" json:"tipe"`       // setoran | penarikan | bunga | pajak_bunga | koreksi_debit | koreksi_kredit
+    Tanggal         time.Time `json:"tanggal"`
+    Jumlah          float64   `json:"jumlah"`
+    SaldoAkhir      float64   `json:"saldo_akhir"`
+    Keterangan      string    `gorm:"size:255" json:"keterangan"`
+    NomorBukti      string    `gorm:"size:64;index" json:"nomor_bukti"` // kuitansi untuk setoran/penarikan tunai
+    Sumber          string    `gorm:"size:16;index" json:"sumber"`      // loket | bunga | auto_debit | pencairan | berjangka | penyelesaian | koreksi
+    KoreksiDariID   *uint     `json:"koreksi_dari_id"`
+    DikoreksiOlehID *uint     `json:"dikoreksi_oleh_id"`
+    CreatedAt       time.Time `json:"created_at"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// SimpananBerjangka merepresentasikan penempatan dana anggota dengan tenor tetap (deposito).
+// Status: aktif | dicairkan | diperpanjang | dipecah
+// Instruksi jatuh tempo: perpanjang_pokok | perpanjang_pokok_bunga | cair_sukarela
+type SimpananBerjangka struct {
+    ID                 uint       `gorm:"primaryKey" json:"id"`
+    AnggotaID          uint       `json:"anggota_id"`
+    NomorBilyet        string     `gorm:"size:64;uniqueIndex" json:"nomor_bilyet"`
+    TanggalPenempatan  time.Time  `json:"tanggal_penempatan"`
+    TenorBulan         int        `json:"tenor_bulan"`
+    BungaPersen        float64    `json:"bunga_persen"`
+    Nominal            float64    `json:"nominal"`
+    TanggalJatuhTempo  time.Time  `json:"tanggal_jatuh_tempo"`
+    Instruksi          string     `gorm:"size:32" json:"instruksi"`
+    Status             string     `gorm:"size:32" json:"status"`
+    Bunga              float64    `json:"bunga"`
+    Pajak              float64    `json:"pajak"`
+    Penalti            float64    `json:"penalti"`
+    TanggalPencairan   *time.Time `json:"tanggal_pencairan"`
+    PerpanjanganDariID *uint      `json:"perpanjangan_dari_id"`
+    CreatedAt          time.Time  `json:"created_at"`
+    UpdatedAt          time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// SkorKredit adalah riwayat skor kredit internal anggota (0-100), paling banyak satu baris per anggota
+// per tanggal; perhitungan ulang pada hari yang sama memperbarui baris tersebut.
+// Komponen bernilai 0-100 dan digabung dengan bobot settings.skor_kredit.
+// Grade: A (>= 80) | B (>= 65) | C (>= 50) | D (>= 35) | E
+// Kolektibilitas: 1 lancar | 2 dalam perhatian khusus | 3 kurang lancar | 4 diragukan | 5 macet
+// Sumber: harian | manual | pengajuan
+type SkorKredit struct {
+    ID                   uint      `gorm:"primaryKey" json:"id"`
+    AnggotaID            uint      `gorm:"uniqueIndex:idx_skor_anggota_tanggal" json:"anggota_id"`
+    Tanggal              string    `gorm:"size:10;uniqueIndex:idx_skor_anggota_tanggal" json:"tanggal"` // YYYY-MM-DD
+    Skor                 int       `json:"skor"`
+    Grade                string    `gorm:"size:1" json:"grade"`
+    Sumber               string    `gorm:"size:16" json:"sumber"`
+
+    SkorKetepatan        float64   `json:"skor_ketepatan"`
+    SkorDenda            float64   `json:"skor_denda"`
+    SkorKolektibilitas   float64   `json:"skor_kolektibilitas"`
+    SkorSimpanan         float64   `json:"skor_simpanan"`
+    SkorMasaKeanggotaan  float64   `json:"skor_masa_keanggotaan"`
+
+    AngsuranTepatWaktu   int       `json:"angsuran_tepat_waktu"`
+    AngsuranTerlambat    int       `json:"angsuran_terlambat"`
+    TotalDenda           float64   `json:"total_denda"`
+    Kolektibilitas       int       `json:"kolektibilitas"`
+    HariTunggakan        int       `json:"hari_tunggakan"`
+    BulanWajib           int       `json:"bulan_wajib"`           // bulan yang dinilai untuk simpanan wajib
+    BulanWajibDisetor    int       `json:"bulan_wajib_disetor"`   // bulan dengan setoran simpanan wajib
+    MasaKeanggotaanBulan int       `json:"masa_keanggotaan_bulan"`
+    CreatedAt            time.Time `json:"created_at"`
+    UpdatedAt            time.Time `json:"updated_at"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// TutupBuku mencatat penutupan tahun buku. Laporan menyimpan snapshot JSON
+// (laba rugi, SHU, neraca saldo) sehingga laporan tahun tertutup tidak berubah.
+type TutupBuku struct {
+    ID          uint      `gorm:"primaryKey" json:"id"`
+    Tahun       int       `gorm:"uniqueIndex" json:"tahun"`
+    SHU         float64   `json:"shu"`
+    JurnalID    uint      `json:"jurnal_id"`
+    DitutupOleh uint      `json:"ditutup_oleh"`
+    DitutupAt   time.Time `json:"ditutup_at"`
+    Laporan     string    `gorm:"type:text" json:"-"`
+    CreatedAt   time.Time `json:"created_at"`
+}
@@ -5,15 +5,23 @@ import (
     "gorm.io/gorm"
 
     "koperasi-desa/service/internal/controllers"
+    "koperasi-desa/service/internal/storage"
 )
 
-func RegisterRoutes(r *gin.Engine, db *gorm.DB) {
+func RegisterRoutes(r *gin.Engine, db *gorm.DB, st storage.Storage) {
     uc := controllers.NewUserController(db)
-    ac := controllers.NewAnggotaController(db)
+    ac := controllers.NewAnggotaController(db, st)
     sc := controllers.NewSimpananController(db)
-    pc := controllers.NewPinjamanController(db)
+    sbc := controllers.NewSimpananBerjangkaController(db)
+    pc := controllers.NewPinjamanController(db, st)
     ic := controllers.NewAngsuranController(db)
     stc := controllers.NewSettingsController(db)
+    kc := controllers.NewKoreksiController(db)
+    auc := controllers.NewAuditController(db)
+    prc := controllers.NewPeriodeController(db)
+    jc := controllers.NewJurnalController(db)
+    tbc := controllers.NewTutupBukuController(db)
+    ksc := controllers.NewKasirController(db)
 
     api := r.Group("/api")
     {
@@ -30,27 +38,111 @@ func RegisterRoutes(r *gin.Engine, db *gorm.DB) {
         api.PUT("/anggota/:id", ac.UpdateAnggota)
         api.POST("/anggota/:id/verify", ac.VerifyAnggota)
         api.POST("/anggota/:id/activate", ac.ActivateAnggota)
+        api.POST("/anggota/:id/status", ac.UbahStatus)
+        api.POST("/anggota/skor-kredit/proses", ac.ProsesSkor)
+        api.GET("/anggota/:id/skor-kredit", ac.SkorKredit)
+        api.POST("/anggota/:id/skor-kredit", ac.HitungSkorKredit)
+        api.GET("/anggota/:id/penyelesaian-keluar", ac.PenyelesaianKeluar)
+        api.POST("/anggota/:id/keluar", ac.Keluar)
+        api.GET("/anggota/:id/ahli-waris", ac.ListAhliWaris)
+        api.PUT("/anggota/:id/ahli-waris", ac.SimpanAhliWaris)
+        api.POST("/anggota/:id/klaim-meninggal", ac.KlaimMeninggal)
         api.POST("/anggota/:id/documents", ac.UploadDocuments)
         api.GET("/anggota/:id/documents", ac.ListDocuments)
+        api.GET("/anggota/:id/documents/bundel", ac.BundelDokumen)
+        api.POST("/anggota/:id/documents/:docId/ganti", ac.GantiDokumen)
+        api.POST("/anggota/:id/documents/:docId/verifikasi", ac.VerifikasiDokumen)
+        api.DELETE("/anggota/:id/documents/:docId", ac.HapusDokumen)
+        api.GET("/anggota/:id/documents/:docId/url", ac.TautanDokumen)
+        api.GET("/anggota/:id/documents/:docId/unduh", ac.UnduhDokumen)
+        api.GET("/anggota/:id/documents/:docId/thumbnail", ac.ThumbnailDokumen)
+        api.GET("/anggota/:id/documents/:docId/akses", ac.AksesDokumen)
 
         // Simpanan routes
         api.GET("/simpanan", sc.ListSimpanan)
         api.POST("/simpanan/setoran", sc.Setoran)
         api.POST("/simpanan/penarikan", sc.Penarikan)
+        api.POST("/simpanan/bunga", sc.KreditBunga)
+
+        // Simpanan berjangka (deposito)
+        api.GET("/simpanan-berjangka", sbc.ListSimpananBerjangka)
+        api.POST("/simpanan-berjangka", sbc.Penempatan)
+        api.GET("/simpanan-berjangka/:id", sbc.GetSimpananBerjangka)
+        api.POST("/simpanan-berjangka/:id/pencairan-awal", sbc.PencairanAwal)
+        api.POST("/simpanan-berjangka/proses-jatuh-tempo", sbc.JatuhTempo)
 
         // Pinjaman routes
         api.GET("/pinjaman", pc.ListPinjaman)
         api.POST("/pinjaman/pengajuan", pc.Pengajuan)
         api.POST("/pinjaman/verifikasi", pc.Verifikasi)
         api.POST("/pinjaman/pencairan", pc.Pencairan)
+        api.GET("/pinjaman/persetujuan/antrian", pc.AntrianPersetujuan)
+        api.GET("/pinjaman/auto-debit/log", pc.LogAutoDebit)
+        api.POST("/pinjaman/auto-debit/proses", pc.ProsesAutoDebitManual)
+        api.GET("/pinjaman/:id/persetujuan", pc.Persetujuan)
+        api.GET("/pinjaman/:id/analisis", pc.Analisis)
+        api.PUT("/pinjaman/:id/analisis", pc.SimpanAnalisis)
+        api.POST("/pinjaman/:id/setujui", pc.Setujui)
+        api.POST("/pinjaman/:id/tolak", pc.Tolak)
+        api.GET("/pinjaman/:id/pencairan", pc.RincianPencairan)
+        api.GET("/pinjaman/:id/slip-pencairan", pc.SlipPencairan)
+        api.GET("/pinjaman/:id/auto-debit", pc.MandatAutoDebit)
+        api.PUT("/pinjaman/:id/auto-debit", pc.SimpanMandatAutoDebit)
+        api.POST("/pinjaman/:id/documents", pc.UploadDokumen)
+        api.GET("/pinjaman/:id/documents", pc.ListDokumen)
+        api.GET("/pinjaman/:id/jaminan", pc.Jaminan)
+        api.POST("/pinjaman/:id/penjamin", pc.TambahPenjamin)
+        api.DELETE("/pinjaman/:id/penjamin/:penjaminId", pc.LepasPenjamin)
+        api.POST("/pinjaman/:id/agunan", pc.TambahAgunan)
+        api.DELETE("/pinjaman/:id/agunan/:agunanId", pc.HapusAgunan)
+        api.POST("/pinjaman/:id/agunan/:agunanId/status", pc.StatusAgunan)
 
         // Angsuran routes
         api.GET("/angsuran", ic.ListAngsuran)
         api.POST("/angsuran/bayar", ic.Bayar)
 
+        // Koreksi (reversal) transaksi simpanan & angsuran
+        api.GET("/koreksi", kc.ListKoreksi)
+        api.POST("/koreksi", kc.AjukanKoreksi)
+        api.POST("/koreksi/:id/setujui", kc.SetujuiKoreksi)
+        api.POST("/koreksi/:id/tolak", kc.TolakKoreksi)
+
+        // Periode akuntansi (tutup/buka buku bulanan)
+        api.GET("/periode", prc.ListPeriode)
+        api.POST("/periode/tutup", prc.TutupPeriode)
+        api.POST("/periode/buka", prc.BukaPeriode)
+
+        // Kas & jurnal
+        api.GET("/kas", jc.ListKas)
+        api.POST("/kas/in", jc.KasMasuk)
+        api.POST("/kas/out", jc.KasKeluar)
+        api.GET("/akun", jc.ListAkun)
+        api.GET("/jurnal", jc.ListJurnal)
+        api.GET("/jurnal/neraca-saldo", jc.NeracaSaldo)
+
+        // Sesi laci kas teller & laporan harian
+        api.POST("/kasir/sesi/buka", ksc.BukaSesi)
+        api.GET("/kasir/sesi/aktif", ksc.SesiAktif)
+        api.GET("/kasir/sesi", ksc.ListSesi)
+        api.GET("/kasir/sesi/:id", ksc.GetSesi)
+        api.POST("/kasir/sesi/:id/tutup", ksc.TutupSesi)
+        api.POST("/kasir/sesi/:id/setujui", ksc.SetujuiSesi)
+        api.GET("/kasir/laporan", ksc.LaporanHarian)
+
+        // Tutup buku tahunan
+        api.GET("/tutup-buku", tbc.ListTutupBuku)
+        api.POST("/tutup-buku", tbc.TutupBuku)
+        api.GET("/tutup-buku/:tahun", tbc.GetTutupBuku)
+        api.GET("/tutup-buku/:tahun/cek", tbc.CekTutupBuku)
+
+        // Audit log
+        api.GET("/audit-log", auc.ListAuditLog)
+
         // Settings routes
         api.GET("/settings", stc.ListSettings)
         api.GET("/settings/:key", stc.GetSetting)
         api.PUT("/settings/:key", stc.PutSetting)
+        api.GET("/settings/:key/history", stc.HistorySetting)
+        api.POST("/settings/:key/rollback", stc.RollbackSetting)
     }
 }
\ No newline at end of file
@@ -0,0 +1,239 @@
+// Package settings berisi definisi terdaftar untuk setiap key pada tabel settings:
+// struktur Go, nilai bawaan, dan validasi. Controller membaca pengaturan lewat
+// accessor bertipe (LoadFinancial, LoadFormat, ...) bukan langsung dari models.Setting.
+package settings
+
+import (
+    "bytes"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "sort"
+
+    "gorm.io/gorm"
+
+    "koperasi-desa/service/internal/models"
+)
+
+const (
+    KeyProfile      = "settings.profile"
+    KeyFinancial    = "settings.financial"
+    KeyCategories   = "settings.categories"
+    KeyFormat       = "settings.format"
+    KeyIntegrations = "settings.integrations"
+    KeyKeanggotaan  = "settings.keanggotaan"
+    KeyDokumen      = "settings.dokumen"
+    KeyPinjaman     = "settings.pinjaman"
+    KeySkorKredit   = "settings.skor_kredit"
+    KeyKasir        = "settings.kasir"
+)
+
+var ErrKeyTidakDikenal = errors.New("key setting tidak dikenal")
+
+// Nilai adalah struktur isi satu key; Validate dipanggil setiap kali nilai disimpan
+type Nilai interface {
+    Validate() error
+}
+
+// penggantiPeta diimplementasikan nilai yang memiliki field map dengan isi bawaan. Decode JSON ke map
+// yang sudah terisi menggabungkan kunci, sehingga kunci bawaan yang sengaja dihilangkan dari payload
+// tetap ikut. kosongkanPeta mengosongkan map yang ada di payload agar isinya menggantikan map bawaan.
+type penggantiPeta interface {
+    kosongkanPeta(raw map[string]json.RawMessage)
+}
+
+// Definisi mendaftarkan satu key. Bawaan mengembalikan pointer baru berisi nilai bawaan;
+// JSON yang tersimpan di-decode di atasnya sehingga field yang tidak diisi memakai nilai bawaan.
+type Definisi struct {
+    Key        string
+    Keterangan string
+    Bawaan     func() Nilai
+}
+
+// defaultFormat dipakai untuk jenis dokumen yang tidak diatur di settings.format
+var defaultFormat = Format{
+    "anggota":            {Template: "AGT/{YYYY}/{SEQ:5}", Reset: "tahunan"},
+    "pinjaman":           {Template: "PJ-{YYYY}-{SEQ:6}", Reset: "tahunan"},
+    "simpanan_berjangka": {Template: "SB-{YYYY}-{SEQ:6}", Reset: "tahunan"},
+    "kuitansi":           {Template: "KW/{YYYY}{MM}/{SEQ:5}", Reset: "bulanan"},
+}
+
+var registry = map[string]Definisi{
+    KeyProfile: {KeyProfile, "Profil koperasi (nama, alamat, kontak)", func() Nilai { return &Profile{} }},
+    KeyFinancial: {KeyFinancial, "Parameter finansial (suku bunga, biaya pinjaman, bunga simpanan, pajak, simpanan berjangka)", func() Nilai {
+        return &Financial{SukuBungaDefault: 1.5}
+    }},
+    KeyCategories: {KeyCategories, "Kategori simpanan & pinjaman", func() Nilai {
+        return &Categories{Simpanan: []string{"wajib", "sukarela", "khusus"}, Pinjaman: []string{"produktif", "konsumtif"}}
+    }},
+    KeyFormat: {KeyFormat, "Format nomor anggota, pinjaman, bilyet dan kuitansi", func() Nilai {
+        f := Format{}
+        for k, v := range defaultFormat { f[k] = v }
+        return &f
+    }},
+    KeyIntegrations: {KeyIntegrations, "Integrasi Email/WhatsApp Gateway", func() Nilai { return &Integrations{} }},
+    // bawaan: terverifikasi, dokumen KTP, dan simpanan pokok sudah dibayar
+    KeyKeanggotaan: {KeyKeanggotaan, "Syarat aktivasi anggota", func() Nilai {
+        return &Keanggotaan{Aktivasi: Aktivasi{WajibVerifikasi: true, DokumenWajib: []string{"ktp"}, WajibSimpananPokok: true}}
+    }},
+    // foto ponsel 5-10 MB diterima lalu dikecilkan ke 2000 px
+    KeyDokumen: {KeyDokumen, "Batas ukuran unggahan dan pengolahan foto dokumen anggota", func() Nilai {
+        return &Dokumen{MaksUkuranKB: 15360, MaksDimensiPx: 2000, KualitasJPEG: 80, ThumbnailPx: 320}
+    }},
+    // bawaan: penjamin dan agunan tidak wajib, LTV 70%; emas dan simpanan berjangka lebih likuid.
+    // Persetujuan satu tahap dan analisis kredit tidak wajib, sama dengan alur verifikasi sebelum rantai persetujuan;
+    // tahap ketua/komite dan analisis wajib diaktifkan lewat settings sesuai AD/ART.
+    // Auto-debit angsuran menyisakan saldo sukarela Rp 10 ribu
+    KeyPinjaman: {KeyPinjaman, "Penjamin, agunan, batas loan-to-value, rantai persetujuan, analisis kredit dan auto-debit angsuran pinjaman", func() Nilai {
+        return &Pinjaman{
+            MaksLTVPersen:   70,
+            MaksLTVPerJenis: map[string]float64{"emas": 85, "simpanan_berjangka": 90},
+            Persetujuan: []AturanPersetujuan{
+                {Nama: "verifikasi", Roles: []string{"petugas", "admin", "bendahara", "ketua"}},
+            },
+            Analisis: AnalisisKredit{
+                Wajib:                   false,
+                Bobot:                   map[string]float64{"character": 25, "capacity": 30, "capital": 15, "collateral": 15, "condition": 15},
+                MaksRasioAngsuranPersen: 40,
+                MinimalRasioTepatWaktu:  80,
+                SkorLayak:               3.5,
+                SkorBersyarat:           2.5,
+            },
+            AutoDebit: AutoDebit{SaldoMinimal: 10_000},
+        }
+    }},
+    // bawaan tanpa batas minimal; anggota tanpa riwayat pinjaman/simpanan mendapat nilai netral 60
+    KeySkorKredit: {KeySkorKredit, "Bobot dan batas skor kredit internal anggota", func() Nilai {
+        return &SkorKredit{
+            Bobot:                map[string]float64{"ketepatan": 35, "denda": 10, "kolektibilitas": 25, "simpanan": 20, "masa_keanggotaan": 10},
+            NilaiTanpaRiwayat:    60,
+            BatasDenda:           500_000,
+            PeriodeSimpananBulan: 12,
+            MasaPenuhBulan:       60,
+        }
+    }},
+    KeyKasir: {KeyKasir, "Sesi laci kas teller dan pecahan uang untuk penghitungan tutup kas", func() Nilai {
+        return &Kasir{
+            WajibSesi: false,
+            Pecahan:   []float64{100_000, 50_000, 20_000, 10_000, 5_000, 2_000, 1_000, 500, 200, 100},
+        }
+    }},
+}
+
+// Daftar mengembalikan semua definisi terurut berdasarkan key
+func Daftar() []Definisi {
+    out := make([]Definisi, 0, len(registry))
+    for _, d := range registry { out = append(out, d) }
+    sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+    return out
+}
+
+// Cari mengembalikan definisi untuk key
+func Cari(key string) (Definisi, bool) {
+    d, ok := registry[key]
+    return d, ok
+}
+
+// Bawaan mengembalikan nilai bawaan key dalam bentuk JSON
+func Bawaan(key string) (string, error) {
+    d, ok := registry[key]
+    if !ok { return "", ErrKeyTidakDikenal }
+    b, err := json.Marshal(d.Bawaan())
+    return string(b), err
+}
+
+// Validasi memeriksa nilai baru untuk key: field yang tidak dikenal ditolak, field yang tidak diisi
+// memakai nilai bawaan (map yang diisi menggantikan map bawaan), lalu aturan Validate dijalankan. Hasilnya JSON lengkap yang siap disimpan.
+func Validasi(key, value string) (string, error) {
+    d, ok := registry[key]
+    if !ok { return "", ErrKeyTidakDikenal }
+    v := d.Bawaan()
+    kosongkanPetaBawaan(v, []byte(value))
+    dec := json.NewDecoder(bytes.NewReader([]byte(value)))
+    dec.DisallowUnknownFields()
+    if err := dec.Decode(v); err != nil { return "", fmt.Errorf("%s tidak valid: %w", key, err) }
+    if dec.More() { return "", fmt.Errorf("%s tidak valid: data berlebih setelah JSON", key) }
+    if err := v.Validate(); err != nil { return "", fmt.Errorf("%s tidak valid: %w", key, err) }
+    b, err := json.Marshal(v)
+    return string(b), err
+}
+
+// kosongkanPetaBawaan mengosongkan map bawaan v yang kuncinya diisi pada payload data
+func kosongkanPetaBawaan(v Nilai, data []byte) {
+    p, ok := v.(penggantiPeta)
+    if !ok { return }
+    var raw map[string]json.RawMessage
+    if err := json.Unmarshal(data, &raw); err != nil { return }
+    p.kosongkanPeta(raw)
+}
+
+// muat membaca nilai tersimpan di atas nilai bawaan; key yang belum diisi mengembalikan nilai bawaan.
+// Decode tidak ketat agar nilai lama dengan field yang sudah tidak dipakai tetap terbaca.
+func muat(db *gorm.DB, key string) (Nilai, error) {
+    v := registry[key].Bawaan()
+    var s models.Setting
+    if err := db.First(&s, "`key` = ?", key).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            return v, nil
+        }
+        return v, err
+    }
+    if s.Value == "" {
+        return v, nil
+    }
+    kosongkanPetaBawaan(v, []byte(s.Value))
+    if err := json.Unmarshal([]byte(s.Value), v); err != nil {
+        return v, fmt.Errorf("%s tidak valid: %w", key, err)
+    }
+    return v, nil
+}
+
+func LoadProfile(db *gorm.DB) (Profile, error) {
+    v, err := muat(db, KeyProfile)
+    return *v.(*Profile), err
+}
+
+func LoadFinancial(db *gorm.DB) (Financial, error) {
+    v, err := muat(db, KeyFinancial)
+    return *v.(*Financial), err
+}
+
+func LoadCategories(db *gorm.DB) (Categories, error) {
+    v, err := muat(db, KeyCategories)
+    return *v.(*Categories), err
+}
+
+func LoadFormat(db *gorm.DB) (Format, error) {
+    v, err := muat(db, KeyFormat)
+    return *v.(*Format), err
+}
+
+func LoadIntegrations(db *gorm.DB) (Integrations, error) {
+    v, err := muat(db, KeyIntegrations)
+    return *v.(*Integrations), err
+}
+
+func LoadKeanggotaan(db *gorm.DB) (Keanggotaan, error) {
+    v, err := muat(db, KeyKeanggotaan)
+    return *v.(*Keanggotaan), err
+}
+
+func LoadDokumen(db *gorm.DB) (Dokumen, error) {
+    v, err := muat(db, KeyDokumen)
+    return *v.(*Dokumen), err
+}
+
+func LoadPinjaman(db *gorm.DB) (Pinjaman, error) {
+    v, err := muat(db, KeyPinjaman)
+    return *v.(*Pinjaman), err
+}
+
+func LoadSkorKredit(db *gorm.DB) (SkorKredit, error) {
+    v, err := muat(db, KeySkorKredit)
+    return *v.(*SkorKredit), err
+}
+
+func LoadKasir(db *gorm.DB) (Kasir, error) {
+    v, err := muat(db, KeyKasir)
+    return *v.(*Kasir), err
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+    "context"
+    "errors"
+    "io"
+    "io/fs"
+    "os"
+    "path/filepath"
+)
+
+// Local menyimpan berkas di bawah satu direktori. Direktori ini tidak boleh disajikan sebagai static file.
+type Local struct { Dir string }
+
+func NewLocal(dir string) *Local { return &Local{Dir: dir} }
+
+func (l *Local) path(key string) (string, error) {
+    k, err := bersihkanKey(key)
+    if err != nil { return "", err }
+    return filepath.Join(l.Dir, filepath.FromSlash(k)), nil
+}
+
+// Put menulis ke berkas sementara lalu rename, sehingga pembaca tidak pernah melihat berkas setengah jadi
+func (l *Local) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+    p, err := l.path(key)
+    if err != nil { return err }
+    if err := os.MkdirAll(filepath.Dir(p), 0750); err != nil { return errorf("put", key, err) }
+    tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+    if err != nil { return errorf("put", key, err) }
+    defer os.Remove(tmp.Name())
+    if _, err := io.Copy(tmp, r); err != nil {
+        tmp.Close()
+        return errorf("put", key, err)
+    }
+    if err := tmp.Chmod(0640); err != nil {
+        tmp.Close()
+        return errorf("put", key, err)
+    }
+    if err := tmp.Close(); err != nil { return errorf("put", key, err) }
+    if err := os.Rename(tmp.Name(), p); err != nil { return errorf("put", key, err) }
+    return nil
+}
+
+func (l *Local) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+    p, err := l.path(key)
+    if err != nil { return nil, err }
+    f, err := os.Open(p)
+    if errors.Is(err, fs.ErrNotExist) { return nil, ErrTidakAda }
+    if err != nil { return nil, errorf("get", key, err) }
+    return f, nil
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+    p, err := l.path(key)
+    if err != nil { return err }
+    if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) { return errorf("delete", key, err) }
+    return nil
+}
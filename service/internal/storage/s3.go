@@ -0,0 +1,172 @@
+package storage
+
+import (
+    "context"
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "fmt"
+    "io"
+    "net/http"
+    "net/url"
+    "sort"
+    "strings"
+    "time"
+)
+
+// S3Config adalah koneksi ke object storage S3-compatible. Endpoint memuat skema,
+// mis. http://localhost:9000 untuk MinIO lokal; request memakai path-style (endpoint/bucket/key).
+type S3Config struct {
+    Endpoint  string
+    Region    string
+    Bucket    string
+    AccessKey string
+    SecretKey string
+}
+
+// S3 adalah backend S3-compatible dengan tanda tangan AWS Signature Version 4
+type S3 struct {
+    cfg    S3Config
+    base   *url.URL
+    client *http.Client
+}
+
+func NewS3(cfg S3Config) (*S3, error) {
+    if cfg.Endpoint == "" || cfg.Bucket == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+        return nil, fmt.Errorf("S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY dan S3_SECRET_KEY wajib diisi")
+    }
+    u, err := url.Parse(strings.TrimRight(cfg.Endpoint, "/"))
+    if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+        return nil, fmt.Errorf("S3_ENDPOINT tidak valid: %s", cfg.Endpoint)
+    }
+    if cfg.Region == "" { cfg.Region = "us-east-1" }
+    return &S3{cfg: cfg, base: u, client: &http.Client{Timeout: 5 * time.Minute}}, nil
+}
+
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+    req, err := s.request(ctx, http.MethodPut, key, r)
+    if err != nil { return err }
+    req.ContentLength = size
+    if contentType != "" { req.Header.Set("Content-Type", contentType) }
+    resp, err := s.do(req)
+    if err != nil { return errorf("put", key, err) }
+    resp.Body.Close()
+    return nil
+}
+
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+    req, err := s.request(ctx, http.MethodGet, key, nil)
+    if err != nil { return nil, err }
+    resp, err := s.do(req)
+    if err != nil {
+        if err == ErrTidakAda { return nil, err }
+        return nil, errorf("get", key, err)
+    }
+    return resp.Body, nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+    req, err := s.request(ctx, http.MethodDelete, key, nil)
+    if err != nil { return err }
+    resp, err := s.do(req)
+    if err != nil && err != ErrTidakAda { return errorf("delete", key, err) }
+    if resp != nil { resp.Body.Close() }
+    return nil
+}
+
+func (s *S3) request(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+    k, err := bersihkanKey(key)
+    if err != nil { return nil, err }
+    u := *s.base
+    u.Path = u.Path + "/" + s.cfg.Bucket + "/" + k
+    u.RawPath = encodePath(u.Path)
+    return http.NewRequestWithContext(ctx, method, u.String(), body)
+}
+
+// do menandatangani dan mengirim request; status selain 2xx dikembalikan sebagai error
+func (s *S3) do(req *http.Request) (*http.Response, error) {
+    s.sign(req, time.Now().UTC())
+    resp, err := s.client.Do(req)
+    if err != nil { return nil, err }
+    if resp.StatusCode == http.StatusNotFound {
+        resp.Body.Close()
+        return nil, ErrTidakAda
+    }
+    if resp.StatusCode/100 != 2 {
+        msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+        resp.Body.Close()
+        return nil, fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+    }
+    return resp, nil
+}
+
+// sign menambahkan header Authorization AWS SigV4. Jika X-Amz-Content-Sha256 belum diisi, payload tidak
+// ikut di-hash (UNSIGNED-PAYLOAD) agar upload dapat di-stream tanpa dibaca dua kali.
+func (s *S3) sign(req *http.Request, now time.Time) {
+    amzDate := now.Format("20060102T150405Z")
+    tanggal := now.Format("20060102")
+    payload := req.Header.Get("X-Amz-Content-Sha256")
+    if payload == "" {
+        payload = "UNSIGNED-PAYLOAD"
+        req.Header.Set("X-Amz-Content-Sha256", payload)
+    }
+    req.Header.Set("X-Amz-Date", amzDate)
+
+    headers := map[string]string{"host": req.URL.Host}
+    for k, v := range req.Header {
+        k = strings.ToLower(k)
+        if strings.HasPrefix(k, "x-amz-") || k == "content-type" || k == "range" { headers[k] = strings.Join(v, ",") }
+    }
+    names := make([]string, 0, len(headers))
+    for k := range headers { names = append(names, k) }
+    sort.Strings(names)
+    var canonHeaders strings.Builder
+    for _, k := range names { canonHeaders.WriteString(k + ":" + strings.TrimSpace(headers[k]) + "\n") }
+    signed := strings.Join(names, ";")
+
+    canonical := strings.Join([]string{
+        req.Method,
+        req.URL.EscapedPath(),
+        req.URL.Query().Encode(),
+        canonHeaders.String(),
+        signed,
+        payload,
+    }, "\n")
+    scope := tanggal + "/" + s.cfg.Region + "/s3/aws4_request"
+    toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hexSHA256(canonical)
+
+    kunci := hmacSHA256([]byte("AWS4"+s.cfg.SecretKey), tanggal)
+    kunci = hmacSHA256(kunci, s.cfg.Region)
+    kunci = hmacSHA256(kunci, "s3")
+    kunci = hmacSHA256(kunci, "aws4_request")
+    sig := hex.EncodeToString(hmacSHA256(kunci, toSign))
+
+    req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", s.cfg.AccessKey, scope, signed, sig))
+}
+
+// encodePath meng-encode tiap segmen path sesuai aturan URI encoding SigV4:
+// hanya A-Z a-z 0-9 - _ . ~ yang dibiarkan apa adanya
+func encodePath(p string) string {
+    var b strings.Builder
+    for i := 0; i < len(p); i++ {
+        c := p[i]
+        switch {
+        case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-', c == '_', c == '.', c == '~', c == '/':
+            b.WriteByte(c)
+        default:
+            fmt.Fprintf(&b, "%%%02X", c)
+        }
+    }
+    return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+    m := hmac.New(sha256.New, key)
+    m.Write([]byte(data))
+    return m.Sum(nil)
+}
+
+func hexSHA256(s string) string {
+    h := sha256.Sum256([]byte(s))
+    return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,73 @@
+// Package storage menyimpan berkas dokumen di backend yang dapat diganti:
+// disk lokal (bawaan) atau object storage S3-compatible seperti MinIO.
+// Berkas tidak pernah disajikan langsung; controller membacanya lewat Get setelah memeriksa akses.
+package storage
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "log"
+    "os"
+    "path"
+    "strings"
+)
+
+var (
+    ErrTidakAda    = errors.New("berkas tidak ditemukan di storage")
+    ErrKeyTidakSah = errors.New("key storage tidak valid")
+)
+
+// Storage adalah backend penyimpanan berkas. Key berbentuk path relatif dengan pemisah "/",
+// mis. anggota/12/3f9c...e1.pdf
+type Storage interface {
+    Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+    Get(ctx context.Context, key string) (io.ReadCloser, error)
+    Delete(ctx context.Context, key string) error
+}
+
+// InitStorage membuat backend dari environment:
+//   STORAGE_BACKEND = local (bawaan) | s3
+//   STORAGE_DIR     = direktori untuk backend local (bawaan ./uploads)
+//   S3_ENDPOINT, S3_REGION, S3_BUCKET, S3_ACCESS_KEY, S3_SECRET_KEY untuk backend s3
+func InitStorage() Storage {
+    switch backend := getenv("STORAGE_BACKEND", "local"); backend {
+    case "local":
+        dir := getenv("STORAGE_DIR", "uploads")
+        log.Printf("storage: disk lokal %s", dir)
+        return NewLocal(dir)
+    case "s3":
+        s, err := NewS3(S3Config{
+            Endpoint:  os.Getenv("S3_ENDPOINT"),
+            Region:    getenv("S3_REGION", "us-east-1"),
+            Bucket:    os.Getenv("S3_BUCKET"),
+            AccessKey: os.Getenv("S3_ACCESS_KEY"),
+            SecretKey: os.Getenv("S3_SECRET_KEY"),
+        })
+        if err != nil { log.Fatalf("storage: %v", err) }
+        log.Printf("storage: s3 %s/%s", s.cfg.Endpoint, s.cfg.Bucket)
+        return s
+    default:
+        log.Fatalf("storage: STORAGE_BACKEND %q tidak dikenal", backend)
+        return nil
+    }
+}
+
+// bersihkanKey menolak key absolut atau yang keluar dari root dengan ".."
+func bersihkanKey(key string) (string, error) {
+    if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") { return "", ErrKeyTidakSah }
+    k := path.Clean(key)
+    if k == "." || k == ".." || strings.HasPrefix(k, "../") { return "", ErrKeyTidakSah }
+    return k, nil
+}
+
+func getenv(key, def string) string {
+    v := os.Getenv(key)
+    if v == "" {
+        return def
+    }
+    return v
+}
+
+func errorf(op, key string, err error) error { return fmt.Errorf("storage %s %s: %w", op, key, err) }
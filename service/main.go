@@ -10,11 +10,14 @@ import (
     "github.com/joho/godotenv"
     "gorm.io/gorm"
 
+    "koperasi-desa/service/internal/controllers"
     dbpkg "koperasi-desa/service/internal/database"
+    "koperasi-desa/service/internal/jobs"
     "koperasi-desa/service/internal/routes"
+    "koperasi-desa/service/internal/storage"
 )
 
-func setupRouter(db *gorm.DB) *gin.Engine {
+func setupRouter(db *gorm.DB, st storage.Storage) *gin.Engine {
     r := gin.Default()
 
     // CORS untuk frontend Vite
@@ -31,11 +34,11 @@ func setupRouter(db *gorm.DB) *gin.Engine {
         c.JSON(200, gin.H{"status": "ok"})
     })
 
-    // Static files untuk dokumen upload
-    r.Static("/uploads", "./uploads")
+    // Dokumen upload sengaja tidak disajikan sebagai static file;
+    // unduh lewat tautan bertanda tangan /api/anggota/:id/documents/:docId/unduh
 
     // Register routes
-    routes.RegisterRoutes(r, db)
+    routes.RegisterRoutes(r, db, st)
     return r
 }
 
@@ -43,7 +46,13 @@ func main() {
     _ = godotenv.Load(".env")
 
     db := dbpkg.InitDB()
-    r := setupRouter(db)
+    // transaksi sebelum buku besar dipasang belum berjurnal; posting saldo awalnya
+    if err := controllers.PostingSaldoAwal(db); err != nil {
+        log.Printf("posting saldo awal gagal: %v", err)
+    }
+    st := storage.InitStorage()
+    r := setupRouter(db, st)
+    jobs.Start(db)
 
     port := os.Getenv("PORT")
     if port == "" {
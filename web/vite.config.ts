@@ -12,10 +12,6 @@ export default defineConfig({
         target: 'http://127.0.0.1:8080',
         changeOrigin: true,
       },
-      '/uploads': {
-        target: 'http://127.0.0.1:8080',
-        changeOrigin: true,
-      },
     },
   },
   resolve: {